@@ -7,21 +7,23 @@ package autotitle
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
 	"time"
 
-	"github.com/mydehq/autotitle/internal/backup"
 	"github.com/mydehq/autotitle/internal/config"
 	"github.com/mydehq/autotitle/internal/database"
+	"github.com/mydehq/autotitle/internal/identify"
 	"github.com/mydehq/autotitle/internal/matcher"
 	"github.com/mydehq/autotitle/internal/provider"
-	_ "github.com/mydehq/autotitle/internal/provider/filler" // Register filler sources
+	"github.com/mydehq/autotitle/internal/provider/filler" // Also registers the filler sources
 	"github.com/mydehq/autotitle/internal/renamer"
 	"github.com/mydehq/autotitle/internal/tagger"
 	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
 	"github.com/mydehq/autotitle/internal/version"
 )
 
@@ -37,6 +39,27 @@ type (
 	MediaType       = types.MediaType
 	OperationStatus = types.OperationStatus
 	EventType       = types.EventType
+	MergeConfig     = types.MergeConfig
+	GlobalConfig    = types.GlobalConfig
+	MigrationReport = database.MigrationReport
+	ImportReport    = database.ImportReport
+	RefreshReport   = database.RefreshReport
+	IdentifyResult  = identify.Result
+	Candidate       = identify.Candidate
+	RestoreReport   = types.RestoreReport
+	RestoreConflict = types.RestoreConflict
+	BackupUsage     = types.BackupUsage
+	BackupIssue     = types.BackupIssue
+	VerifyReport    = types.VerifyReport
+	PruneReport     = types.PruneReport
+
+	RenameStage          = types.RenameStage
+	RenameEvent          = types.RenameEvent
+	EpisodeNotFoundEvent = types.EpisodeNotFoundEvent
+	CollisionEvent       = types.CollisionEvent
+	BackupEvent          = types.BackupEvent
+	TagEvent             = types.TagEvent
+	FetchEvent           = types.FetchEvent
 
 	Pattern      = matcher.Pattern
 	TemplateVars = matcher.TemplateVars
@@ -54,6 +77,10 @@ const (
 	StatusSuccess = types.StatusSuccess
 	StatusSkipped = types.StatusSkipped
 	StatusFailed  = types.StatusFailed
+
+	RenamePlanned = types.RenamePlanned
+	RenameApplied = types.RenameApplied
+	RenameFailed  = types.RenameFailed
 )
 
 // Option is a functional option for configuring operations
@@ -69,29 +96,42 @@ type Options struct {
 	Offset *int
 
 	// Init options
-	URL       string
-	FillerURL string
+	URL        string
+	FillerURLs []string
+
+	// FillerPolicy combines classifications when several filler URLs are set
+	FillerPolicy string
+
+	// Fallback providers merged into the primary media
+	FallbackURLs []string
+	Merge        *types.MergeConfig
+
 	Separator string
 	Padding   int
 	Force     bool
 
 	// Search options
 	Provider string
-}
 
-var defaultEvents types.EventHandler
+	// Directories whose map file overrides are exported or imported
+	MapDirs []string
+
+	// Undo options
+	Generation string
+	Only       string
+	Conflict   string
+
+	// Backup prune options
+	PruneOrphaned bool
 
-// SetDefaultEventHandler sets the global event handler for all operations
-// that don't specify their own handler.
-func SetDefaultEventHandler(h types.EventHandler) {
-	defaultEvents = h
+	// Extension points (default to the on-disk cache implementations)
+	DB     types.DatabaseRepository
+	Backup types.BackupManager
 }
 
 func (o *Options) emit(t types.EventType, msg string) {
 	if o.Events != nil {
 		o.Events(types.Event{Type: t, Message: msg})
-	} else if defaultEvents != nil {
-		defaultEvents(types.Event{Type: t, Message: msg})
 	} else if t == types.EventWarning || t == types.EventError {
 		fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
 	}
@@ -122,9 +162,31 @@ func WithURL(url string) Option {
 	return func(o *Options) { o.URL = url }
 }
 
-// WithFiller sets the filler list URL for Init
-func WithFiller(url string) Option {
-	return func(o *Options) { o.FillerURL = url }
+// WithFiller sets the filler list URLs, in priority order. Empty URLs are ignored.
+func WithFiller(urls ...string) Option {
+	return func(o *Options) {
+		o.FillerURLs = nil
+		for _, u := range urls {
+			if u != "" {
+				o.FillerURLs = append(o.FillerURLs, u)
+			}
+		}
+	}
+}
+
+// WithFillerPolicy sets how classifications from several filler URLs are combined
+func WithFillerPolicy(policy string) Option {
+	return func(o *Options) { o.FillerPolicy = policy }
+}
+
+// WithFallbacks sets extra provider URLs whose metadata fills gaps in the primary media
+func WithFallbacks(urls ...string) Option {
+	return func(o *Options) { o.FallbackURLs = urls }
+}
+
+// WithMerge sets the policy used to combine the primary and fallback media
+func WithMerge(cfg *types.MergeConfig) Option {
+	return func(o *Options) { o.Merge = cfg }
 }
 
 // WithSeparator sets the separator for Init
@@ -147,146 +209,278 @@ func WithNoTagging() Option {
 	return func(o *Options) { o.NoTag = true }
 }
 
+// WithDatabase uses a custom database repository instead of the on-disk cache
+func WithDatabase(db types.DatabaseRepository) Option {
+	return func(o *Options) { o.DB = db }
+}
+
+// WithBackupManager uses a custom backup manager for renames and undo
+func WithBackupManager(bm types.BackupManager) Option {
+	return func(o *Options) { o.Backup = bm }
+}
+
 // WithProvider filters search results to a specific provider
 func WithProvider(provider string) Option {
 	return func(o *Options) { o.Provider = provider }
 }
 
-// Rename renames media files in the specified directory
-func Rename(ctx context.Context, path string, opts ...Option) ([]types.RenameOperation, error) {
-	options := &Options{}
+// WithMapDirs includes the overrides of the map files in dirs in database
+// exports, and merges imported overrides into them
+func WithMapDirs(dirs ...string) Option {
+	return func(o *Options) { o.MapDirs = append(o.MapDirs, dirs...) }
+}
 
-	for _, opt := range opts {
-		opt(options)
+// WithGeneration makes Undo revert every backup generation back to and
+// including generation, instead of only the latest
+func WithGeneration(generation string) Option {
+	return func(o *Options) { o.Generation = generation }
+}
+
+// WithOnly limits Undo to files whose original or renamed name matches a
+// glob, or whose episode number is in a range like "1-3,7"
+func WithOnly(only string) Option {
+	return func(o *Options) { o.Only = only }
+}
+
+// WithConflictPolicy decides what Undo does with files changed since the
+// backup: ConflictSkip (default), ConflictForce or ConflictRenameAside
+func WithConflictPolicy(policy string) Option {
+	return func(o *Options) { o.Conflict = policy }
+}
+
+// WithOrphanedBackups makes BackupPrune also delete central generations
+// whose media directory is missing
+func WithOrphanedBackups() Option {
+	return func(o *Options) { o.PruneOrphaned = true }
+}
+
+// Rename renames media files in the specified directory
+func (c *Client) Rename(ctx context.Context, path string, opts ...Option) ([]types.RenameOperation, error) {
+	r, target, media, err := c.renamer(ctx, path, c.options(opts))
+	if err != nil {
+		return nil, err
 	}
 
+	// Execute rename
+	return r.Execute(ctx, path, target, media)
+}
+
+// renamer loads the map file, target and media of path and sets up a
+// renamer for them as options say
+func (c *Client) renamer(ctx context.Context, path string, options *Options) (*renamer.Renamer, *types.Target, *types.Media, error) {
 	// Load config
-	cfg, err := config.Load(path)
+	cfg, err := c.loadMap(path)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	// Resolve target
 	target, err := cfg.ResolveTarget(path)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
+	resolveFillerPaths(target, path)
 
-	// Get provider for URL
-	prov, err := provider.GetProviderForURL(target.URL)
+	// Initialize database
+	db, err := c.database(options)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	// Extract ID
-	id, err := prov.ExtractID(target.URL)
+	// If local options specify filler URLs, prefer those over the config file
+	fillerURLs := []string(target.FillerURLs)
+	if len(options.FillerURLs) > 0 {
+		fillerURLs = options.FillerURLs
+	}
+
+	media, err := c.loadMedia(ctx, target.URL, db, options,
+		WithFiller(fillerURLs...),
+		WithFillerPolicy(target.FillerPolicy),
+		WithFallbacks(target.FallbackURLs...),
+		WithMerge(target.Merge),
+	)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	// Initialize database
-	db, err := database.NewRepository("")
+	media, err = config.ApplyOverrides(media, target.Overrides)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	// If local options specify a FillerURL, prefer that over the config file
-	fillerURL := target.FillerURL
-	if options.FillerURL != "" {
-		fillerURL = options.FillerURL
+	if c.configErr != nil {
+		options.emit(types.EventWarning, fmt.Sprintf("Failed to load global config: %v", c.configErr))
 	}
+	globalCfg := c.config
 
-	force := options.Force
+	// Create renamer
+	r := renamer.New(db, globalCfg.Backup, globalCfg.Formats)
+	if options.DryRun {
+		r.WithDryRun()
+	}
+	if options.NoBackup {
+		r.WithNoBackup()
+	}
+	if h := options.Events; h != nil {
+		r.WithEvents(h)
+	}
+	if options.Backup != nil || c.backup != nil {
+		r.WithBackupManager(c.backupManager(db, options))
+	}
+
+	if options.Offset != nil {
+		r.WithOffset(*options.Offset)
+	}
+
+	// Resolve mapping segments to their own media entries
+	if len(target.Mapping) > 0 {
+		segments, err := c.loadSegments(ctx, target, db, options)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		r.WithSegments(segments)
+	}
+
+	// Wire tagging: on by default if mkvpropedit is available, off if --no-tag
+	taggingEnabled := !options.NoTag && tagger.IsAvailable()
+	if globalCfg.Tagging.Enabled != nil {
+		taggingEnabled = *globalCfg.Tagging.Enabled && !options.NoTag
+	}
+	r.WithTagging(taggingEnabled)
+
+	return r, target, media, nil
+}
+
+// resolveFillerPaths makes the relative dataset paths among the filler URLs
+// of target relative to dir, the directory of its map file, rather than the
+// working directory
+func resolveFillerPaths(target *types.Target, dir string) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	for i, url := range target.FillerURLs {
+		target.FillerURLs[i] = filler.ResolveDatasetURL(url, dir)
+	}
+	for i := range target.Mapping {
+		for j, url := range target.Mapping[i].FillerURLs {
+			target.Mapping[i].FillerURLs[j] = filler.ResolveDatasetURL(url, dir)
+		}
+	}
+}
 
-	dbGenOpts := []Option{
-		WithFiller(fillerURL),
+// loadMedia refreshes the database entry for url and loads it.
+// A failed refresh is only a warning as long as cached data exists.
+func (c *Client) loadMedia(ctx context.Context, url string, db types.DatabaseRepository, options *Options, genOpts ...Option) (*types.Media, error) {
+	prov, err := c.providerForURL(url)
+	if err != nil {
+		return nil, err
+	}
+	id, err := prov.ExtractID(url)
+	if err != nil {
+		return nil, err
 	}
-	if force {
-		dbGenOpts = append(dbGenOpts, WithForce())
+
+	genOpts = append(genOpts, WithDatabase(db), WithEvents(options.Events))
+	if options.Force {
+		genOpts = append(genOpts, WithForce())
 	}
 
-	_, genErr := DBGen(ctx, target.URL, dbGenOpts...)
+	_, genErr := c.DBGen(ctx, url, genOpts...)
 	if genErr != nil {
 		options.emit(types.EventWarning, fmt.Sprintf("Failed to update database: %v", genErr))
 	}
 
-	// Load media from database
 	media, err := db.Load(ctx, prov.Name(), id)
 	if err != nil {
 		return nil, err
 	}
-
 	if media == nil {
 		if genErr != nil {
 			return nil, fmt.Errorf("failed to generate database: %w", genErr)
 		}
 		return nil, types.ErrDatabaseNotFound{Provider: prov.Name(), ID: id}
 	}
+	return media, nil
+}
 
-	// Load global config
-	globalCfg, err := config.LoadGlobal()
-	if err != nil {
-		options.emit(types.EventWarning, fmt.Sprintf("Failed to load global config: %v", err))
-		globalCfg = &types.GlobalConfig{
-			API:    types.APIConfig{RateLimit: 2.0, Timeout: 30},
-			Backup: types.BackupConfig{Enabled: true, DirName: "backups"},
+// loadSegments loads the media for each mapping segment of a target,
+// refreshing the database entries first, with the target's overrides
+// applied in local numbering
+func (c *Client) loadSegments(ctx context.Context, target *types.Target, db types.DatabaseRepository, options *Options) ([]renamer.Segment, error) {
+	return segments(target, func(m types.MappingSegment) (*types.Media, error) {
+		return c.loadMedia(ctx, m.URL, db, options,
+			WithFiller(m.FillerURLs...),
+			WithFillerPolicy(target.FillerPolicy),
+		)
+	})
+}
+
+// segments builds the mapping segments of target from the media load
+// returns for each, applying the target's overrides in local numbering
+func segments(target *types.Target, load func(types.MappingSegment) (*types.Media, error)) ([]renamer.Segment, error) {
+	segments := make([]renamer.Segment, 0, len(target.Mapping))
+	for _, m := range target.Mapping {
+		episodes, err := util.ParseRanges(m.Episodes)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %q: %w", m.Episodes, err)
+		}
+		media, err := load(m)
+		if err != nil {
+			return nil, fmt.Errorf("mapping %q: %w", m.Episodes, err)
+		}
+		start := m.Start
+		if start == 0 {
+			start = 1
+		}
+		seg := renamer.Segment{Episodes: episodes, Start: start}
+		seg.Media, err = config.ApplyMappedOverrides(media, target.Overrides, seg.Resolve)
+		if err != nil {
+			return nil, err
 		}
+		segments = append(segments, seg)
 	}
+	return segments, nil
+}
 
-	// Create renamer
-	r := renamer.New(db, globalCfg.Backup, globalCfg.Formats)
-	if options.DryRun {
-		r.WithDryRun()
-	}
-	if options.NoBackup {
-		r.WithNoBackup()
+// cachedMedia loads the database entry for url without refreshing it
+func (c *Client) cachedMedia(ctx context.Context, db types.DatabaseRepository, url string) (*types.Media, error) {
+	prov, err := c.providerForURL(url)
+	if err != nil {
+		return nil, err
 	}
-	if options.Events != nil {
-		r.WithEvents(options.Events)
-	} else if defaultEvents != nil {
-		r.WithEvents(defaultEvents)
+	id, err := prov.ExtractID(url)
+	if err != nil {
+		return nil, err
 	}
-
-	if options.Offset != nil {
-		r.WithOffset(*options.Offset)
+	media, err := db.Load(ctx, prov.Name(), id)
+	if err != nil {
+		return nil, err
 	}
-
-	// Wire tagging: on by default if mkvpropedit is available, off if --no-tag
-	taggingEnabled := !options.NoTag && tagger.IsAvailable()
-	if globalCfg.Tagging.Enabled != nil {
-		taggingEnabled = *globalCfg.Tagging.Enabled && !options.NoTag
+	if media == nil {
+		return nil, types.ErrDatabaseNotFound{Provider: prov.Name(), ID: id}
 	}
-	r.WithTagging(taggingEnabled)
-
-	// Execute rename
-	return r.Execute(ctx, path, target, media)
+	return media, nil
 }
 
 // Init creates a new map file in the specified directory
-func Init(ctx context.Context, path string, opts ...Option) error {
-	options := &Options{}
-	for _, opt := range opts {
-		opt(options)
-	}
+func (c *Client) Init(ctx context.Context, path string, opts ...Option) error {
+	options := c.options(opts)
 
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return fmt.Errorf("failed to resolve path: %w", err)
 	}
 
-	// Load global config
-	globalCfg, _ := config.LoadGlobal()
+	globalCfg := c.config
 	defaults := config.GetDefaults()
 
 	mapFileName := defaults.MapFile
 	formats := defaults.Formats
-	if globalCfg != nil {
-		if globalCfg.MapFile != "" {
-			mapFileName = globalCfg.MapFile
-		}
-		if len(globalCfg.Formats) > 0 {
-			formats = globalCfg.Formats
-		}
+	if globalCfg.MapFile != "" {
+		mapFileName = globalCfg.MapFile
+	}
+	if len(globalCfg.Formats) > 0 {
+		formats = globalCfg.Formats
 	}
 
 	mapPath := filepath.Join(absPath, mapFileName)
@@ -306,12 +500,12 @@ func Init(ctx context.Context, path string, opts ...Option) error {
 
 	if !scanResult.HasMedia && scanResult.TotalFiles == 0 {
 		if !options.Force {
-			return fmt.Errorf("no files found in directory")
+			return fmt.Errorf("%w (use --force to initialize anyway)", types.ErrNoMediaFiles{Directory: absPath})
 		}
 		options.emit(types.EventWarning, "No files found in directory. Use standard configuration.")
 	} else if !scanResult.HasMedia && scanResult.TotalFiles > 0 {
 		if !options.Force {
-			return fmt.Errorf("no media files found in directory (use --force to initialize anyway)")
+			return fmt.Errorf("%w (use --force to initialize anyway)", types.ErrNoMediaFiles{Directory: absPath})
 		}
 		options.emit(types.EventWarning, "No media files found. Use standard configuration.")
 	}
@@ -321,9 +515,9 @@ func Init(ctx context.Context, path string, opts ...Option) error {
 	if url == "" {
 		url = "https://myanimelist.net/anime/XXXXX/Series_Name"
 	}
-	fillerURL := options.FillerURL
-	if fillerURL == "" {
-		fillerURL = "https://www.animefillerlist.com/shows/series-name"
+	fillerURL := "https://www.animefillerlist.com/shows/series-name"
+	if len(options.FillerURLs) > 0 {
+		fillerURL = options.FillerURLs[0]
 	}
 
 	offset := 0
@@ -333,9 +527,12 @@ func Init(ctx context.Context, path string, opts ...Option) error {
 
 	// Generate default config
 	cfg := config.GenerateDefault(url, fillerURL, scanResult.DetectedPatterns, options.Separator, offset, options.Padding)
+	if len(options.FillerURLs) > 1 {
+		cfg.Targets[0].FillerURLs = options.FillerURLs
+	}
 
 	// If detection failed but we have global patterns, prefer those over hardcoded defaults
-	if len(scanResult.DetectedPatterns) == 0 && globalCfg != nil && len(globalCfg.Patterns) > 0 {
+	if len(scanResult.DetectedPatterns) == 0 && len(globalCfg.Patterns) > 0 {
 		cfg.Targets[0].Patterns = globalCfg.Patterns
 		// Apply overrides to these global patterns
 		for i := range cfg.Targets[0].Patterns {
@@ -356,18 +553,15 @@ func Init(ctx context.Context, path string, opts ...Option) error {
 
 // Tag embeds MKV metadata into all matched files in the given directory
 // without renaming them. Requires mkvpropedit (MKVToolNix) to be installed.
-func Tag(ctx context.Context, path string, opts ...Option) error {
-	options := &Options{}
-	for _, opt := range opts {
-		opt(options)
-	}
+func (c *Client) Tag(ctx context.Context, path string, opts ...Option) error {
+	options := c.options(opts)
 
 	if !tagger.IsAvailable() {
 		return fmt.Errorf("mkvpropedit not found; please install MKVToolNix")
 	}
 
 	// Load config
-	cfg, err := config.Load(path)
+	cfg, err := c.loadMap(path)
 	if err != nil {
 		return err
 	}
@@ -375,25 +569,25 @@ func Tag(ctx context.Context, path string, opts ...Option) error {
 	if err != nil {
 		return err
 	}
-	prov, err := provider.GetProviderForURL(target.URL)
+	db, err := c.database(options)
 	if err != nil {
 		return err
 	}
-	id, err := prov.ExtractID(target.URL)
+	media, err := c.cachedMedia(ctx, db, target.URL)
 	if err != nil {
 		return err
 	}
-	db, err := database.NewRepository("")
+	media, err = config.ApplyOverrides(media, target.Overrides)
 	if err != nil {
 		return err
 	}
-	media, err := db.Load(ctx, prov.Name(), id)
+	segs, err := segments(target, func(m types.MappingSegment) (*types.Media, error) {
+		return c.cachedMedia(ctx, db, m.URL)
+	})
 	if err != nil {
 		return err
 	}
-	if media == nil {
-		return types.ErrDatabaseNotFound{Provider: prov.Name(), ID: id}
-	}
+	candidates := tagCandidates(media, segs)
 
 	// Walk directory and tag MKV files that have matching episodes by filename
 	entries, err := os.ReadDir(path)
@@ -402,12 +596,9 @@ func Tag(ctx context.Context, path string, opts ...Option) error {
 	}
 
 	evtFn := options.Events
-	if evtFn == nil {
-		evtFn = defaultEvents
-	}
-	emit := func(t types.EventType, msg string) {
+	emit := func(t types.EventType, msg string, data types.TagEvent) {
 		if evtFn != nil {
-			evtFn(types.Event{Type: t, Message: msg})
+			evtFn(types.Event{Type: t, Message: msg, Data: data})
 		}
 	}
 
@@ -419,61 +610,92 @@ func Tag(ctx context.Context, path string, opts ...Option) error {
 		if !strings.EqualFold(filepath.Ext(name), ".mkv") {
 			continue
 		}
-		// Try to match episode number from filename using media episode list
-		var matchedEp *types.Episode
-		for i := range media.Episodes {
-			ep := &media.Episodes[i]
+		// Try to match episode number from filename using the local episode list
+		var matched *tagCandidate
+		for i := range candidates {
 			// Simple heuristic: filename contains the episode number
-			epStr := fmt.Sprintf("%d", ep.Number)
+			epStr := fmt.Sprintf("%d", candidates[i].local)
 			if strings.Contains(name, epStr) {
-				matchedEp = ep
+				matched = &candidates[i]
 				break
 			}
 		}
-		if matchedEp == nil {
-			emit(types.EventInfo, fmt.Sprintf("Skipped (no episode match): %s", name))
+		if matched == nil {
+			emit(types.EventInfo, fmt.Sprintf("Skipped (no episode match): %s", name),
+				types.TagEvent{File: name, Status: types.StatusSkipped, Reason: "no episode match"})
+			continue
+		}
+		matchedEp := matched.episode
+		if matchedEp.Skip {
+			emit(types.EventInfo, fmt.Sprintf("Skipped (override): %s", name),
+				types.TagEvent{File: name, Episode: matched.local, Status: types.StatusSkipped, Reason: "override"})
 			continue
 		}
 
 		info := tagger.TagInfo{
 			Title:       matchedEp.Title,
-			Show:        media.Title,
+			Show:        matched.media.Title,
 			EpisodeID:   fmt.Sprintf("%d", matchedEp.Number),
 			EpisodeSort: matchedEp.Number,
 			AirDate:     matchedEp.AirDate,
 		}
 		filePath := filepath.Join(path, name)
 		if err := tagger.TagFile(ctx, filePath, info); err != nil {
-			emit(types.EventWarning, fmt.Sprintf("Tagging failed for %s: %v", name, err))
+			emit(types.EventWarning, fmt.Sprintf("Tagging failed for %s: %v", name, err),
+				types.TagEvent{File: name, Episode: matched.local, Status: types.StatusFailed, Error: err.Error()})
 		} else {
-			emit(types.EventSuccess, fmt.Sprintf("Tagged: %s", name))
+			emit(types.EventSuccess, fmt.Sprintf("Tagged: %s", name),
+				types.TagEvent{File: name, Episode: matched.local, Status: types.StatusSuccess})
 		}
 	}
 	return nil
 }
 
-// DBGen generates a database from a provider URL
-// Returns true if database was generated, false if it already existed
-func DBGen(ctx context.Context, url string, opts ...Option) (bool, error) {
-	options := &Options{}
-	for _, opt := range opts {
-		opt(options)
+// tagCandidate is an episode Tag can match by its local number
+type tagCandidate struct {
+	local   int
+	episode *types.Episode
+	media   *types.Media
+}
+
+// tagCandidates lists the local episodes of a target in ascending order,
+// resolving those covered by a mapping segment to the segment's media
+func tagCandidates(media *types.Media, segments []renamer.Segment) []tagCandidate {
+	var candidates []tagCandidate
+	mapped := func(num int) bool {
+		return slices.ContainsFunc(segments, func(s renamer.Segment) bool {
+			_, ok := s.Resolve(num)
+			return ok
+		})
+	}
+	for i := range media.Episodes {
+		if ep := &media.Episodes[i]; !mapped(ep.Number) {
+			candidates = append(candidates, tagCandidate{local: ep.Number, episode: ep, media: media})
+		}
 	}
+	for _, seg := range segments {
+		for _, local := range seg.Episodes {
+			num, _ := seg.Resolve(local)
+			if ep := seg.Media.GetEpisode(num); ep != nil {
+				candidates = append(candidates, tagCandidate{local: local, episode: ep, media: seg.Media})
+			}
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b tagCandidate) int { return a.local - b.local })
+	return candidates
+}
 
-	// Load global config to configure provider
-	globalCfg, _ := config.LoadGlobal()
+// DBGen generates a database from a provider URL
+// Returns true if database was generated, false if it already existed
+func (c *Client) DBGen(ctx context.Context, url string, opts ...Option) (bool, error) {
+	options := c.options(opts)
 
-	// Get provider
-	prov, err := provider.GetProviderForURL(url)
+	// Get provider, configured with the client's API settings
+	prov, err := c.providerForURL(url)
 	if err != nil {
 		return false, err
 	}
 
-	// Configure provider with global settings
-	if globalCfg != nil {
-		prov.Configure(&globalCfg.API)
-	}
-
 	// Extract ID
 	id, err := prov.ExtractID(url)
 	if err != nil {
@@ -481,100 +703,216 @@ func DBGen(ctx context.Context, url string, opts ...Option) (bool, error) {
 	}
 
 	// Initialize database repository
-	db, err := database.NewRepository("")
+	db, err := c.database(options)
 	if err != nil {
 		return false, err
 	}
 
-	// Check if exists
+	// Check if exists and whether the refresh policy wants a refetch
 	if !options.Force && db.Exists(prov.Name(), id) {
-		// Load existing data to check expiration
 		existing, err := db.Load(ctx, prov.Name(), id)
-		if err == nil && existing != nil {
-			// If finished airing, no new episodes will come
-			if existing.Status == "Finished Airing" {
-				return false, nil // Skip
-			}
-
-			// If next episode is known and in the future, wait
-			if existing.NextEpisodeAirDate != nil {
-				t, err := time.Parse(time.RFC3339, *existing.NextEpisodeAirDate)
-				if err == nil && t.After(time.Now()) {
-					return false, nil // Skip
-				}
-			}
-		} else {
+		if err != nil || existing == nil {
 			return false, nil
 		}
+		if due, _ := database.NeedsRefresh(existing, c.config.Refresh, time.Now()); !due {
+			return false, nil // Skip
+		}
+	}
+
+	if err := c.generate(ctx, prov, id, db, options); err != nil {
+		return false, err
 	}
+	return true, nil
+}
+
+// generate fetches media for a provider ID, merges fallbacks and fillers
+// from options and saves the result
+func (c *Client) generate(ctx context.Context, prov types.Provider, id string, db types.DatabaseRepository, options *Options) error {
+	ctx = c.context(ctx, options)
 
 	// Fetch media
 	media, err := prov.FetchMedia(ctx, id)
 	if err != nil {
-		return false, err
+		return err
+	}
+
+	// Merge metadata from fallback providers
+	if len(options.FallbackURLs) > 0 {
+		sources := []*types.Media{media}
+		for _, fallbackURL := range options.FallbackURLs {
+			fallback, err := c.fetchFallback(ctx, fallbackURL)
+			if err != nil {
+				options.emit(types.EventWarning, fmt.Sprintf("Fallback %s failed: %v", fallbackURL, err))
+				continue
+			}
+			sources = append(sources, fallback)
+		}
+		if len(sources) > 1 {
+			media = provider.MergeMedia(sources, options.Merge)
+		}
 	}
 
-	// Fetch filler if URL provided
-	if options.FillerURL != "" {
-		fillerSource, err := provider.GetFillerSourceForURL(options.FillerURL)
-		if err == nil {
-			slug, err := fillerSource.ExtractSlug(options.FillerURL)
-			if err == nil {
-				fillers, err := fillerSource.FetchFillers(ctx, slug)
-				if err == nil {
-					for i := range media.Episodes {
-						if slices.Contains(fillers, media.Episodes[i].Number) {
-							media.Episodes[i].IsFiller = true
-						}
-					}
-					media.FillerSource = fillerSource.Name()
+	// Fetch filler classifications from every configured source
+	if len(options.FillerURLs) > 0 {
+		var lists []map[int]types.FillerType
+		var names []string
+		for _, fillerURL := range options.FillerURLs {
+			fillerSource, fillers, err := c.fetchFillers(ctx, fillerURL)
+			if err != nil {
+				options.emit(types.EventWarning, fmt.Sprintf("Filler list %s failed: %v", fillerURL, err))
+				continue
+			}
+			lists = append(lists, fillers)
+			if !slices.Contains(names, fillerSource.Name()) {
+				names = append(names, fillerSource.Name())
+			}
+		}
+		if len(lists) > 0 {
+			fillers := provider.CombineFillers(options.FillerPolicy, lists)
+			for i := range media.Episodes {
+				if t, ok := fillers[media.Episodes[i].Number]; ok {
+					media.Episodes[i].SetFillerType(t)
 				}
 			}
+			media.FillerSource = strings.Join(names, ",")
+		}
+	}
+
+	// Remember the inputs so db refresh can regenerate the entry
+	if len(options.FillerURLs) > 0 || len(options.FallbackURLs) > 0 {
+		media.Origin = &types.MediaOrigin{
+			FillerURLs:   options.FillerURLs,
+			FillerPolicy: options.FillerPolicy,
+			FallbackURLs: options.FallbackURLs,
+			Merge:        options.Merge,
 		}
 	}
 
 	// Save to database
-	if err := db.Save(ctx, media); err != nil {
-		return false, err
+	return db.Save(ctx, media)
+}
+
+// DBRefresh refetches cached entries that are due under the global refresh
+// policy (all entries with WithForce), emitting a progress event per entry
+func (c *Client) DBRefresh(ctx context.Context, opts ...Option) (*RefreshReport, error) {
+	options := c.options(opts)
+
+	db, err := c.database(options)
+	if err != nil {
+		return nil, err
+	}
+	items, err := db.List(ctx, "")
+	if err != nil {
+		return nil, err
 	}
+	policy := c.config.Refresh
 
-	return true, nil
+	report := &RefreshReport{Total: len(items)}
+	fail := func(item types.MediaSummary, err error) {
+		report.Failed = append(report.Failed, database.EntryError{Provider: item.Provider, ID: item.ID, Err: err})
+		options.emit(types.EventWarning, fmt.Sprintf("%s/%s: %v", item.Provider, item.ID, err))
+	}
+
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		prefix := fmt.Sprintf("[%d/%d] %s/%s", i+1, len(items), item.Provider, item.ID)
+
+		existing, err := db.Load(ctx, item.Provider, item.ID)
+		if err == nil && existing == nil {
+			err = types.ErrDatabaseNotFound{Provider: item.Provider, ID: item.ID}
+		}
+		if err != nil {
+			fail(item, err)
+			continue
+		}
+
+		reason := "forced"
+		if !options.Force {
+			var due bool
+			due, reason = database.NeedsRefresh(existing, policy, time.Now())
+			if !due {
+				report.Skipped++
+				options.emit(types.EventProgress, fmt.Sprintf("%s skipped (%s)", prefix, reason))
+				continue
+			}
+		}
+		options.emit(types.EventProgress, fmt.Sprintf("%s refreshing (%s)", prefix, reason))
+
+		prov, err := c.provider(item.Provider)
+		if err != nil {
+			fail(item, err)
+			continue
+		}
+
+		entryOpts := &Options{Events: options.Events}
+		if o := existing.Origin; o != nil {
+			entryOpts.FillerURLs = o.FillerURLs
+			entryOpts.FillerPolicy = o.FillerPolicy
+			entryOpts.FallbackURLs = o.FallbackURLs
+			entryOpts.Merge = o.Merge
+		}
+		if err := c.generate(ctx, prov, item.ID, db, entryOpts); err != nil {
+			fail(item, err)
+			continue
+		}
+		report.Refreshed++
+	}
+	return report, nil
+}
+
+// fetchFallback fetches media for a fallback provider URL
+func (c *Client) fetchFallback(ctx context.Context, url string) (*types.Media, error) {
+	prov, err := c.providerForURL(url)
+	if err != nil {
+		return nil, err
+	}
+	id, err := prov.ExtractID(url)
+	if err != nil {
+		return nil, err
+	}
+	return prov.FetchMedia(ctx, id)
+}
+
+// fetchFillers fetches the filler classification for a filler list URL
+func (c *Client) fetchFillers(ctx context.Context, url string) (types.FillerSource, map[int]types.FillerType, error) {
+	fillerSource, err := c.fillerSourceForURL(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	slug, err := fillerSource.ExtractSlug(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	fillers, err := fillerSource.FetchFillers(ctx, slug)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fillerSource, fillers, nil
 }
 
 // Search queries the configured providers for media matching the query.
 // If WithProvider is used, it only queries that specific provider.
-func Search(ctx context.Context, query string, opts ...Option) ([]types.SearchResult, error) {
-	options := &Options{}
-	for _, opt := range opts {
-		opt(options)
-	}
+func (c *Client) Search(ctx context.Context, query string, opts ...Option) ([]types.SearchResult, error) {
+	options := c.options(opts)
+	ctx = c.context(ctx, options)
 
-	// Load global config to configure provider
-	globalCfg, _ := config.LoadGlobal()
 	var results []types.SearchResult
 
 	if options.Provider != "" {
-		prov, err := provider.GetProvider(options.Provider)
+		prov, err := c.provider(options.Provider)
 		if err != nil {
 			return nil, err
 		}
-		if globalCfg != nil {
-			prov.Configure(&globalCfg.API)
-		}
 		res, err := prov.Search(ctx, query)
 		if err != nil {
 			return nil, err
 		}
 		results = append(results, res...)
 	} else {
-		for _, name := range provider.ListProviders() {
-			prov, err := provider.GetProvider(name)
-			if err != nil {
-				continue
-			}
-			if globalCfg != nil {
-				prov.Configure(&globalCfg.API)
-			}
+		for _, prov := range c.sources().Providers() {
+			c.configureProvider(prov)
 			res, err := prov.Search(ctx, query)
 			if err != nil {
 				continue
@@ -586,18 +924,106 @@ func Search(ctx context.Context, query string, opts ...Option) ([]types.SearchRe
 	return results, nil
 }
 
+// Identify guesses the series of the media files in path. The series name
+// comes from the filenames (or the directory name if they carry none) and is
+// searched on the providers; candidates are ranked by title and alias
+// similarity, year, and episode count against the files present. Use
+// Result.Confident before trusting the top candidate.
+func (c *Client) Identify(ctx context.Context, path string, opts ...Option) (*IdentifyResult, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	defaults := config.GetDefaults()
+	formats := defaults.Formats
+	if len(c.config.Formats) > 0 {
+		formats = c.config.Formats
+	}
+	var patterns []string
+	for _, p := range c.config.Patterns {
+		patterns = append(patterns, p.Input...)
+	}
+
+	entries, err := os.ReadDir(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && slices.Contains(formats, strings.TrimPrefix(filepath.Ext(e.Name()), ".")) {
+			files = append(files, e.Name())
+		}
+	}
+
+	dirName := filepath.Base(absPath)
+	res := &IdentifyResult{
+		Query: identify.SeriesName(files, patterns),
+		Year:  identify.Year(append([]string{dirName}, files...)...),
+		Files: len(files),
+	}
+	if res.Query == "" {
+		res.Query = matcher.SeriesQuery(dirName)
+	}
+	if res.Query == "" {
+		return res, fmt.Errorf("could not derive a series name from %s", absPath)
+	}
+
+	results, err := c.Search(ctx, res.Query, opts...)
+	if err != nil {
+		return res, err
+	}
+	res.Candidates = identify.Rank(results, res.Query, res.Year, res.Files)
+	return res, nil
+}
+
+// SuggestFiller asks the registered filler sources for a filler list matching
+// one of titles, trying titles in order. It returns "" when none is found.
+func (c *Client) SuggestFiller(ctx context.Context, titles ...string) (string, error) {
+	ctx = c.context(ctx, c.options(nil))
+
+	var lastErr error
+	for _, source := range c.sources().FillerSources() {
+		suggester, ok := c.configureFillerSource(source).(types.FillerSuggester)
+		if !ok {
+			continue
+		}
+		for _, title := range titles {
+			url, err := suggester.SuggestURL(ctx, title)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if url != "" {
+				return url, nil
+			}
+		}
+	}
+	return "", lastErr
+}
+
 // DBList lists all cached databases
-func DBList(ctx context.Context, providerFilter string) ([]types.MediaSummary, error) {
-	db, err := database.NewRepository("")
+func (c *Client) DBList(ctx context.Context, providerFilter string, opts ...Option) ([]types.MediaSummary, error) {
+	db, err := c.database(c.options(opts))
 	if err != nil {
 		return nil, err
 	}
 	return db.List(ctx, providerFilter)
 }
 
+// DBSearch finds cached entries whose ID matches the query or whose title,
+// English or Japanese title or aliases contain it
+func (c *Client) DBSearch(ctx context.Context, query string, opts ...Option) ([]types.MediaSummary, error) {
+	db, err := c.database(c.options(opts))
+	if err != nil {
+		return nil, err
+	}
+	return db.Search(ctx, query)
+}
+
 // DBInfo returns information about a specific database entry
-func DBInfo(ctx context.Context, prov, id string) (*types.Media, error) {
-	db, err := database.NewRepository("")
+func (c *Client) DBInfo(ctx context.Context, prov, id string, opts ...Option) (*types.Media, error) {
+	db, err := c.database(c.options(opts))
 	if err != nil {
 		return nil, err
 	}
@@ -605,8 +1031,8 @@ func DBInfo(ctx context.Context, prov, id string) (*types.Media, error) {
 }
 
 // DBDelete removes a database entry
-func DBDelete(ctx context.Context, prov, id string) error {
-	db, err := database.NewRepository("")
+func (c *Client) DBDelete(ctx context.Context, prov, id string, opts ...Option) error {
+	db, err := c.database(c.options(opts))
 	if err != nil {
 		return err
 	}
@@ -614,8 +1040,8 @@ func DBDelete(ctx context.Context, prov, id string) error {
 }
 
 // DBDeleteAll removes all database entries
-func DBDeleteAll(ctx context.Context) error {
-	db, err := database.NewRepository("")
+func (c *Client) DBDeleteAll(ctx context.Context, opts ...Option) error {
+	db, err := c.database(c.options(opts))
 	if err != nil {
 		return err
 	}
@@ -623,69 +1049,278 @@ func DBDeleteAll(ctx context.Context) error {
 }
 
 // DBPath returns the database directory path
-func DBPath() (string, error) {
-	db, err := database.NewRepository("")
+func (c *Client) DBPath(opts ...Option) (string, error) {
+	db, err := c.database(c.options(opts))
 	if err != nil {
 		return "", err
 	}
 	return db.Path(), nil
 }
 
-// Undo restores files from backup
-func Undo(ctx context.Context, path string) error {
-	db, err := database.NewRepository("")
+// DBConvert imports the JSON cache tree at jsonDir (default <cache>/db)
+// into the SQLite database at sqlitePath (default <cache>/db.sqlite), where
+// <cache> is the client's cache directory or ~/.cache/autotitle.
+// Existing SQLite entries with the same provider and ID are replaced.
+func (c *Client) DBConvert(ctx context.Context, jsonDir, sqlitePath string) (int, error) {
+	if c.cacheDir != "" {
+		if jsonDir == "" {
+			jsonDir = filepath.Join(c.cacheDir, "db")
+		}
+		if sqlitePath == "" {
+			sqlitePath = filepath.Join(c.cacheDir, database.SQLiteFile)
+		}
+	}
+	src, err := database.NewRepository(jsonDir)
+	if err != nil {
+		return 0, err
+	}
+	dst, err := database.NewSQLiteRepository(sqlitePath)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = dst.Close() }()
+
+	return database.Copy(ctx, dst, src)
+}
+
+// DBMigrate upgrades every database entry stored with an older schema version.
+// With dryRun set, entries needing migration are counted but not rewritten.
+func (c *Client) DBMigrate(ctx context.Context, dryRun bool, opts ...Option) (*MigrationReport, error) {
+	db, err := c.database(c.options(opts))
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return database.Migrate(ctx, db, dryRun)
+}
+
+// Import strategies for DBImport
+const (
+	ImportNewer     = database.ImportNewer
+	ImportOverwrite = database.ImportOverwrite
+	ImportSkip      = database.ImportSkip
+)
+
+// DBExport writes a bundle of cached entries to w. refs are "provider/id"
+// strings; with none, every entry is exported. Overrides from map files in
+// the directories given via WithMapDirs travel with their entries.
+func (c *Client) DBExport(ctx context.Context, w io.Writer, refs []string, opts ...Option) (int, error) {
+	options := c.options(opts)
+	db, err := c.database(options)
+	if err != nil {
+		return 0, err
 	}
-	cacheRoot := filepath.Dir(db.Path())
 
-	globalCfg, _ := config.LoadGlobal()
-	dirName := backup.DefaultDirName
-	if globalCfg != nil && globalCfg.Backup.DirName != "" {
-		dirName = globalCfg.Backup.DirName
+	bundle, err := database.Export(ctx, db, refs)
+	if err != nil {
+		return 0, err
 	}
 
-	bm := backup.New(cacheRoot, dirName)
-	if defaultEvents != nil {
-		bm.WithEvents(defaultEvents)
+	for _, dir := range options.MapDirs {
+		cfg, err := c.loadMap(dir)
+		if err != nil {
+			return 0, err
+		}
+		for _, target := range cfg.Targets {
+			if len(target.Overrides) == 0 {
+				continue
+			}
+			entry := c.findBundleEntry(bundle, target.URL)
+			if entry == nil {
+				continue
+			}
+			if entry.Overrides == nil {
+				entry.Overrides = make(map[string]types.EpisodeOverride)
+			}
+			for key, o := range target.Overrides {
+				entry.Overrides[key] = o
+			}
+		}
+	}
+
+	if err := database.WriteBundle(w, bundle); err != nil {
+		return 0, err
 	}
-	return bm.Restore(ctx, path)
+	return len(bundle.Entries), nil
 }
 
-// Clean removes the backup for a directory
-func Clean(ctx context.Context, path string) error {
-	db, err := database.NewRepository("")
+// DBImport reads a bundle from r and saves its entries using strategy
+// (ImportNewer, ImportOverwrite or ImportSkip). Bundled overrides are merged
+// into matching targets of the map files in the directories given via
+// WithMapDirs; existing override keys are only replaced with ImportOverwrite.
+func (c *Client) DBImport(ctx context.Context, r io.Reader, strategy string, opts ...Option) (*ImportReport, error) {
+	options := c.options(opts)
+	db, err := c.database(options)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	cacheRoot := filepath.Dir(db.Path())
 
-	globalCfg, _ := config.LoadGlobal()
-	dirName := backup.DefaultDirName
-	if globalCfg != nil && globalCfg.Backup.DirName != "" {
-		dirName = globalCfg.Backup.DirName
+	bundle, err := database.ReadBundle(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := database.Import(ctx, db, bundle, strategy)
+	if err != nil {
+		return nil, err
 	}
 
-	bm := backup.New(cacheRoot, dirName)
-	return bm.Clean(ctx, path)
+	for _, dir := range options.MapDirs {
+		path := c.mapFilePath(dir)
+		cfg, err := config.LoadFile(path)
+		if err != nil {
+			return report, err
+		}
+		for i, target := range cfg.Targets {
+			entry := c.findBundleEntry(bundle, target.URL)
+			if entry == nil || len(entry.Overrides) == 0 {
+				continue
+			}
+			n, err := config.MergeOverrides(path, i, entry.Overrides, strategy == ImportOverwrite)
+			if err != nil {
+				return report, fmt.Errorf("%s: %w", path, err)
+			}
+			report.Overrides += n
+		}
+	}
+
+	return report, nil
 }
 
-// CleanAll removes all backups globally
-func CleanAll(ctx context.Context) error {
-	db, err := database.NewRepository("")
+// findBundleEntry returns the bundle entry for the media a target URL points at
+func (c *Client) findBundleEntry(bundle *database.Bundle, url string) *database.BundleEntry {
+	prov, err := c.providerForURL(url)
 	if err != nil {
-		return err
+		return nil
+	}
+	id, err := prov.ExtractID(url)
+	if err != nil {
+		return nil
+	}
+	for i := range bundle.Entries {
+		if m := bundle.Entries[i].Media; m.Provider == prov.Name() && m.ID == id {
+			return &bundle.Entries[i]
+		}
 	}
-	cacheRoot := filepath.Dir(db.Path())
+	return nil
+}
 
-	globalCfg, _ := config.LoadGlobal()
-	dirName := backup.DefaultDirName
-	if globalCfg != nil && globalCfg.Backup.DirName != "" {
-		dirName = globalCfg.Backup.DirName
+// Restore conflict policies for Undo
+const (
+	ConflictSkip        = types.ConflictSkip
+	ConflictForce       = types.ConflictForce
+	ConflictRenameAside = types.ConflictRenameAside
+)
+
+// Undo restores files from backup. Files that were changed since the backup
+// are reported as conflicts and handled by the WithConflictPolicy policy.
+func (c *Client) Undo(ctx context.Context, path string, opts ...Option) (*RestoreReport, error) {
+	options := c.options(opts)
+	db, err := c.database(options)
+	if err != nil {
+		return nil, err
 	}
+	return c.backupManager(db, options).Restore(ctx, path, types.RestoreOptions{
+		Generation: options.Generation,
+		Only:       options.Only,
+		Conflict:   options.Conflict,
+	})
+}
 
-	bm := backup.New(cacheRoot, dirName)
-	return bm.CleanAll(ctx)
+// BackupHistory returns the backup generations of a directory, oldest first
+func (c *Client) BackupHistory(ctx context.Context, path string, opts ...Option) ([]BackupRecord, error) {
+	options := c.options(opts)
+	db, err := c.database(options)
+	if err != nil {
+		return nil, err
+	}
+	return c.backupManager(db, options).List(ctx, path)
+}
+
+// BackupList returns the backup generations of path with their disk usage,
+// or of every directory in the backup registry when path is empty
+func (c *Client) BackupList(ctx context.Context, path string, opts ...Option) ([]BackupUsage, error) {
+	mgr, inspector, err := c.backupInspector(c.options(opts))
+	if err != nil {
+		return nil, err
+	}
+	records, err := backupRecords(ctx, mgr, path)
+	if err != nil {
+		return nil, err
+	}
+	usage := make([]BackupUsage, 0, len(records))
+	for _, r := range records {
+		usage = append(usage, inspector.Usage(ctx, r))
+	}
+	return usage, nil
+}
+
+// BackupVerify checks that the backups of path, or of every directory in the
+// backup registry when path is empty, are complete and restorable
+func (c *Client) BackupVerify(ctx context.Context, path string, opts ...Option) (*VerifyReport, error) {
+	mgr, inspector, err := c.backupInspector(c.options(opts))
+	if err != nil {
+		return nil, err
+	}
+	records, err := backupRecords(ctx, mgr, path)
+	if err != nil {
+		return nil, err
+	}
+	report := &VerifyReport{Checked: len(records)}
+	for _, r := range records {
+		report.Issues = append(report.Issues, inspector.Verify(ctx, r)...)
+	}
+	return report, nil
+}
+
+// BackupPrune drops backup registry records whose backup is gone and, with
+// maxAge set, every generation older than maxAge. Generations of missing
+// directories are only deleted with WithOrphanedBackups.
+func (c *Client) BackupPrune(ctx context.Context, maxAge time.Duration, opts ...Option) (*PruneReport, error) {
+	options := c.options(opts)
+	_, inspector, err := c.backupInspector(options)
+	if err != nil {
+		return nil, err
+	}
+	return inspector.Prune(ctx, types.PruneOptions{MaxAge: maxAge, Orphaned: options.PruneOrphaned})
+}
+
+// backupRecords returns the generations of path, or every registry record
+// grouped by directory when path is empty
+func backupRecords(ctx context.Context, mgr types.BackupManager, path string) ([]BackupRecord, error) {
+	if path != "" {
+		return mgr.List(ctx, path)
+	}
+	records, err := mgr.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(records, func(a, b BackupRecord) int {
+		if c := strings.Compare(a.SourceDir, b.SourceDir); c != 0 {
+			return c
+		}
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return records, nil
+}
+
+// Clean removes the backup for a directory
+func (c *Client) Clean(ctx context.Context, path string, opts ...Option) error {
+	options := c.options(opts)
+	db, err := c.database(options)
+	if err != nil {
+		return err
+	}
+	return c.backupManager(db, options).Clean(ctx, path)
+}
+
+// CleanAll removes all backups globally
+func (c *Client) CleanAll(ctx context.Context, opts ...Option) error {
+	options := c.options(opts)
+	db, err := c.database(options)
+	if err != nil {
+		return err
+	}
+	return c.backupManager(db, options).CleanAll(ctx)
 }
 
 // Version returns the version string
@@ -693,18 +1328,10 @@ func Version() string {
 	return version.String()
 }
 
-// Provider registry functions
-var (
-	GetProviderForURL     = provider.GetProviderForURL
-	GetFillerSourceForURL = provider.GetFillerSourceForURL
-	GetProvider           = provider.GetProvider
-	ListProviders         = provider.ListProviders
-	ListFillerSources     = provider.ListFillerSources
-)
-
 // Pattern utilities
 var (
 	CompilePattern             = matcher.Compile
 	GuessPattern               = matcher.GuessPattern
 	GenerateFilenameFromFields = matcher.GenerateFilenameFromFields
+	SeriesQuery                = matcher.SeriesQuery
 )
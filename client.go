@@ -0,0 +1,309 @@
+package autotitle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mydehq/autotitle/internal/backup"
+	"github.com/mydehq/autotitle/internal/config"
+	"github.com/mydehq/autotitle/internal/database"
+	"github.com/mydehq/autotitle/internal/provider"
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// ClientConfig holds the dependencies of a Client. Zero fields fall back to
+// the defaults the package-level functions use.
+type ClientConfig struct {
+	// Config is the global configuration. Nil loads it from
+	// ~/.config/autotitle/config.yml or /etc/autotitle/config.yml.
+	Config *GlobalConfig
+
+	// CacheDir holds the database and backup registry when DB is nil.
+	// Empty uses ~/.cache/autotitle.
+	CacheDir string
+
+	// DB replaces the repository opened from Config.Database
+	DB DatabaseRepository
+
+	// Backup replaces the manager built from Config.Backup
+	Backup BackupManager
+
+	// HTTPClient sends provider and filler source requests instead of the
+	// sources' own clients
+	HTTPClient *http.Client
+
+	// Events receives progress events of operations without WithEvents
+	Events EventHandler
+}
+
+// Client runs autotitle operations against its own configuration, database,
+// backup manager, HTTP client, event handler, plugins and provider
+// instances. Options passed to a single operation take precedence over the
+// client's settings. Close releases the database the client opened.
+type Client struct {
+	config     *types.GlobalConfig
+	configErr  error // Why Config could not be loaded; defaults are used instead
+	cacheDir   string
+	backup     types.BackupManager
+	httpClient *http.Client
+	events     types.EventHandler
+
+	dbOnce sync.Once
+	db     types.DatabaseRepository
+	dbErr  error
+	ownsDB bool // db was opened by the client and is closed by Close
+	dbMu   sync.Mutex
+	closed bool
+
+	registryOnce     sync.Once
+	registry         *provider.Registry
+	ownProviders     map[string]bool // Configured once when the registry is built
+	ownFillerSources map[string]bool
+}
+
+// NewClient creates a client from cfg. The database is opened on first use.
+func NewClient(cfg ClientConfig) *Client {
+	c := &Client{
+		config:     cfg.Config,
+		cacheDir:   cfg.CacheDir,
+		db:         cfg.DB,
+		backup:     cfg.Backup,
+		httpClient: cfg.HTTPClient,
+		events:     cfg.Events,
+	}
+	if c.config == nil {
+		c.config, c.configErr = config.LoadGlobal()
+	}
+	if c.config == nil {
+		defaults := config.GetDefaults()
+		c.config = &defaults
+	}
+	return c
+}
+
+var (
+	defaultEvents atomic.Pointer[types.EventHandler]
+	defaultOnce   sync.Once
+	defaultCl     *Client
+)
+
+// SetDefaultEventHandler sets the event handler of the client behind the
+// package-level functions, used by operations that don't specify their own.
+func SetDefaultEventHandler(h types.EventHandler) {
+	defaultEvents.Store(&h)
+}
+
+// defaultClient returns the client behind the package-level functions,
+// created on first use. Its database stays open for the life of the
+// process.
+func defaultClient() *Client {
+	defaultOnce.Do(func() {
+		defaultCl = NewClient(ClientConfig{Events: func(e types.Event) {
+			if h := defaultEvents.Load(); h != nil && *h != nil {
+				(*h)(e)
+			}
+		}})
+	})
+	return defaultCl
+}
+
+// Close closes the database the client opened. A repository passed in
+// ClientConfig.DB is left open. Operations needing the database fail after
+// Close.
+func (c *Client) Close() error {
+	c.dbOnce.Do(func() {}) // Waits for an open in progress, prevents later ones
+	c.dbMu.Lock()
+	defer c.dbMu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.db == nil || !c.ownsDB {
+		return nil
+	}
+	return c.db.Close()
+}
+
+// ErrClientClosed is returned by operations on a closed Client
+var ErrClientClosed = errors.New("client is closed")
+
+// sources returns the client's provider registry, built on first use. The
+// built-in providers and filler sources are copied and configured with the
+// client's API settings, and the plugins found on $PATH or listed in the
+// client's config are loaded into it. Sources registered globally that
+// cannot be copied are shared between clients.
+func (c *Client) sources() *provider.Registry {
+	c.registryOnce.Do(func() {
+		global := provider.Global()
+		r := provider.NewRegistry(global)
+		c.ownProviders = make(map[string]bool)
+		c.ownFillerSources = make(map[string]bool)
+
+		for _, p := range global.Providers() {
+			cl, ok := p.(types.ProviderCloner)
+			if !ok {
+				continue
+			}
+			p = cl.Clone()
+			p.Configure(&c.config.API)
+			if r.AddProvider(p) == nil {
+				c.ownProviders[p.Name()] = true
+			}
+		}
+		for _, s := range global.FillerSources() {
+			cl, ok := s.(types.FillerSourceCloner)
+			if !ok {
+				continue
+			}
+			s = cl.Clone()
+			if cfg, ok := s.(types.Configurable); ok {
+				cfg.Configure(&c.config.API)
+			}
+			if r.AddFillerSource(s) == nil {
+				c.ownFillerSources[s.Name()] = true
+			}
+		}
+		loaded, err := r.LoadPlugins(c.config.Plugins)
+		if err != nil && c.events != nil {
+			c.events(types.Event{Type: types.EventWarning, Message: err.Error()})
+		}
+		for _, name := range loaded {
+			if p, err := r.Provider(name); err == nil {
+				p.Configure(&c.config.API)
+				c.ownProviders[name] = true
+			}
+		}
+		c.registry = r
+	})
+	return c.registry
+}
+
+// configureProvider applies the client's API settings to a shared provider;
+// the client's own instances were configured when its registry was built
+func (c *Client) configureProvider(p types.Provider) types.Provider {
+	if !c.ownProviders[p.Name()] {
+		p.Configure(&c.config.API)
+	}
+	return p
+}
+
+// configureFillerSource is the filler source counterpart of
+// configureProvider
+func (c *Client) configureFillerSource(s types.FillerSource) types.FillerSource {
+	if cfg, ok := s.(types.Configurable); ok && !c.ownFillerSources[s.Name()] {
+		cfg.Configure(&c.config.API)
+	}
+	return s
+}
+
+// provider finds a provider of the client by its name
+func (c *Client) provider(name string) (types.Provider, error) {
+	p, err := c.sources().Provider(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.configureProvider(p), nil
+}
+
+// providerForURL finds the provider of the client that handles url
+func (c *Client) providerForURL(url string) (types.Provider, error) {
+	p, err := c.sources().ProviderForURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return c.configureProvider(p), nil
+}
+
+// fillerSourceForURL finds the filler source of the client that handles url
+func (c *Client) fillerSourceForURL(url string) (types.FillerSource, error) {
+	s, err := c.sources().FillerSourceForURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return c.configureFillerSource(s), nil
+}
+
+// options applies opts to a fresh Options value, falling back to the
+// client's event handler
+func (c *Client) options(opts []Option) *Options {
+	options := &Options{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.Events == nil {
+		options.Events = c.events
+	}
+	return options
+}
+
+// context carries the operation's event handler and the client's HTTP
+// client to providers and filler sources
+func (c *Client) context(ctx context.Context, o *Options) context.Context {
+	ctx = types.ContextWithEvents(ctx, o.Events)
+	return provider.ContextWithHTTPClient(ctx, c.httpClient)
+}
+
+// database returns the repository set via WithDatabase or the client's
+// repository, opening it on first use
+func (c *Client) database(o *Options) (types.DatabaseRepository, error) {
+	if o.DB != nil {
+		return o.DB, nil
+	}
+	c.dbOnce.Do(func() {
+		if c.db == nil {
+			c.db, c.dbErr = database.OpenIn(c.cacheDir, c.config.Database.Backend)
+			c.ownsDB = true
+		}
+	})
+	c.dbMu.Lock()
+	defer c.dbMu.Unlock()
+	if c.closed {
+		return nil, ErrClientClosed
+	}
+	return c.db, c.dbErr
+}
+
+// backupManager returns the manager set via WithBackupManager, the client's
+// manager, or a default manager whose registry lives next to the database
+func (c *Client) backupManager(db types.DatabaseRepository, o *Options) types.BackupManager {
+	bm := o.Backup
+	if bm == nil {
+		bm = c.backup
+	}
+	if bm == nil {
+		bm = backup.FromConfig(filepath.Dir(db.Path()), c.config.Backup)
+	}
+	if o.Events != nil {
+		bm.WithEvents(o.Events)
+	}
+	return bm
+}
+
+// backupInspector returns the backup manager and its inspection interface
+func (c *Client) backupInspector(o *Options) (types.BackupManager, types.BackupInspector, error) {
+	db, err := c.database(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	mgr := c.backupManager(db, o)
+	inspector, ok := mgr.(types.BackupInspector)
+	if !ok {
+		return nil, nil, fmt.Errorf("backup manager does not support listing and verification")
+	}
+	return mgr, inspector, nil
+}
+
+// loadMap loads the map file of dir, named as the client's config says
+func (c *Client) loadMap(dir string) (*types.Config, error) {
+	return config.LoadFile(c.mapFilePath(dir))
+}
+
+// mapFilePath returns the map file path for dir
+func (c *Client) mapFilePath(dir string) string {
+	return config.FindMapFile(dir, c.config.MapFile)
+}
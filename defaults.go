@@ -0,0 +1,143 @@
+package autotitle
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// The package-level functions run on a default client built from the global
+// config file, the on-disk cache and the handler set with
+// SetDefaultEventHandler. Use NewClient for other settings.
+
+// Rename calls Client.Rename on the default client
+func Rename(ctx context.Context, path string, opts ...Option) ([]types.RenameOperation, error) {
+	return defaultClient().Rename(ctx, path, opts...)
+}
+
+// PlanRename calls Client.PlanRename on the default client
+func PlanRename(ctx context.Context, path string, opts ...Option) (*RenamePlan, error) {
+	return defaultClient().PlanRename(ctx, path, opts...)
+}
+
+// Init calls Client.Init on the default client
+func Init(ctx context.Context, path string, opts ...Option) error {
+	return defaultClient().Init(ctx, path, opts...)
+}
+
+// Tag calls Client.Tag on the default client
+func Tag(ctx context.Context, path string, opts ...Option) error {
+	return defaultClient().Tag(ctx, path, opts...)
+}
+
+// DBGen calls Client.DBGen on the default client
+func DBGen(ctx context.Context, url string, opts ...Option) (bool, error) {
+	return defaultClient().DBGen(ctx, url, opts...)
+}
+
+// DBRefresh calls Client.DBRefresh on the default client
+func DBRefresh(ctx context.Context, opts ...Option) (*RefreshReport, error) {
+	return defaultClient().DBRefresh(ctx, opts...)
+}
+
+// Search calls Client.Search on the default client
+func Search(ctx context.Context, query string, opts ...Option) ([]types.SearchResult, error) {
+	return defaultClient().Search(ctx, query, opts...)
+}
+
+// Identify calls Client.Identify on the default client
+func Identify(ctx context.Context, path string, opts ...Option) (*IdentifyResult, error) {
+	return defaultClient().Identify(ctx, path, opts...)
+}
+
+// SuggestFiller calls Client.SuggestFiller on the default client
+func SuggestFiller(ctx context.Context, titles ...string) (string, error) {
+	return defaultClient().SuggestFiller(ctx, titles...)
+}
+
+// DBList calls Client.DBList on the default client
+func DBList(ctx context.Context, providerFilter string, opts ...Option) ([]types.MediaSummary, error) {
+	return defaultClient().DBList(ctx, providerFilter, opts...)
+}
+
+// DBSearch calls Client.DBSearch on the default client
+func DBSearch(ctx context.Context, query string, opts ...Option) ([]types.MediaSummary, error) {
+	return defaultClient().DBSearch(ctx, query, opts...)
+}
+
+// DBInfo calls Client.DBInfo on the default client
+func DBInfo(ctx context.Context, prov, id string, opts ...Option) (*types.Media, error) {
+	return defaultClient().DBInfo(ctx, prov, id, opts...)
+}
+
+// DBDelete calls Client.DBDelete on the default client
+func DBDelete(ctx context.Context, prov, id string, opts ...Option) error {
+	return defaultClient().DBDelete(ctx, prov, id, opts...)
+}
+
+// DBDeleteAll calls Client.DBDeleteAll on the default client
+func DBDeleteAll(ctx context.Context, opts ...Option) error {
+	return defaultClient().DBDeleteAll(ctx, opts...)
+}
+
+// DBPath calls Client.DBPath on the default client
+func DBPath(opts ...Option) (string, error) {
+	return defaultClient().DBPath(opts...)
+}
+
+// DBConvert calls Client.DBConvert on the default client
+func DBConvert(ctx context.Context, jsonDir, sqlitePath string) (int, error) {
+	return defaultClient().DBConvert(ctx, jsonDir, sqlitePath)
+}
+
+// DBMigrate calls Client.DBMigrate on the default client
+func DBMigrate(ctx context.Context, dryRun bool, opts ...Option) (*MigrationReport, error) {
+	return defaultClient().DBMigrate(ctx, dryRun, opts...)
+}
+
+// DBExport calls Client.DBExport on the default client
+func DBExport(ctx context.Context, w io.Writer, refs []string, opts ...Option) (int, error) {
+	return defaultClient().DBExport(ctx, w, refs, opts...)
+}
+
+// DBImport calls Client.DBImport on the default client
+func DBImport(ctx context.Context, r io.Reader, strategy string, opts ...Option) (*ImportReport, error) {
+	return defaultClient().DBImport(ctx, r, strategy, opts...)
+}
+
+// Undo calls Client.Undo on the default client
+func Undo(ctx context.Context, path string, opts ...Option) (*RestoreReport, error) {
+	return defaultClient().Undo(ctx, path, opts...)
+}
+
+// BackupHistory calls Client.BackupHistory on the default client
+func BackupHistory(ctx context.Context, path string, opts ...Option) ([]BackupRecord, error) {
+	return defaultClient().BackupHistory(ctx, path, opts...)
+}
+
+// BackupList calls Client.BackupList on the default client
+func BackupList(ctx context.Context, path string, opts ...Option) ([]BackupUsage, error) {
+	return defaultClient().BackupList(ctx, path, opts...)
+}
+
+// BackupVerify calls Client.BackupVerify on the default client
+func BackupVerify(ctx context.Context, path string, opts ...Option) (*VerifyReport, error) {
+	return defaultClient().BackupVerify(ctx, path, opts...)
+}
+
+// BackupPrune calls Client.BackupPrune on the default client
+func BackupPrune(ctx context.Context, maxAge time.Duration, opts ...Option) (*PruneReport, error) {
+	return defaultClient().BackupPrune(ctx, maxAge, opts...)
+}
+
+// Clean calls Client.Clean on the default client
+func Clean(ctx context.Context, path string, opts ...Option) error {
+	return defaultClient().Clean(ctx, path, opts...)
+}
+
+// CleanAll calls Client.CleanAll on the default client
+func CleanAll(ctx context.Context, opts ...Option) error {
+	return defaultClient().CleanAll(ctx, opts...)
+}
@@ -8,21 +8,39 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
 )
 
 const (
 	RegistryFileName = "backup_registry.json"
 	MappingsFileName = "mappings.json"
+	MetaFileName     = "generation.json"
 	DefaultDirName   = ".autotitle_backup"
 )
 
-// Manager handles backup operations
+// generationFormat names generation directories so they sort oldest first
+const generationFormat = "20060102T150405Z"
+
+// Manager handles backup operations.
+// Each rename run is kept as a generation under <dir>/<dirName>/<generation>/
+// holding a mappings.json of oldName -> newName, a generation.json with the
+// backup mode and file fingerprints, and in copy mode the original files.
+// With the central location, generations live under the cache root in
+// backups/<dir key>/<generation>/ instead; originals that cannot be
+// hardlinked there are copied into the content-addressed backups/objects/.
 type Manager struct {
-	registryPath string // ~/.cache/autotitle/backup_registry.json
-	dirName      string // Backup dir name (from config)
+	registryPath string        // ~/.cache/autotitle/backup_registry.json
+	storeDir     string        // ~/.cache/autotitle/backups
+	dirName      string        // Backup dir name (from config)
+	central      bool          // Write new generations to the central store
+	mode         string        // types.BackupModeCopy or types.BackupModeJournal
+	keep         int           // Generations kept per directory (0 = all)
+	maxAge       time.Duration // Generations older than this are pruned (0 = never)
 	Events       types.EventHandler
 }
 
@@ -33,23 +51,64 @@ func New(cacheRoot string, dirName string) *Manager {
 	}
 	return &Manager{
 		registryPath: filepath.Join(cacheRoot, RegistryFileName),
+		storeDir:     filepath.Join(cacheRoot, StoreDirName),
 		dirName:      dirName,
 	}
 }
 
+// generationMeta is the content of a generation's generation.json
+type generationMeta struct {
+	Mode         string                 `json:"mode"`
+	Source       string                 `json:"source,omitempty"`  // Media directory the generation belongs to
+	Fingerprints map[string]Fingerprint `json:"fingerprints"`      // Keyed by original name
+	Objects      map[string]string      `json:"objects,omitempty"` // Original name -> object hash in the central store
+}
+
+// FromConfig creates a BackupManager using the directory name, mode,
+// location and retention settings of cfg
+func FromConfig(cacheRoot string, cfg types.BackupConfig) *Manager {
+	maxAge, _ := util.ParseAge(cfg.MaxAge) // Validated when the config is loaded
+	return New(cacheRoot, cfg.DirName).WithMode(cfg.Mode).WithLocation(cfg.Location).WithRetention(cfg.Keep, maxAge)
+}
+
+// WithMode selects how files are backed up. In journal mode only names and
+// fingerprints are recorded and undo renames the files back.
+func (m *Manager) WithMode(mode string) *Manager {
+	m.mode = mode
+	return m
+}
+
+// WithLocation selects where new generations are written. With
+// types.BackupLocationCentral they go to the store under the cache root,
+// keeping media directories free of backup files. Existing generations are
+// found in either location.
+func (m *Manager) WithLocation(location string) *Manager {
+	m.central = location == types.BackupLocationCentral
+	return m
+}
+
+// WithRetention limits how many generations are kept per directory and how
+// old they may get. Zero values disable the respective limit. The oldest
+// generation, which holds the original names, is always kept in addition.
+func (m *Manager) WithRetention(keep int, maxAge time.Duration) *Manager {
+	m.keep = keep
+	m.maxAge = maxAge
+	return m
+}
+
 // WithEvents sets the event handler
 func (m *Manager) WithEvents(h types.EventHandler) types.BackupManager {
 	m.Events = h
 	return m
 }
 
-func (m *Manager) emit(t types.EventType, msg string) {
+func (m *Manager) emit(t types.EventType, msg string, data types.BackupEvent) {
 	if m.Events != nil {
-		m.Events(types.Event{Type: t, Message: msg})
+		m.Events(types.Event{Type: t, Message: msg, Data: data})
 	}
 }
 
-// Backup creates a backup of files before renaming
+// Backup creates a new backup generation of files before renaming
 // mappings is a map of oldName -> newName
 func (m *Manager) Backup(ctx context.Context, dir string, mappings map[string]string) error {
 	absDir, err := filepath.Abs(dir)
@@ -57,86 +116,321 @@ func (m *Manager) Backup(ctx context.Context, dir string, mappings map[string]st
 		return fmt.Errorf("failed to resolve source dir: %w", err)
 	}
 
-	// Clean any previous backup for this directory first
-	_ = m.Clean(ctx, dir)
+	root := m.root(absDir)
+	if err := m.migrateLegacy(absDir); err != nil {
+		return err
+	}
 
-	// Create backup directory inside the input directory
-	backupPath := filepath.Join(absDir, m.dirName)
+	// Create the generation directory
+	now := time.Now()
+	generation := newGenerationID(m.roots(absDir), now)
+	backupPath := filepath.Join(root, generation)
 	if err := os.MkdirAll(backupPath, 0755); err != nil {
 		return fmt.Errorf("failed to create backup dir: %w", err)
 	}
 
-	// Copy original files to backup
+	mode := m.mode
+	if mode == "" {
+		mode = types.BackupModeCopy
+	}
+	if err := m.storeGeneration(absDir, backupPath, generation, mode, mappings); err != nil {
+		return err
+	}
+	if err := writeMappings(backupPath, mappings); err != nil {
+		return err
+	}
+
+	// Add to global registry
+	record := types.BackupRecord{
+		Path:       backupPath,
+		SourceDir:  absDir,
+		Generation: generation,
+		Mode:       mode,
+		Timestamp:  now,
+		Files:      len(mappings),
+	}
+	if err := m.addRegistry(record); err != nil {
+		return err
+	}
+
+	return m.prune(absDir, now)
+}
+
+// storeGeneration fingerprints the original files and, in copy mode, backs
+// them up, then writes the generation's meta. In the central store this
+// holds the store lock, so garbage collection cannot delete objects before
+// the meta refers to them.
+func (m *Manager) storeGeneration(absDir, backupPath, generation, mode string, mappings map[string]string) error {
+	if m.central && mode != types.BackupModeJournal {
+		unlock, err := m.lockStore()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	meta := generationMeta{Mode: mode, Source: absDir, Fingerprints: make(map[string]Fingerprint, len(mappings))}
+	done := 0
 	for oldName := range mappings {
 		src := filepath.Join(absDir, oldName)
-		dst := filepath.Join(backupPath, oldName)
-		if err := copyFile(src, dst); err != nil {
+		fp, err := fingerprint(src)
+		if err != nil {
 			return fmt.Errorf("failed to backup file %s: %w", oldName, err)
 		}
-		m.emit(types.EventInfo, fmt.Sprintf("Backed up: %s", oldName))
-	}
+		meta.Fingerprints[oldName] = fp
 
-	// Write mappings.json
-	mappingsPath := filepath.Join(backupPath, MappingsFileName)
-	mappingsData, err := json.MarshalIndent(mappings, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal mappings: %w", err)
+		if mode == types.BackupModeJournal {
+			continue
+		}
+		hash, err := m.storeFile(src, backupPath, oldName)
+		if err != nil {
+			return fmt.Errorf("failed to backup file %s: %w", oldName, err)
+		}
+		if hash != "" {
+			if meta.Objects == nil {
+				meta.Objects = make(map[string]string)
+			}
+			meta.Objects[oldName] = hash
+		}
+		done++
+		m.emit(types.EventInfo, fmt.Sprintf("Backed up: %s", oldName), types.BackupEvent{
+			Action: types.BackupActionStored, File: oldName, Generation: generation, Done: done, Total: len(mappings),
+		})
 	}
-	if err := os.WriteFile(mappingsPath, mappingsData, 0644); err != nil {
-		return fmt.Errorf("failed to write mappings file: %w", err)
+
+	return writeMeta(backupPath, meta)
+}
+
+// UpdateFingerprints records the current state of renamed files in the latest
+// generation, after the renamer changed them in place
+func (m *Manager) UpdateFingerprints(ctx context.Context, dir string, renamed map[string]string) error {
+	gens, err := m.List(ctx, dir)
+	if err != nil || len(gens) == 0 {
+		return err
 	}
+	gen := gens[len(gens)-1]
 
-	// Add to global registry
-	record := types.BackupRecord{
-		Path:      backupPath,
-		SourceDir: absDir,
-		Timestamp: time.Now(),
+	meta := readMeta(gen.Path)
+	if meta.Fingerprints == nil {
+		meta.Fingerprints = make(map[string]Fingerprint)
 	}
-	return m.addRegistry(record)
+	for oldName, newName := range renamed {
+		fp, err := fingerprint(filepath.Join(gen.SourceDir, newName))
+		if err != nil {
+			return fmt.Errorf("failed to fingerprint %s: %w", newName, err)
+		}
+		meta.Fingerprints[oldName] = fp
+	}
+	return writeMeta(gen.Path, meta)
 }
 
-// Restore restores files from backup (undo rename)
-func (m *Manager) Restore(ctx context.Context, dir string) error {
+// List returns the backup generations of a directory, oldest first
+func (m *Manager) List(ctx context.Context, dir string) ([]types.BackupRecord, error) {
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
-		return fmt.Errorf("failed to resolve dir: %w", err)
+		return nil, fmt.Errorf("failed to resolve dir: %w", err)
+	}
+	if err := m.migrateLegacy(absDir); err != nil {
+		return nil, err
 	}
+	return m.generations(absDir)
+}
 
-	backupPath := filepath.Join(absDir, m.dirName)
+// generations reads the generation directories of absDir from every backup
+// location, oldest first
+func (m *Manager) generations(absDir string) ([]types.BackupRecord, error) {
+	var gens []types.BackupRecord
+	for _, root := range m.roots(absDir) {
+		entries, err := os.ReadDir(root)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup dir: %w", err)
+		}
 
-	// Read mappings
-	mappingsPath := filepath.Join(backupPath, MappingsFileName)
-	data, err := os.ReadFile(mappingsPath)
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			path := filepath.Join(root, e.Name())
+			mappings, err := readMappings(path)
+			if err != nil {
+				continue // Not a generation
+			}
+			record := types.BackupRecord{
+				Path:       path,
+				SourceDir:  absDir,
+				Generation: e.Name(),
+				Mode:       readMeta(path).Mode,
+				Files:      len(mappings),
+			}
+			record.Timestamp = generationTime(e.Name(), path)
+			gens = append(gens, record)
+		}
+	}
+	// Generation names sort oldest first
+	slices.SortStableFunc(gens, func(a, b types.BackupRecord) int { return strings.Compare(a.Generation, b.Generation) })
+	return gens, nil
+}
+
+// prune removes generations beyond the retention limits, except the oldest:
+// undoing back to the original names must stay possible
+func (m *Manager) prune(absDir string, now time.Time) error {
+	if m.keep <= 0 && m.maxAge <= 0 {
+		return nil
+	}
+	gens, err := m.generations(absDir)
 	if err != nil {
-		return fmt.Errorf("no backup found for directory: %w", err)
+		return err
+	}
+	for i, gen := range gens {
+		if i == 0 {
+			continue
+		}
+		tooMany := m.keep > 0 && len(gens)-i > m.keep
+		tooOld := m.maxAge > 0 && now.Sub(gen.Timestamp) > m.maxAge && i < len(gens)-1
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := m.removeGeneration(gen); err != nil {
+			return err
+		}
+		m.emit(types.EventInfo, fmt.Sprintf("Pruned backup generation %s", gen.Generation),
+			types.BackupEvent{Action: types.BackupActionPruned, Generation: gen.Generation})
 	}
+	return nil
+}
 
-	var mappings map[string]string
-	if err := json.Unmarshal(data, &mappings); err != nil {
-		return fmt.Errorf("failed to parse mappings: %w", err)
+// removeGeneration deletes a generation directory and its registry record.
+// The backup root is removed once its last generation is gone.
+func (m *Manager) removeGeneration(gen types.BackupRecord) error {
+	if err := os.RemoveAll(gen.Path); err != nil {
+		return fmt.Errorf("failed to remove backup generation: %w", err)
+	}
+	_ = os.Remove(filepath.Dir(gen.Path)) // Only succeeds when empty
+	if err := m.collectObjects(); err != nil {
+		return err
+	}
+
+	return m.updateRegistry(func(records []types.BackupRecord) []types.BackupRecord {
+		return slices.DeleteFunc(records, func(r types.BackupRecord) bool { return r.Path == gen.Path })
+	})
+}
+
+// migrateLegacy moves a backup written before generations existed (files and
+// mappings.json directly in the backup root) into a generation of its own
+func (m *Manager) migrateLegacy(absDir string) error {
+	root := filepath.Join(absDir, m.dirName)
+	info, err := os.Stat(filepath.Join(root, MappingsFileName))
+	if err != nil {
+		return nil // No legacy backup
 	}
 
-	for oldName, newName := range mappings {
-		src := filepath.Join(backupPath, oldName)
-		dst := filepath.Join(absDir, oldName)
-		renamedPath := filepath.Join(absDir, newName)
+	generation := newGenerationID(m.roots(absDir), info.ModTime())
+	genPath := filepath.Join(root, generation)
+	if err := os.Mkdir(genPath, 0755); err != nil {
+		return fmt.Errorf("failed to migrate backup: %w", err)
+	}
 
-		// Restore original first
-		if err := copyFile(src, dst); err != nil {
-			return fmt.Errorf("failed to restore file %s: %w", oldName, err)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return fmt.Errorf("failed to migrate backup: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
 		}
+		if err := os.Rename(filepath.Join(root, e.Name()), filepath.Join(genPath, e.Name())); err != nil {
+			return fmt.Errorf("failed to migrate backup: %w", err)
+		}
+	}
 
-		// Only remove renamed file IF it's different from the original
-		if oldName != newName {
-			if _, err := os.Stat(renamedPath); err == nil {
-				_ = os.Remove(renamedPath)
+	return m.updateRegistry(func(records []types.BackupRecord) []types.BackupRecord {
+		for i := range records {
+			if records[i].Path == root {
+				records[i].Path = genPath
+				records[i].Generation = generation
 			}
 		}
-		m.emit(types.EventSuccess, fmt.Sprintf("Restored: %s → %s", newName, oldName))
+		return records
+	})
+}
+
+// readMeta reads a generation's metadata. Generations written before it
+// existed are copy backups without fingerprints.
+func readMeta(backupPath string) generationMeta {
+	meta := generationMeta{Mode: types.BackupModeCopy}
+	if data, err := os.ReadFile(filepath.Join(backupPath, MetaFileName)); err == nil {
+		_ = json.Unmarshal(data, &meta)
 	}
+	return meta
+}
 
-	// Clean up backup after successful restore
-	return m.Clean(ctx, dir)
+// newGenerationID returns a generation name for t that is unused in every
+// root, so generations from all backup locations sort unambiguously
+func newGenerationID(roots []string, t time.Time) string {
+	base := t.UTC().Format(generationFormat)
+	id := base
+	for n := 2; ; n++ {
+		if !slices.ContainsFunc(roots, func(root string) bool {
+			_, err := os.Stat(filepath.Join(root, id))
+			return !os.IsNotExist(err)
+		}) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// generationTime parses the creation time from a generation name, falling
+// back to the directory's modification time
+func generationTime(name, path string) time.Time {
+	base, _, _ := strings.Cut(name, "-")
+	if t, err := time.Parse(generationFormat, base); err == nil {
+		return t
+	}
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// writeMeta writes a generation's generation.json
+func writeMeta(backupPath string, meta generationMeta) error {
+	data, err := json.MarshalIndent(&meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup metadata: %w", err)
+	}
+	if err := util.WriteFileAtomic(filepath.Join(backupPath, MetaFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup metadata: %w", err)
+	}
+	return nil
+}
+
+// writeMappings writes a generation's mappings.json of oldName -> newName
+func writeMappings(backupPath string, mappings map[string]string) error {
+	data, err := json.MarshalIndent(mappings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal mappings: %w", err)
+	}
+	if err := util.WriteFileAtomic(filepath.Join(backupPath, MappingsFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write mappings file: %w", err)
+	}
+	return nil
+}
+
+func readMappings(backupPath string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(backupPath, MappingsFileName))
+	if err != nil {
+		return nil, fmt.Errorf("no backup found for directory: %w", err)
+	}
+
+	var mappings map[string]string
+	if err := json.Unmarshal(data, &mappings); err != nil {
+		return nil, fmt.Errorf("failed to parse mappings: %w", err)
+	}
+	return mappings, nil
 }
 
 // Clean removes backup for a specific directory
@@ -146,11 +440,14 @@ func (m *Manager) Clean(ctx context.Context, dir string) error {
 		return fmt.Errorf("failed to resolve dir: %w", err)
 	}
 
-	backupPath := filepath.Join(absDir, m.dirName)
-
-	// Remove backup directory
-	if err := os.RemoveAll(backupPath); err != nil {
-		return fmt.Errorf("failed to remove backup dir: %w", err)
+	// Remove backup directories from every location
+	for _, root := range m.roots(absDir) {
+		if err := os.RemoveAll(root); err != nil {
+			return fmt.Errorf("failed to remove backup dir: %w", err)
+		}
+	}
+	if err := m.collectObjects(); err != nil {
+		return err
 	}
 
 	// Remove from registry
@@ -166,10 +463,19 @@ func (m *Manager) CleanAll(ctx context.Context) error {
 
 	for _, r := range records {
 		_ = os.RemoveAll(r.Path) // Ignore individual errors
+		if r.Generation != "" {
+			_ = os.Remove(filepath.Dir(r.Path)) // Backup root, once empty
+		}
+	}
+
+	if err := m.collectObjects(); err != nil {
+		return err
 	}
 
 	// Clear registry
-	return m.saveRegistry([]types.BackupRecord{})
+	return m.updateRegistry(func([]types.BackupRecord) []types.BackupRecord {
+		return []types.BackupRecord{}
+	})
 }
 
 // ListAll returns all backup records from global registry
@@ -190,32 +496,47 @@ func (m *Manager) ListAll(ctx context.Context) ([]types.BackupRecord, error) {
 }
 
 func (m *Manager) addRegistry(r types.BackupRecord) error {
-	records, _ := m.ListAll(context.Background())
-	records = append(records, r)
-	return m.saveRegistry(records)
+	return m.updateRegistry(func(records []types.BackupRecord) []types.BackupRecord {
+		return append(records, r)
+	})
 }
 
 func (m *Manager) removeFromRegistry(sourceDir string) error {
-	records, _ := m.ListAll(context.Background())
-	var kept []types.BackupRecord
-	for _, r := range records {
-		if r.SourceDir != sourceDir {
-			kept = append(kept, r)
+	return m.updateRegistry(func(records []types.BackupRecord) []types.BackupRecord {
+		var kept []types.BackupRecord
+		for _, r := range records {
+			if r.SourceDir != sourceDir {
+				kept = append(kept, r)
+			}
 		}
-	}
-	return m.saveRegistry(kept)
+		return kept
+	})
 }
 
-func (m *Manager) saveRegistry(records []types.BackupRecord) error {
+// updateRegistry applies fn to the registry while holding the registry lock,
+// so concurrent runs cannot drop each other's records
+func (m *Manager) updateRegistry(fn func([]types.BackupRecord) []types.BackupRecord) error {
 	// Ensure parent directory exists
 	if err := os.MkdirAll(filepath.Dir(m.registryPath), 0755); err != nil {
 		return err
 	}
+
+	l, err := util.Lock(m.registryPath + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock backup registry: %w", err)
+	}
+	defer func() { _ = l.Unlock() }()
+
+	records, _ := m.ListAll(context.Background())
+	return m.saveRegistry(fn(records))
+}
+
+func (m *Manager) saveRegistry(records []types.BackupRecord) error {
 	data, err := json.MarshalIndent(records, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(m.registryPath, data, 0644)
+	return util.WriteFileAtomic(m.registryPath, data, 0644)
 }
 
 func copyFile(src, dst string) error {
@@ -0,0 +1,536 @@
+package backup_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mydehq/autotitle/internal/backup"
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// rename backs up and applies one rename run, like the renamer does
+func rename(t *testing.T, m *backup.Manager, dir string, mappings map[string]string) {
+	t.Helper()
+	if err := m.Backup(context.Background(), dir, mappings); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	for oldName, newName := range mappings {
+		if err := os.Rename(filepath.Join(dir, oldName), filepath.Join(dir, newName)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertFiles(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	for _, name := range want {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to exist", name)
+		}
+	}
+}
+
+func TestManager_Generations(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	m := backup.New(t.TempDir(), "")
+	if err := os.WriteFile(filepath.Join(dir, "ep01.mkv"), []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rename(t, m, dir, map[string]string{"ep01.mkv": "Show - 01.mkv"})
+	rename(t, m, dir, map[string]string{"Show - 01.mkv": "Show - 01 - Pilot.mkv"})
+	rename(t, m, dir, map[string]string{"Show - 01 - Pilot.mkv": "S01E01.mkv"})
+
+	history, err := m.List(ctx, dir)
+	if err != nil || len(history) != 3 {
+		t.Fatalf("List = %d generations, %v; want 3", len(history), err)
+	}
+	for i := 1; i < len(history); i++ {
+		if history[i-1].Generation >= history[i].Generation {
+			t.Errorf("generations not sorted: %s >= %s", history[i-1].Generation, history[i].Generation)
+		}
+	}
+
+	// Undo the latest run only
+	if _, err := m.Restore(ctx, dir, types.RestoreOptions{}); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	assertFiles(t, dir, "Show - 01 - Pilot.mkv")
+
+	// Undo back to the original release names
+	if _, err := m.Restore(ctx, dir, types.RestoreOptions{Generation: history[0].Generation}); err != nil {
+		t.Fatalf("Restore to %s failed: %v", history[0].Generation, err)
+	}
+	assertFiles(t, dir, "ep01.mkv")
+	if _, err := os.Stat(filepath.Join(dir, "Show - 01.mkv")); err == nil {
+		t.Error("renamed file still exists after undo")
+	}
+	if _, err := os.Stat(filepath.Join(dir, backup.DefaultDirName)); !os.IsNotExist(err) {
+		t.Error("backup root not removed after undoing every generation")
+	}
+	if records, _ := m.ListAll(ctx); len(records) != 0 {
+		t.Errorf("registry still has %d records", len(records))
+	}
+
+	if _, err := m.Restore(ctx, dir, types.RestoreOptions{}); err == nil {
+		t.Error("expected error restoring without backups")
+	}
+}
+
+func TestManager_RestoreKeepsIdentity(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	m := backup.New(t.TempDir(), "")
+	if err := os.WriteFile(filepath.Join(dir, "ep01.mkv"), []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rename(t, m, dir, map[string]string{"ep01.mkv": "A.mkv"})
+	rename(t, m, dir, map[string]string{"A.mkv": "B.mkv"})
+
+	// Backups on another filesystem hold real copies instead of hardlinks
+	history, _ := m.List(ctx, dir)
+	for _, gen := range history {
+		for _, name := range []string{"ep01.mkv", "A.mkv"} {
+			path := filepath.Join(gen.Path, name)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			_ = os.Remove(path)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Undoing the newer run must leave A.mkv as the file the older run renamed
+	report, err := m.Restore(ctx, dir, types.RestoreOptions{Generation: history[0].Generation})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if len(report.Conflicts) != 0 {
+		t.Errorf("unexpected conflicts: %+v", report.Conflicts)
+	}
+	assertFiles(t, dir, "ep01.mkv")
+}
+
+func TestManager_Retention(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	m := backup.New(t.TempDir(), "").WithRetention(2, 0)
+
+	names := []string{"a.mkv", "b.mkv", "c.mkv", "d.mkv", "e.mkv"}
+	if err := os.WriteFile(filepath.Join(dir, names[0]), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(names); i++ {
+		rename(t, m, dir, map[string]string{names[i-1]: names[i]})
+	}
+
+	// The two latest runs are kept, plus the first with the original name
+	history, _ := m.List(ctx, dir)
+	if len(history) != 3 {
+		t.Fatalf("kept %d generations, want 3", len(history))
+	}
+	if records, _ := m.ListAll(ctx); len(records) != 3 {
+		t.Errorf("registry has %d records, want 3", len(records))
+	}
+
+	// Pruned runs leave a gap, so the first run restores from its copy
+	opts := types.RestoreOptions{Generation: history[0].Generation, Conflict: types.ConflictForce}
+	if _, err := m.Restore(ctx, dir, opts); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	assertFiles(t, dir, "a.mkv")
+}
+
+func TestManager_LegacyBackup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	root := filepath.Join(dir, backup.DefaultDirName)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(root, "old.mkv"), []byte("video"), 0644)
+	_ = os.WriteFile(filepath.Join(root, backup.MappingsFileName), []byte(`{"old.mkv": "new.mkv"}`), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "new.mkv"), []byte("video"), 0644)
+
+	m := backup.New(t.TempDir(), "")
+	history, err := m.List(ctx, dir)
+	if err != nil || len(history) != 1 || history[0].Files != 1 {
+		t.Fatalf("legacy backup not listed as a generation: %+v, %v", history, err)
+	}
+
+	if _, err := m.Restore(ctx, dir, types.RestoreOptions{}); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	assertFiles(t, dir, "old.mkv")
+}
+
+func TestManager_MaxAge(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	root := filepath.Join(dir, backup.DefaultDirName)
+	var gens []string
+	for _, age := range []time.Duration{48 * time.Hour, 47 * time.Hour} {
+		gen := filepath.Join(root, time.Now().Add(-age).UTC().Format("20060102T150405Z"))
+		if err := os.MkdirAll(gen, 0755); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.WriteFile(filepath.Join(gen, backup.MappingsFileName), []byte(`{}`), 0644)
+		gens = append(gens, gen)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "a.mkv"), nil, 0644)
+
+	m := backup.New(t.TempDir(), "").WithRetention(0, 24*time.Hour)
+	rename(t, m, dir, map[string]string{"a.mkv": "b.mkv"})
+
+	// The first generation holds the original names and survives its age
+	history, _ := m.List(ctx, dir)
+	if len(history) != 2 || history[0].Path != gens[0] || history[1].Path == gens[1] {
+		t.Errorf("expected the first and the new generation, got %+v", history)
+	}
+}
+
+func TestManager_Journal(t *testing.T) {
+	ctx := context.Background()
+	setup := func(t *testing.T) (*backup.Manager, string) {
+		dir := t.TempDir()
+		m := backup.New(t.TempDir(), "").WithMode(types.BackupModeJournal)
+		for _, name := range []string{"ep01.mkv", "ep02.mkv"} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		rename(t, m, dir, map[string]string{"ep01.mkv": "Show - 01.mkv", "ep02.mkv": "Show - 02.mkv"})
+		return m, dir
+	}
+
+	t.Run("restore", func(t *testing.T) {
+		m, dir := setup(t)
+		history, _ := m.List(ctx, dir)
+		if len(history) != 1 || history[0].Mode != types.BackupModeJournal {
+			t.Fatalf("unexpected history: %+v", history)
+		}
+		if _, err := os.Stat(filepath.Join(history[0].Path, "ep01.mkv")); err == nil {
+			t.Error("journal backup copied a media file")
+		}
+
+		if _, err := m.Restore(ctx, dir, types.RestoreOptions{}); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		assertFiles(t, dir, "ep01.mkv", "ep02.mkv")
+	})
+
+	t.Run("replaced file", func(t *testing.T) {
+		m, dir := setup(t)
+		replaced := filepath.Join(dir, "Show - 02.mkv")
+		_ = os.Remove(replaced)
+		if err := os.WriteFile(replaced, []byte("another release"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// The replaced file is skipped, the other one restored
+		report, err := m.Restore(ctx, dir, types.RestoreOptions{})
+		if err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		if len(report.Conflicts) != 1 || report.Conflicts[0].Reason != types.ConflictModified || report.Conflicts[0].Action != "skipped" {
+			t.Fatalf("unexpected conflicts: %+v", report.Conflicts)
+		}
+		assertFiles(t, dir, "ep01.mkv", "Show - 02.mkv")
+		if history, _ := m.List(ctx, dir); len(history) != 1 || history[0].Files != 1 {
+			t.Errorf("generation should keep the skipped file: %+v", history)
+		}
+
+		// A journal backup has no copy, so forcing renames the new file back
+		if _, err := m.Restore(ctx, dir, types.RestoreOptions{Conflict: types.ConflictForce}); err != nil {
+			t.Fatalf("forced Restore failed: %v", err)
+		}
+		assertFiles(t, dir, "ep01.mkv", "ep02.mkv")
+		if history, _ := m.List(ctx, dir); len(history) != 0 {
+			t.Error("generation not dropped after restoring every file")
+		}
+	})
+
+	t.Run("tagged after rename", func(t *testing.T) {
+		m, dir := setup(t)
+		tagged := filepath.Join(dir, "Show - 01.mkv")
+		if err := os.WriteFile(tagged, []byte("ep01.mkv with tags"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		renamed := map[string]string{"ep01.mkv": "Show - 01.mkv"}
+		if err := m.UpdateFingerprints(ctx, dir, renamed); err != nil {
+			t.Fatalf("UpdateFingerprints failed: %v", err)
+		}
+		report, err := m.Restore(ctx, dir, types.RestoreOptions{})
+		if err != nil || len(report.Conflicts) != 0 {
+			t.Fatalf("Restore after updating fingerprints = %+v, %v", report, err)
+		}
+		assertFiles(t, dir, "ep01.mkv", "ep02.mkv")
+	})
+}
+
+func TestManager_RestoreOnly(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	m := backup.New(t.TempDir(), "")
+	mappings := map[string]string{}
+	for _, ep := range []string{"01", "02", "03"} {
+		name := "[Group] Show - " + ep + " [1080p].mkv"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(ep), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mappings[name] = "E" + ep + " - Title.mkv"
+	}
+	rename(t, m, dir, mappings)
+
+	if _, err := m.Restore(ctx, dir, types.RestoreOptions{Only: "2-3"}); err != nil {
+		t.Fatalf("Restore by episode range failed: %v", err)
+	}
+	assertFiles(t, dir, "E01 - Title.mkv", "[Group] Show - 02 [1080p].mkv", "[Group] Show - 03 [1080p].mkv")
+	if history, _ := m.List(ctx, dir); len(history) != 1 || history[0].Files != 1 {
+		t.Fatalf("generation should keep episode 1: %+v", history)
+	}
+
+	if _, err := m.Restore(ctx, dir, types.RestoreOptions{Only: "*.txt"}); err != nil {
+		t.Fatalf("Restore by glob failed: %v", err)
+	}
+	assertFiles(t, dir, "E01 - Title.mkv")
+
+	if _, err := m.Restore(ctx, dir, types.RestoreOptions{Only: "E01*"}); err != nil {
+		t.Fatalf("Restore by glob failed: %v", err)
+	}
+	assertFiles(t, dir, "[Group] Show - 01 [1080p].mkv")
+	if history, _ := m.List(ctx, dir); len(history) != 0 {
+		t.Error("generation not dropped after restoring every file")
+	}
+
+	if _, err := m.Restore(ctx, dir, types.RestoreOptions{Only: "[bad"}); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+}
+
+func TestManager_RestoreConflicts(t *testing.T) {
+	ctx := context.Background()
+	setup := func(t *testing.T) (*backup.Manager, string) {
+		dir := t.TempDir()
+		m := backup.New(t.TempDir(), "")
+		if err := os.WriteFile(filepath.Join(dir, "ep01.mkv"), []byte("original"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		rename(t, m, dir, map[string]string{"ep01.mkv": "Show - 01.mkv"})
+		return m, dir
+	}
+	read := func(t *testing.T, path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("expected %s: %v", filepath.Base(path), err)
+		}
+		return string(data)
+	}
+
+	t.Run("occupied skip", func(t *testing.T) {
+		m, dir := setup(t)
+		_ = os.WriteFile(filepath.Join(dir, "ep01.mkv"), []byte("new download"), 0644)
+
+		report, err := m.Restore(ctx, dir, types.RestoreOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Restored) != 0 || len(report.Conflicts) != 1 || report.Conflicts[0].Reason != types.ConflictOccupied {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		if got := read(t, filepath.Join(dir, "ep01.mkv")); got != "new download" {
+			t.Errorf("skipped restore overwrote the file: %q", got)
+		}
+		assertFiles(t, dir, "Show - 01.mkv")
+	})
+
+	t.Run("occupied rename-aside", func(t *testing.T) {
+		m, dir := setup(t)
+		_ = os.WriteFile(filepath.Join(dir, "ep01.mkv"), []byte("new download"), 0644)
+
+		report, err := m.Restore(ctx, dir, types.RestoreOptions{Conflict: types.ConflictRenameAside})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Conflicts) != 1 || report.Conflicts[0].Aside != "ep01.mkv.autotitle-conflict" {
+			t.Fatalf("unexpected conflicts: %+v", report.Conflicts)
+		}
+		if got := read(t, filepath.Join(dir, "ep01.mkv")); got != "original" {
+			t.Errorf("restored content = %q", got)
+		}
+		if got := read(t, filepath.Join(dir, "ep01.mkv.autotitle-conflict")); got != "new download" {
+			t.Errorf("aside content = %q", got)
+		}
+	})
+
+	t.Run("modified rename-aside", func(t *testing.T) {
+		m, dir := setup(t)
+		modified := filepath.Join(dir, "Show - 01.mkv")
+		_ = os.Remove(modified)
+		_ = os.WriteFile(modified, []byte("re-encoded"), 0644)
+
+		if _, err := m.Restore(ctx, dir, types.RestoreOptions{Conflict: types.ConflictRenameAside}); err != nil {
+			t.Fatal(err)
+		}
+		if got := read(t, filepath.Join(dir, "ep01.mkv")); got != "original" {
+			t.Errorf("restored content = %q", got)
+		}
+		if got := read(t, modified+".autotitle-conflict"); got != "re-encoded" {
+			t.Errorf("aside content = %q", got)
+		}
+	})
+
+	t.Run("missing force", func(t *testing.T) {
+		m, dir := setup(t)
+		_ = os.Rename(filepath.Join(dir, "Show - 01.mkv"), filepath.Join(dir, "renamed by hand.mkv"))
+
+		report, err := m.Restore(ctx, dir, types.RestoreOptions{})
+		if err != nil || len(report.Conflicts) != 1 || report.Conflicts[0].Reason != types.ConflictMissing {
+			t.Fatalf("Restore = %+v, %v; want a missing conflict", report, err)
+		}
+
+		report, err = m.Restore(ctx, dir, types.RestoreOptions{Conflict: types.ConflictForce})
+		if err != nil || len(report.Restored) != 1 {
+			t.Fatalf("forced Restore = %+v, %v", report, err)
+		}
+		if got := read(t, filepath.Join(dir, "ep01.mkv")); got != "original" {
+			t.Errorf("restored content = %q", got)
+		}
+		assertFiles(t, dir, "renamed by hand.mkv")
+	})
+}
+
+func TestManager_UsageAndVerify(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	m := backup.New(t.TempDir(), "")
+	for _, name := range []string{"ep01.mkv", "ep02.mkv"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rename(t, m, dir, map[string]string{"ep01.mkv": "Show - 01.mkv", "ep02.mkv": "Show - 02.mkv"})
+
+	history, _ := m.List(ctx, dir)
+	gen := history[0]
+	usage := m.Usage(ctx, gen)
+	if usage.Missing || usage.Linked+usage.Copied != 2 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+	if issues := m.Verify(ctx, gen); len(issues) != 0 {
+		t.Fatalf("fresh backup has issues: %+v", issues)
+	}
+
+	// A lost backup copy cannot be restored; a missing renamed file still can
+	_ = os.Remove(filepath.Join(gen.Path, "ep01.mkv"))
+	_ = os.Remove(filepath.Join(dir, "Show - 02.mkv"))
+	issues := m.Verify(ctx, gen)
+	if len(issues) != 2 {
+		t.Fatalf("Verify = %+v, want 2 issues", issues)
+	}
+	if issues[0].File != "ep01.mkv" || issues[0].Restorable {
+		t.Errorf("missing copy: %+v", issues[0])
+	}
+	if issues[1].File != "ep02.mkv" || !issues[1].Restorable {
+		t.Errorf("missing renamed file: %+v", issues[1])
+	}
+}
+
+func TestManager_Prune(t *testing.T) {
+	ctx := context.Background()
+	m := backup.New(t.TempDir(), "")
+	dirs := []string{t.TempDir(), t.TempDir()}
+	for _, dir := range dirs {
+		if err := os.WriteFile(filepath.Join(dir, "ep01.mkv"), []byte("video"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		rename(t, m, dir, map[string]string{"ep01.mkv": "Show - 01.mkv"})
+	}
+
+	// Deleting a directory leaves a stale registry record behind
+	_ = os.RemoveAll(filepath.Join(dirs[1], backup.DefaultDirName))
+	report, err := m.Prune(ctx, types.PruneOptions{})
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(report.Stale) != 1 || report.Stale[0].SourceDir != dirs[1] || len(report.Removed) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if records, _ := m.ListAll(ctx); len(records) != 1 {
+		t.Fatalf("registry has %d records, want 1", len(records))
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if report, err = m.Prune(ctx, types.PruneOptions{MaxAge: time.Millisecond}); err != nil || len(report.Removed) != 1 {
+		t.Fatalf("Prune by age = %+v, %v", report, err)
+	}
+	if records, _ := m.ListAll(ctx); len(records) != 0 {
+		t.Errorf("registry has %d records after pruning by age", len(records))
+	}
+	assertFiles(t, dirs[0], "Show - 01.mkv")
+}
+
+func TestManager_CentralStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cache := t.TempDir()
+	m := backup.New(cache, "").WithLocation(types.BackupLocationCentral)
+	if err := os.WriteFile(filepath.Join(dir, "ep01.mkv"), []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rename(t, m, dir, map[string]string{"ep01.mkv": "Show - 01.mkv"})
+	if _, err := os.Stat(filepath.Join(dir, backup.DefaultDirName)); !os.IsNotExist(err) {
+		t.Fatal("central backup wrote into the media directory")
+	}
+	history, err := m.List(ctx, dir)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("List = %+v, %v", history, err)
+	}
+	if rel, err := filepath.Rel(filepath.Join(cache, backup.StoreDirName), history[0].Path); err != nil || rel[0] == '.' {
+		t.Errorf("generation %s is not in the central store", history[0].Path)
+	}
+
+	// A directory backup made before switching locations is still undone
+	local := backup.New(cache, "")
+	rename(t, local, dir, map[string]string{"Show - 01.mkv": "Show - 01 - Pilot.mkv"})
+	if history, _ := m.List(ctx, dir); len(history) != 2 {
+		t.Fatalf("List over both locations = %d generations, want 2", len(history))
+	}
+
+	if _, err := m.Restore(ctx, dir, types.RestoreOptions{Generation: history[0].Generation}); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	assertFiles(t, dir, "ep01.mkv")
+	if entries, _ := os.ReadDir(filepath.Join(cache, backup.StoreDirName)); len(entries) != 0 {
+		t.Errorf("central store not emptied: %v", entries)
+	}
+
+	// Central generations of missing directories are only reported, since
+	// the directory may be on an unmounted drive
+	rename(t, m, dir, map[string]string{"ep01.mkv": "Show - 01.mkv"})
+	_ = os.RemoveAll(dir)
+	if report, err := m.Prune(ctx, types.PruneOptions{}); err != nil || len(report.Orphaned) != 1 || len(report.Stale) != 0 {
+		t.Fatalf("Prune = %+v, %v", report, err)
+	}
+	if records, _ := m.ListAll(ctx); len(records) != 1 {
+		t.Fatalf("Prune without Orphaned dropped the generation: %d records left", len(records))
+	}
+
+	// Asked for, they are deleted
+	if report, err := m.Prune(ctx, types.PruneOptions{Orphaned: true}); err != nil || len(report.Orphaned) != 1 {
+		t.Fatalf("Prune = %+v, %v", report, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(cache, backup.StoreDirName)); len(entries) != 0 {
+		t.Errorf("central store not emptied by prune: %v", entries)
+	}
+}
@@ -0,0 +1,41 @@
+package backup
+
+import (
+	"os"
+	"time"
+)
+
+// Fingerprint identifies a file at backup time so undo can tell whether the
+// file at the renamed path is still the one autotitle produced
+type Fingerprint struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Device  uint64    `json:"device,omitempty"`
+	Inode   uint64    `json:"inode,omitempty"` // 0 where the platform has no inodes
+}
+
+// fingerprint returns the fingerprint of the file at path
+func fingerprint(path string) (Fingerprint, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Fingerprint{}, err
+	}
+	f := Fingerprint{Size: info.Size(), ModTime: info.ModTime().UTC()}
+	f.Device, f.Inode = fileID(info)
+	return f, nil
+}
+
+// SameFile reports whether g is the same, unmodified file as f. Inodes are
+// compared when both are known, and size and modification time must agree.
+func (f Fingerprint) SameFile(g Fingerprint) bool {
+	if f.Inode != 0 && g.Inode != 0 && (f.Inode != g.Inode || f.Device != g.Device) {
+		return false
+	}
+	return !f.Modified(g)
+}
+
+// Modified reports whether the contents behind g differ from f by size or
+// modification time
+func (f Fingerprint) Modified(g Fingerprint) bool {
+	return f.Size != g.Size || !f.ModTime.Equal(g.ModTime)
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package backup
+
+import "os"
+
+// fileID returns zero on platforms without inode numbers
+func fileID(info os.FileInfo) (device, inode uint64) {
+	return 0, 0
+}
+
+// linkCount reports a single link where hardlinks cannot be counted
+func linkCount(info os.FileInfo) uint64 {
+	return 1
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package backup
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID returns the device and inode numbers of a file
+func fileID(info os.FileInfo) (device, inode uint64) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Dev), uint64(st.Ino)
+	}
+	return 0, 0
+}
+
+// linkCount returns the number of hardlinks to a file
+func linkCount(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Nlink)
+	}
+	return 1
+}
@@ -0,0 +1,177 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// Usage returns the disk usage of a generation. Hardlinked backup files
+// share their data with the media and are not counted in Size.
+func (m *Manager) Usage(ctx context.Context, gen types.BackupRecord) types.BackupUsage {
+	usage := types.BackupUsage{BackupRecord: gen}
+	entries, err := os.ReadDir(gen.Path)
+	if err != nil {
+		usage.Missing = os.IsNotExist(err)
+		return usage
+	}
+
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		switch {
+		case e.Name() == MappingsFileName || e.Name() == MetaFileName:
+			usage.Size += info.Size()
+		case linkCount(info) > 1:
+			usage.Linked++
+		default:
+			usage.Copied++
+			usage.Size += info.Size()
+		}
+	}
+
+	// Copies in the central object store
+	for _, hash := range readMeta(gen.Path).Objects {
+		if info, err := os.Stat(m.objectPath(hash)); err == nil {
+			usage.Copied++
+			usage.Size += info.Size()
+		}
+	}
+	return usage
+}
+
+// Verify checks that a generation is complete and that undo can restore
+// each of its files
+func (m *Manager) Verify(ctx context.Context, gen types.BackupRecord) []types.BackupIssue {
+	issue := func(file, problem string, restorable bool) types.BackupIssue {
+		return types.BackupIssue{SourceDir: gen.SourceDir, Generation: gen.Generation, File: file, Problem: problem, Restorable: restorable}
+	}
+
+	if _, err := os.Stat(gen.Path); err != nil {
+		return []types.BackupIssue{issue("", "backup directory is missing", false)}
+	}
+	mappings, err := readMappings(gen.Path)
+	if err != nil {
+		return []types.BackupIssue{issue("", fmt.Sprintf("%s is unreadable: %v", MappingsFileName, err), false)}
+	}
+	meta := readMeta(gen.Path)
+	journal := meta.Mode == types.BackupModeJournal
+
+	names := make([]string, 0, len(mappings))
+	for oldName := range mappings {
+		names = append(names, oldName)
+	}
+	slices.Sort(names)
+
+	var issues []types.BackupIssue
+	for _, oldName := range names {
+		newName := mappings[oldName]
+		if journal {
+			if _, ok := meta.Fingerprints[oldName]; !ok {
+				issues = append(issues, issue(oldName, "no fingerprint recorded", true))
+			}
+		} else if _, err := os.Stat(m.backupSource(gen.Path, meta, oldName)); err != nil {
+			issues = append(issues, issue(oldName, "backup copy is missing", false))
+			continue
+		}
+
+		// Conflicts can be forced, except a missing file with no copy to restore
+		if reason := checkFile(gen.SourceDir, oldName, newName, meta); reason != "" {
+			restorable := !journal || reason != types.ConflictMissing
+			issues = append(issues, issue(oldName, conflictText(reason), restorable))
+		}
+	}
+	return issues
+}
+
+// Prune drops registry records whose backup directory is gone. With
+// opts.Orphaned it deletes central generations whose media directory is
+// gone, and with opts.MaxAge every generation older than that.
+func (m *Manager) Prune(ctx context.Context, opts types.PruneOptions) (*types.PruneReport, error) {
+	records, err := m.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &types.PruneReport{}
+
+	// Move legacy backups into generations so they can be pruned by age
+	for _, r := range records {
+		if r.Generation != "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(r.Path, MappingsFileName)); err != nil {
+			continue
+		}
+		if err := m.migrateLegacy(r.SourceDir); err != nil {
+			return nil, err
+		}
+		report.Migrated = append(report.Migrated, r.SourceDir)
+		m.emit(types.EventInfo, fmt.Sprintf("Moved the legacy backup of %s into a generation", r.SourceDir), types.BackupEvent{})
+	}
+	if len(report.Migrated) > 0 {
+		if records, err = m.ListAll(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	stale := make(map[string]bool)
+	for _, r := range records {
+		if _, err := os.Stat(r.Path); os.IsNotExist(err) {
+			stale[r.Path] = true
+			report.Stale = append(report.Stale, r)
+		}
+	}
+	if len(stale) > 0 {
+		err := m.updateRegistry(func(records []types.BackupRecord) []types.BackupRecord {
+			return slices.DeleteFunc(records, func(r types.BackupRecord) bool { return stale[r.Path] })
+		})
+		if err != nil {
+			return report, err
+		}
+	}
+
+	// Central generations outlive their media directory. It may only be on
+	// an unmounted drive, so they are reported and kept unless asked for.
+	for _, r := range records {
+		if stale[r.Path] || !m.inStore(r.Path) {
+			continue
+		}
+		if _, err := os.Stat(r.SourceDir); !os.IsNotExist(err) {
+			continue
+		}
+		report.Orphaned = append(report.Orphaned, r)
+		if !opts.Orphaned {
+			continue
+		}
+		if err := m.removeGeneration(r); err != nil {
+			return report, err
+		}
+		stale[r.Path] = true
+		m.emit(types.EventInfo, fmt.Sprintf("Pruned backup generation %s of missing %s", r.Generation, r.SourceDir),
+			types.BackupEvent{Action: types.BackupActionPruned, Generation: r.Generation})
+	}
+
+	if opts.MaxAge <= 0 {
+		return report, nil
+	}
+	now := time.Now()
+	for _, r := range records {
+		if stale[r.Path] || now.Sub(r.Timestamp) <= opts.MaxAge {
+			continue
+		}
+		if err := m.removeGeneration(r); err != nil {
+			return report, err
+		}
+		report.Removed = append(report.Removed, r)
+		m.emit(types.EventInfo, fmt.Sprintf("Pruned backup generation %s of %s", r.Generation, r.SourceDir),
+			types.BackupEvent{Action: types.BackupActionPruned, Generation: r.Generation})
+	}
+	return report, nil
+}
@@ -0,0 +1,318 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mydehq/autotitle/internal/matcher"
+	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
+)
+
+// asideSuffix marks files moved out of the way by ConflictRenameAside. It
+// has no video extension so the renamer does not pick the files up again.
+const asideSuffix = ".autotitle-conflict"
+
+// reEpisodeRange recognizes --only values meant as episode ranges
+var reEpisodeRange = regexp.MustCompile(`^[\d\s,-]+$`)
+
+// restoreItem is one mapping selected for restore
+type restoreItem struct {
+	oldName, newName string
+	reason           string // Conflict reason, empty when the file matches its backup
+}
+
+// Restore undoes the latest generation, or with opts.Generation set, every
+// generation from the newest back to and including that one. Files changed
+// since the backup are handled according to opts.Conflict; files that are
+// not restored stay in their generation so a later undo can retry them.
+func (m *Manager) Restore(ctx context.Context, dir string, opts types.RestoreOptions) (*types.RestoreReport, error) {
+	switch opts.Conflict {
+	case "", types.ConflictSkip, types.ConflictForce, types.ConflictRenameAside:
+	default:
+		return nil, fmt.Errorf("unknown conflict policy %q (use %s, %s or %s)", opts.Conflict, types.ConflictSkip, types.ConflictForce, types.ConflictRenameAside)
+	}
+	only, err := restoreFilter(opts.Only)
+	if err != nil {
+		return nil, err
+	}
+
+	gens, err := m.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(gens) == 0 {
+		return nil, types.ErrBackupNotFound{Directory: dir}
+	}
+
+	oldest := len(gens) - 1
+	if opts.Generation != "" {
+		oldest = slices.IndexFunc(gens, func(r types.BackupRecord) bool { return r.Generation == opts.Generation })
+		if oldest < 0 {
+			return nil, fmt.Errorf("unknown backup generation %q", opts.Generation)
+		}
+	}
+
+	report := &types.RestoreReport{}
+	for i := len(gens) - 1; i >= oldest; i-- {
+		if err := m.restoreGeneration(gens[i], only, opts.Conflict, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// restoreGeneration reverses the renames recorded in one generation. Every
+// selected file is checked before any file is touched. The generation is
+// dropped once all its files are back.
+func (m *Manager) restoreGeneration(gen types.BackupRecord, only func(oldName, newName string) bool, policy string, report *types.RestoreReport) error {
+	mappings, err := readMappings(gen.Path)
+	if err != nil {
+		return err
+	}
+	meta := readMeta(gen.Path)
+
+	var items []restoreItem
+	for oldName, newName := range mappings {
+		if only != nil && !only(oldName, newName) {
+			continue
+		}
+		items = append(items, restoreItem{oldName, newName, checkFile(gen.SourceDir, oldName, newName, meta)})
+	}
+	slices.SortFunc(items, func(a, b restoreItem) int { return strings.Compare(a.oldName, b.oldName) })
+
+	restored := make(map[string]bool)
+	for i, it := range items {
+		progress := types.BackupEvent{File: it.oldName, Generation: gen.Generation, Done: i + 1, Total: len(items)}
+		if it.reason != "" && (policy == "" || policy == types.ConflictSkip) {
+			report.Conflicts = append(report.Conflicts, types.RestoreConflict{
+				Original: it.oldName, Renamed: it.newName, Reason: it.reason, Action: "skipped",
+			})
+			progress.Action = types.BackupActionSkipped
+			m.emit(types.EventWarning, fmt.Sprintf("Skipped %s: %s", it.newName, conflictText(it.reason)), progress)
+			continue
+		}
+
+		conflict, err := m.restoreFile(gen, meta, it, policy)
+		if err != nil {
+			report.Conflicts = append(report.Conflicts, types.RestoreConflict{
+				Original: it.oldName, Renamed: it.newName, Reason: it.reason, Action: "failed", Error: err.Error(),
+			})
+			progress.Action = types.BackupActionFailed
+			m.emit(types.EventError, fmt.Sprintf("Failed to restore %s: %v", it.oldName, err), progress)
+			continue
+		}
+		if conflict != nil {
+			report.Conflicts = append(report.Conflicts, *conflict)
+		}
+		restored[it.oldName] = true
+		report.Restored = append(report.Restored, it.oldName)
+		progress.Action = types.BackupActionRestored
+		m.emit(types.EventSuccess, fmt.Sprintf("Restored: %s → %s", it.newName, it.oldName), progress)
+	}
+
+	if len(restored) == len(mappings) {
+		return m.removeGeneration(gen)
+	}
+	if len(restored) == 0 {
+		return nil
+	}
+	return m.shrinkGeneration(gen, mappings, meta, restored)
+}
+
+// checkFile returns why restoring oldName could lose data, or "" when the
+// renamed file is still the one autotitle produced and the original name
+// is free
+func checkFile(dir, oldName, newName string, meta generationMeta) string {
+	current, err := fingerprint(filepath.Join(dir, newName))
+	if err != nil {
+		return types.ConflictMissing
+	}
+	if recorded, ok := meta.Fingerprints[oldName]; ok && !recorded.SameFile(current) {
+		return types.ConflictModified
+	}
+	if oldName != newName {
+		if _, err := os.Lstat(filepath.Join(dir, oldName)); err == nil {
+			return types.ConflictOccupied
+		}
+	}
+	return ""
+}
+
+// restoreFile puts one file back under its original name. Under
+// ConflictRenameAside, files that would be replaced or deleted are moved
+// aside instead. The returned conflict describes what was done about it.
+func (m *Manager) restoreFile(gen types.BackupRecord, meta generationMeta, it restoreItem, policy string) (*types.RestoreConflict, error) {
+	oldPath := filepath.Join(gen.SourceDir, it.oldName)
+	newPath := filepath.Join(gen.SourceDir, it.newName)
+	journal := meta.Mode == types.BackupModeJournal
+
+	var conflict *types.RestoreConflict
+	if it.reason != "" {
+		conflict = &types.RestoreConflict{Original: it.oldName, Renamed: it.newName, Reason: it.reason, Action: "forced"}
+	}
+	if journal && it.reason == types.ConflictMissing {
+		return nil, errors.New("the renamed file is gone and journal backups keep no copy")
+	}
+
+	// Clear the original name
+	if it.oldName != it.newName {
+		if _, err := os.Lstat(oldPath); err == nil {
+			if conflict == nil {
+				return nil, errors.New("a file with the original name appeared during the restore")
+			}
+			if policy == types.ConflictRenameAside {
+				aside, err := moveAside(oldPath)
+				if err != nil {
+					return nil, err
+				}
+				conflict.Action, conflict.Aside = "moved-aside", filepath.Base(aside)
+			} else if err := os.Remove(oldPath); err != nil {
+				return nil, fmt.Errorf("failed to replace %s: %w", it.oldName, err)
+			}
+		}
+	}
+
+	if it.oldName == it.newName {
+		return conflict, nil // Nothing was renamed
+	}
+	// An unmodified renamed file is the original itself; moving it back keeps
+	// the identity older generations recorded. The backup copy is only for
+	// files that are gone or were changed.
+	if journal || (it.reason != types.ConflictMissing && it.reason != types.ConflictModified) {
+		if err := os.Rename(newPath, oldPath); err != nil {
+			return nil, fmt.Errorf("failed to restore file: %w", err)
+		}
+		return conflict, nil
+	}
+
+	if err := copyFile(m.backupSource(gen.Path, meta, it.oldName), oldPath); err != nil {
+		return nil, fmt.Errorf("failed to restore file: %w", err)
+	}
+	if it.reason == types.ConflictMissing {
+		return conflict, nil
+	}
+	// The renamed file holds edits the backup lacks; keep them around
+	if it.reason == types.ConflictModified && policy == types.ConflictRenameAside {
+		aside, err := moveAside(newPath)
+		if err != nil {
+			return nil, err
+		}
+		conflict.Action, conflict.Aside = "moved-aside", filepath.Base(aside)
+		return conflict, nil
+	}
+	if err := os.Remove(newPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove %s: %w", it.newName, err)
+	}
+	return conflict, nil
+}
+
+// shrinkGeneration drops restored files from a generation, keeping the rest
+// for a later undo
+func (m *Manager) shrinkGeneration(gen types.BackupRecord, mappings map[string]string, meta generationMeta, restored map[string]bool) error {
+	for oldName := range restored {
+		delete(mappings, oldName)
+		delete(meta.Fingerprints, oldName)
+		delete(meta.Objects, oldName)
+		if meta.Mode != types.BackupModeJournal {
+			_ = os.Remove(filepath.Join(gen.Path, oldName))
+		}
+	}
+	if err := writeMeta(gen.Path, meta); err != nil {
+		return err
+	}
+	if err := writeMappings(gen.Path, mappings); err != nil {
+		return err
+	}
+	if err := m.collectObjects(); err != nil {
+		return err
+	}
+
+	return m.updateRegistry(func(records []types.BackupRecord) []types.BackupRecord {
+		for i := range records {
+			if records[i].Path == gen.Path {
+				records[i].Files = len(mappings)
+			}
+		}
+		return records
+	})
+}
+
+// restoreFilter returns a predicate for RestoreOptions.Only, or nil when
+// every file is restored
+func restoreFilter(only string) (func(oldName, newName string) bool, error) {
+	if only == "" {
+		return nil, nil
+	}
+
+	if reEpisodeRange.MatchString(only) {
+		nums, err := util.ParseRanges(only)
+		if err != nil {
+			return nil, fmt.Errorf("invalid episode range %q: %w", only, err)
+		}
+		return func(oldName, newName string) bool {
+			for _, name := range []string{oldName, newName} {
+				if n, ok := episodeNumber(name); ok {
+					return slices.Contains(nums, n)
+				}
+			}
+			return false
+		}, nil
+	}
+
+	if _, err := filepath.Match(only, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", only, err)
+	}
+	return func(oldName, newName string) bool {
+		a, _ := filepath.Match(only, oldName)
+		b, _ := filepath.Match(only, newName)
+		return a || b
+	}, nil
+}
+
+// episodeNumber guesses the episode number in a file name
+func episodeNumber(name string) (int, bool) {
+	p, err := matcher.Compile(matcher.GuessPattern(name))
+	if err != nil {
+		return 0, false
+	}
+	res, ok := p.MatchTyped(name)
+	if !ok || res.EpisodeNum == 0 {
+		return 0, false
+	}
+	return res.EpisodeNum, true
+}
+
+// moveAside renames path to an unused "<name>.autotitle-conflict" name
+func moveAside(path string) (string, error) {
+	aside := path + asideSuffix
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(aside); os.IsNotExist(err) {
+			break
+		}
+		aside = fmt.Sprintf("%s%s-%d", path, asideSuffix, n)
+	}
+	if err := os.Rename(path, aside); err != nil {
+		return "", fmt.Errorf("failed to move %s aside: %w", filepath.Base(path), err)
+	}
+	return aside, nil
+}
+
+// conflictText describes a conflict reason for messages
+func conflictText(reason string) string {
+	switch reason {
+	case types.ConflictMissing:
+		return "renamed file is missing or was renamed again"
+	case types.ConflictModified:
+		return "file changed since the backup"
+	case types.ConflictOccupied:
+		return "another file has the original name"
+	}
+	return reason
+}
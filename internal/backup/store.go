@@ -0,0 +1,175 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mydehq/autotitle/internal/util"
+)
+
+const (
+	StoreDirName   = "backups" // Central backup store under the cache root
+	objectsDirName = "objects" // Content-addressed copies inside the store
+	storeLockName  = ".lock"   // Held while objects are stored or collected
+)
+
+// root returns the directory new generations of absDir are written to
+func (m *Manager) root(absDir string) string {
+	if m.central {
+		return filepath.Join(m.storeDir, storeKey(absDir))
+	}
+	return filepath.Join(absDir, m.dirName)
+}
+
+// roots returns every directory that may hold generations of absDir, so
+// backups stay restorable after the configured location changes
+func (m *Manager) roots(absDir string) []string {
+	return []string{filepath.Join(absDir, m.dirName), filepath.Join(m.storeDir, storeKey(absDir))}
+}
+
+// inStore reports whether path is inside the central store
+func (m *Manager) inStore(path string) bool {
+	rel, err := filepath.Rel(m.storeDir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// storeKey names a media directory's folder in the central store: its base
+// name for readability plus a hash of the full path for uniqueness
+func storeKey(absDir string) string {
+	sum := sha256.Sum256([]byte(absDir))
+	name := strings.Map(func(r rune) rune {
+		if r == os.PathSeparator || r == '/' || r == ':' {
+			return '_'
+		}
+		return r
+	}, filepath.Base(absDir))
+	return name + "-" + hex.EncodeToString(sum[:6])
+}
+
+// storeFile backs up src as name in the generation at backupPath. A hardlink
+// is used where possible; in the central store, files on another filesystem
+// are copied into the object store instead and their hash is returned.
+func (m *Manager) storeFile(src, backupPath, name string) (string, error) {
+	dst := filepath.Join(backupPath, name)
+	if !m.central {
+		return "", copyFile(src, dst)
+	}
+	if err := os.Link(src, dst); err == nil {
+		return "", nil
+	}
+	return m.storeObject(src)
+}
+
+// storeObject copies src into the object store under its SHA-256 hash.
+// Identical content is stored once.
+func (m *Manager) storeObject(src string) (string, error) {
+	dir := filepath.Join(m.storeDir, objectsDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = in.Close() }()
+
+	tmp, err := os.CreateTemp(dir, ".object-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), in)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	path := m.objectPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil // Already stored
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// objectPath returns where the object with the given hash is stored
+func (m *Manager) objectPath(hash string) string {
+	return filepath.Join(m.storeDir, objectsDirName, hash[:2], hash)
+}
+
+// backupSource returns the path of a file's backup copy in a generation
+func (m *Manager) backupSource(backupPath string, meta generationMeta, oldName string) string {
+	if hash := meta.Objects[oldName]; hash != "" {
+		return m.objectPath(hash)
+	}
+	return filepath.Join(backupPath, oldName)
+}
+
+// lockStore takes the central store lock and returns its release function
+func (m *Manager) lockStore() (func(), error) {
+	if err := os.MkdirAll(m.storeDir, 0755); err != nil {
+		return nil, err
+	}
+	l, err := util.Lock(filepath.Join(m.storeDir, storeLockName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock backup store: %w", err)
+	}
+	return func() { _ = l.Unlock() }, nil
+}
+
+// collectObjects deletes objects no generation in the central store refers
+// to any more. While another process is storing a backup, collection is
+// left to a later run.
+func (m *Manager) collectObjects() error {
+	objects := filepath.Join(m.storeDir, objectsDirName)
+	if _, err := os.Stat(objects); err != nil {
+		return nil
+	}
+
+	unlock, err := m.lockStore()
+	if errors.Is(err, util.ErrLocked) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	used := make(map[string]bool)
+	metas, _ := filepath.Glob(filepath.Join(m.storeDir, "*", "*", MetaFileName))
+	for _, path := range metas {
+		for _, hash := range readMeta(filepath.Dir(path)).Objects {
+			used[hash] = true
+		}
+	}
+
+	return filepath.WalkDir(objects, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return err // Temporary files belong to backups in progress
+		}
+		if !used[d.Name()] {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove unused backup object: %w", err)
+			}
+			_ = os.Remove(filepath.Dir(path)) // Only succeeds when empty
+		}
+		return nil
+	})
+}
@@ -0,0 +1,121 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
+)
+
+func TestStoreObject(t *testing.T) {
+	m := New(t.TempDir(), "").WithLocation(types.BackupLocationCentral)
+	dir := t.TempDir()
+	for _, name := range []string{"a.mkv", "b.mkv"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("same content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h1, err := m.storeObject(filepath.Join(dir, "a.mkv"))
+	if err != nil {
+		t.Fatalf("storeObject failed: %v", err)
+	}
+	h2, _ := m.storeObject(filepath.Join(dir, "b.mkv"))
+	if h1 != h2 {
+		t.Errorf("identical files stored under %s and %s", h1, h2)
+	}
+	if data, err := os.ReadFile(m.objectPath(h1)); err != nil || string(data) != "same content" {
+		t.Fatalf("object content = %q, %v", data, err)
+	}
+
+	// Nothing refers to the object yet
+	if err := m.collectObjects(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(m.objectPath(h1)); !os.IsNotExist(err) {
+		t.Error("unreferenced object not collected")
+	}
+}
+
+// TestCollectObjects_BackupInProgress covers a collection running while
+// another process has stored objects but not yet written the meta
+func TestCollectObjects_BackupInProgress(t *testing.T) {
+	oldWait := util.LockWait
+	t.Cleanup(func() { util.LockWait = oldWait })
+	util.LockWait = 100 * time.Millisecond
+
+	m := New(t.TempDir(), "").WithLocation(types.BackupLocationCentral)
+	src := filepath.Join(t.TempDir(), "a.mkv")
+	if err := os.WriteFile(src, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := m.lockStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := m.storeObject(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := New(filepath.Dir(m.storeDir), "").WithLocation(types.BackupLocationCentral)
+	if err := other.collectObjects(); err != nil {
+		t.Fatalf("collectObjects failed: %v", err)
+	}
+	if _, err := os.Stat(m.objectPath(hash)); err != nil {
+		t.Error("object of a backup in progress was collected")
+	}
+	unlock()
+}
+
+// TestRestoreFromObject covers media on another filesystem than the cache,
+// where originals are copied into the object store instead of hardlinked
+func TestRestoreFromObject(t *testing.T) {
+	ctx := context.Background()
+	m := New(t.TempDir(), "").WithLocation(types.BackupLocationCentral)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ep01.mkv"), []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Backup(ctx, dir, map[string]string{"ep01.mkv": "Show - 01.mkv"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the hardlink with an object, as a cross-device backup would
+	gens, _ := m.List(ctx, dir)
+	gen := gens[0]
+	hash, err := m.storeObject(filepath.Join(dir, "ep01.mkv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Remove(filepath.Join(gen.Path, "ep01.mkv"))
+	meta := readMeta(gen.Path)
+	meta.Objects = map[string]string{"ep01.mkv": hash}
+	if err := writeMeta(gen.Path, meta); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "ep01.mkv"), filepath.Join(dir, "Show - 01.mkv")); err != nil {
+		t.Fatal(err)
+	}
+
+	if usage := m.Usage(ctx, gen); usage.Copied != 1 {
+		t.Errorf("usage = %+v, want 1 copied object", usage)
+	}
+	if issues := m.Verify(ctx, gen); len(issues) != 0 {
+		t.Errorf("Verify = %+v", issues)
+	}
+	if _, err := m.Restore(ctx, dir, types.RestoreOptions{}); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "ep01.mkv")); err != nil || string(data) != "original" {
+		t.Fatalf("restored content = %q, %v", data, err)
+	}
+	if _, err := os.Stat(m.objectPath(hash)); !os.IsNotExist(err) {
+		t.Error("object not collected after restore")
+	}
+}
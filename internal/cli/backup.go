@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mydehq/autotitle"
+	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagBackupOlderThan string
+	flagBackupOrphaned  bool
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Inspect and maintain backups",
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list [path]",
+	Short: "List backups with their size on disk",
+	Long: `List backup generations with their file count and size on disk.
+Without a path every directory in the backup registry is listed. Hardlinked
+backups share their data with the renamed files and take no extra space.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runBackupList(cmd.Context(), optionalArg(args))
+	},
+}
+
+var backupVerifyCmd = &cobra.Command{
+	Use:   "verify [path]",
+	Short: "Check that backups are complete and restorable",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runBackupVerify(cmd.Context(), optionalArg(args))
+	},
+}
+
+var backupPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Drop stale registry entries and old generations",
+	Long: `Drop backup registry entries whose backup directory no longer exists.
+With --older-than, also delete every generation older than the given age
+(units h, d, w; e.g. 90d).
+
+Central backups of directories that are missing are only listed, as the
+directory may be on an unmounted drive. --orphaned deletes them.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runBackupPrune(cmd.Context())
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupListCmd, backupVerifyCmd, backupPruneCmd)
+	backupPruneCmd.Flags().StringVar(&flagBackupOlderThan, "older-than", "", "Also delete generations older than this (e.g. 90d)")
+	backupPruneCmd.Flags().BoolVar(&flagBackupOrphaned, "orphaned", false, "Also delete central backups of missing directories")
+}
+
+func optionalArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+func runBackupList(ctx context.Context, path string) {
+	items, err := autotitle.BackupList(ctx, path)
+	if err != nil {
+		fail("Failed to list backups", err)
+	}
+	if items == nil {
+		items = []types.BackupUsage{}
+	}
+	emitResult(items)
+	if len(items) == 0 {
+		logger.Info("No backups found")
+		exit(ExitNoMatch)
+	}
+
+	var total int64
+	logger.Info(fmt.Sprintf("%s count: %s", StyleHeader.Render("Backup generations"), StylePattern.Render(fmt.Sprint(len(items)))))
+	for i, item := range items {
+		if i == 0 || items[i-1].SourceDir != item.SourceDir {
+			logger.Print(fmt.Sprintf("  %s", StylePath.Render(item.SourceDir)))
+		}
+		total += item.Size
+
+		if item.Missing {
+			logger.Print(fmt.Sprintf("    %s  %s  %s",
+				item.Generation,
+				item.Timestamp.Local().Format("2006-01-02 15:04:05"),
+				StyleDim.Render("(missing; run backup prune)"),
+			))
+			continue
+		}
+		logger.Print(fmt.Sprintf("    %s  %s  %s  %s  %s",
+			item.Generation,
+			item.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			fmt.Sprintf("%3d files", item.Files),
+			StylePattern.Render(fmt.Sprintf("%9s", formatSize(item.Size))),
+			StyleDim.Render(storageKind(item)),
+		))
+	}
+	logger.Info(fmt.Sprintf("Total size on disk: %s", StylePattern.Render(formatSize(total))))
+}
+
+func runBackupVerify(ctx context.Context, path string) {
+	report, err := autotitle.BackupVerify(ctx, path)
+	if err != nil {
+		fail("Failed to verify backups", err)
+	}
+	emitResult(report)
+
+	broken := 0
+	for _, issue := range report.Issues {
+		file := issue.File
+		if file == "" {
+			file = "generation"
+		}
+		msg := fmt.Sprintf("%s %s: %s", StylePath.Render(issue.SourceDir), issue.Generation, file)
+		if issue.Restorable {
+			logger.Warn(msg, "problem", issue.Problem)
+		} else {
+			broken++
+			logger.Error(msg, "problem", issue.Problem)
+		}
+	}
+
+	switch {
+	case report.Checked == 0:
+		logger.Info("No backups found")
+	case len(report.Issues) == 0:
+		logger.Info(StyleHeader.Render(fmt.Sprintf("Verified %d generation(s): all files restorable", report.Checked)))
+	default:
+		logger.Info(fmt.Sprintf("Verified %d generation(s): %d issue(s), %d not restorable", report.Checked, len(report.Issues), broken))
+		if broken < len(report.Issues) {
+			logger.Info(fmt.Sprintf("Conflicting files can still be restored with %s", StyleCommand.Render("undo --conflict")))
+		}
+	}
+	switch {
+	case broken > 0:
+		exit(ExitPartial)
+	case report.Checked == 0:
+		exit(ExitNoMatch)
+	}
+}
+
+func runBackupPrune(ctx context.Context) {
+	var maxAge time.Duration
+	if flagBackupOlderThan != "" {
+		age, err := util.ParseAge(flagBackupOlderThan)
+		if err != nil || age <= 0 {
+			failWith(ExitConfig, fmt.Sprintf("Invalid --older-than %q (e.g. 12h, 90d, 4w)", flagBackupOlderThan), nil)
+		}
+		maxAge = age
+	}
+
+	var opts []autotitle.Option
+	if flagBackupOrphaned {
+		opts = append(opts, autotitle.WithOrphanedBackups())
+	}
+	report, err := autotitle.BackupPrune(ctx, maxAge, opts...)
+	if err != nil {
+		fail("Failed to prune backups", err)
+	}
+	emitResult(report)
+	for _, dir := range report.Migrated {
+		logger.Print(fmt.Sprintf("  %s %s %s", StyleDim.Render("~"), StylePath.Render(dir), StyleDim.Render("(legacy backup moved into a generation)")))
+	}
+	for _, r := range report.Stale {
+		logger.Print(fmt.Sprintf("  %s %s %s", StyleDim.Render("-"), StylePath.Render(r.SourceDir), StyleDim.Render("(stale registry entry)")))
+	}
+	orphaned := 0
+	for _, r := range report.Orphaned {
+		note := "(directory missing, kept)"
+		if flagBackupOrphaned {
+			note = "(directory missing)"
+			orphaned++
+		}
+		logger.Print(fmt.Sprintf("  %s %s %s %s", StyleDim.Render("-"), StylePath.Render(r.SourceDir), r.Generation, StyleDim.Render(note)))
+	}
+	logger.Info(fmt.Sprintf("%s: %d stale registry entries, %d old generation(s), %d of missing directories",
+		StyleHeader.Render("Pruned backups"), len(report.Stale), len(report.Removed), orphaned))
+	if len(report.Orphaned) > 0 && !flagBackupOrphaned {
+		logger.Warn(fmt.Sprintf("%d backup generation(s) belong to missing directories; if they are gone for good, delete them with %s",
+			len(report.Orphaned), StyleCommand.Render("autotitle backup prune --orphaned")))
+	}
+}
+
+// storageKind describes how a generation stores its files
+func storageKind(u types.BackupUsage) string {
+	switch {
+	case u.Mode == types.BackupModeJournal:
+		return "journal"
+	case u.Copied == 0:
+		return "hardlink"
+	case u.Linked == 0:
+		return "copy"
+	}
+	return fmt.Sprintf("%d hardlinked, %d copied", u.Linked, u.Copied)
+}
+
+// formatSize renders a byte count with a binary unit
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -2,7 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/mydehq/autotitle"
 	"github.com/spf13/cobra"
@@ -28,21 +27,26 @@ func runClean(cmd *cobra.Command, args []string) {
 	ctx := cmd.Context()
 	if flagCleanAll {
 		if err := autotitle.CleanAll(ctx); err != nil {
-			logger.Error("Failed to clean global backups", "error", err)
-			os.Exit(1)
+			fail("Failed to clean global backups", err)
 		}
+		emitResult(cleanResult{All: true})
 		logger.Info(StyleHeader.Render("Removed all backups globally"))
 		return
 	}
 
 	if len(args) == 0 {
-		logger.Error("Please specify a path or use -a for global cleanup")
-		os.Exit(1)
+		failWith(ExitConfig, "Please specify a path or use -a for global cleanup", nil)
 	}
 
 	if err := autotitle.Clean(ctx, args[0]); err != nil {
-		logger.Error("Failed to remove backup", "path", args[0], "error", err)
-		os.Exit(1)
+		fail(fmt.Sprintf("Failed to remove backup for %s", args[0]), err)
 	}
+	emitResult(cleanResult{Path: args[0]})
 	logger.Info(fmt.Sprintf("%s: %s", StyleHeader.Render("Removed backup"), StylePath.Render(args[0])))
 }
+
+// cleanResult is the result of clean
+type cleanResult struct {
+	Path string `json:"path,omitempty"` // Directory whose backups were removed
+	All  bool   `json:"all,omitempty"`  // Every backup was removed
+}
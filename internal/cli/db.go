@@ -1,20 +1,31 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/mydehq/autotitle"
+	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
 	"github.com/spf13/cobra"
 )
 
 var (
-	flagDBFillerURL string
-	flagDBForce     bool
-	flagDBProvider  string
-	flagDBAll       bool
+	flagDBFillerURLs []string
+	flagDBForce      bool
+	flagDBProvider   string
+	flagDBAll        bool
+	flagDBFrom       string
+	flagDBTo         string
+	flagDBDryRun     bool
+	flagDBFile       string
+	flagDBStrategy   string
+	flagDBMapDirs    []string
+	flagDBEpisodes   string
 )
 
 var dbCmd = &cobra.Command{
@@ -48,6 +59,15 @@ var dbInfoCmd = &cobra.Command{
 	},
 }
 
+var dbSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search cached databases by title, alias or ID",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runDBSearch(cmd.Context(), strings.Join(args, " "))
+	},
+}
+
 var dbRmCmd = &cobra.Command{
 	Use:   "rm <provider>/<id>",
 	Short: "Remove a database",
@@ -59,27 +79,88 @@ var dbRmCmd = &cobra.Command{
 
 var dbPathCmd = &cobra.Command{
 	Use:   "path",
-	Short: "Show database directory path",
+	Short: "Show database path",
 	Run: func(cmd *cobra.Command, args []string) {
 		runDBPath()
 	},
 }
 
+var dbConvertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Import the JSON database cache into SQLite",
+	Long: `Import every entry of the JSON database cache into the SQLite database.
+Set "database: {backend: sqlite}" in the global config to use it afterwards.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runDBConvert(cmd.Context())
+	},
+}
+
+var dbRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Refetch cached databases that are due under the refresh policy",
+	Run: func(cmd *cobra.Command, args []string) {
+		runDBRefresh(cmd.Context())
+	},
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade cached databases to the current schema",
+	Run: func(cmd *cobra.Command, args []string) {
+		runDBMigrate(cmd.Context())
+	},
+}
+
+var dbExportCmd = &cobra.Command{
+	Use:   "export [<provider>/<id>...]",
+	Short: "Export cached databases to a bundle file",
+	Long: `Export cached databases (all of them when none are given) to a single
+bundle file that "autotitle db import" reads on another machine.
+Use --map to include the overrides of map files in those directories.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runDBExport(cmd.Context(), args)
+	},
+}
+
+var dbImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import cached databases from a bundle file",
+	Long: `Import cached databases from a bundle written by "autotitle db export".
+Existing entries are handled by --strategy:
+  newer      keep whichever copy was updated last (default)
+  overwrite  always replace the local copy
+  skip       never replace the local copy
+Use --map to merge bundled overrides into map files in those directories.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runDBImport(cmd.Context(), args[0])
+	},
+}
+
 func init() {
 	RootCmd.AddCommand(dbCmd)
-	dbCmd.AddCommand(dbGenCmd, dbListCmd, dbInfoCmd, dbRmCmd, dbPathCmd)
+	dbCmd.AddCommand(dbGenCmd, dbListCmd, dbSearchCmd, dbInfoCmd, dbRmCmd, dbPathCmd, dbConvertCmd, dbMigrateCmd, dbRefreshCmd, dbExportCmd, dbImportCmd)
 
-	dbGenCmd.Flags().StringVarP(&flagDBFillerURL, "filler", "F", "", "Filler list URL")
+	dbGenCmd.Flags().StringArrayVarP(&flagDBFillerURLs, "filler", "F", nil, "Filler list URL (repeatable)")
 	dbGenCmd.Flags().BoolVarP(&flagDBForce, "force", "f", false, "Overwrite existing database")
 	dbListCmd.Flags().StringVarP(&flagDBProvider, "provider", "p", "", "Filter by provider (mal, tmdb, etc)")
+	dbInfoCmd.Flags().StringVarP(&flagDBEpisodes, "episodes", "e", "", "Only list these episodes (e.g. 1-12,25)")
 	dbRmCmd.Flags().BoolVarP(&flagDBAll, "all", "a", false, "Remove all databases")
+	dbConvertCmd.Flags().StringVar(&flagDBFrom, "from", "", "JSON database directory (default ~/.cache/autotitle/db)")
+	dbRefreshCmd.Flags().BoolVarP(&flagDBForce, "force", "f", false, "Refresh every entry regardless of policy")
+	dbMigrateCmd.Flags().BoolVarP(&flagDBDryRun, "dry-run", "n", false, "Report entries needing migration without rewriting them")
+	dbConvertCmd.Flags().StringVar(&flagDBTo, "to", "", "SQLite database file (default ~/.cache/autotitle/db.sqlite)")
+	dbExportCmd.Flags().StringVarP(&flagDBFile, "file", "o", "autotitle-db.json.gz", "Bundle file to write (- for stdout)")
+	dbExportCmd.Flags().StringArrayVarP(&flagDBMapDirs, "map", "m", nil, "Directory whose map file overrides are exported (repeatable)")
+	dbImportCmd.Flags().StringVarP(&flagDBStrategy, "strategy", "s", autotitle.ImportNewer, "How to handle existing entries: newer, overwrite or skip")
+	dbImportCmd.Flags().StringArrayVarP(&flagDBMapDirs, "map", "m", nil, "Directory whose map file receives bundled overrides (repeatable)")
 }
 
 func runDBGen(ctx context.Context, url string) {
 	opts := []autotitle.Option{}
 
-	if flagDBFillerURL != "" {
-		opts = append(opts, autotitle.WithFiller(flagDBFillerURL))
+	if len(flagDBFillerURLs) > 0 {
+		opts = append(opts, autotitle.WithFiller(flagDBFillerURLs...))
 	}
 
 	if flagDBForce {
@@ -88,10 +169,10 @@ func runDBGen(ctx context.Context, url string) {
 
 	generated, err := autotitle.DBGen(ctx, url, opts...)
 	if err != nil {
-		logger.Error("Failed to generate database", "error", err)
-		os.Exit(1)
+		fail("Failed to generate database", err)
 	}
 
+	emitResult(dbGenResult{URL: url, Generated: generated})
 	if generated {
 		logger.Info(fmt.Sprintf("%s: %s", StyleHeader.Render("Database generated"), StylePath.Render(url)))
 	} else {
@@ -102,16 +183,44 @@ func runDBGen(ctx context.Context, url string) {
 func runDBList(ctx context.Context) {
 	items, err := autotitle.DBList(ctx, flagDBProvider)
 	if err != nil {
-		logger.Error("Failed to list databases", "error", err)
-		os.Exit(1)
+		fail("Failed to list databases", err)
 	}
 
+	emitSummaries(items)
 	if len(items) == 0 {
 		logger.Info("No databases found")
-		return
+		exit(ExitNoMatch)
 	}
 
 	logger.Info(fmt.Sprintf("%s count: %s", StyleHeader.Render("Cached databases"), StylePattern.Render(fmt.Sprint(len(items)))))
+	printSummaries(items)
+}
+
+func runDBSearch(ctx context.Context, query string) {
+	items, err := autotitle.DBSearch(ctx, query)
+	if err != nil {
+		fail("Failed to search databases", err)
+	}
+
+	emitSummaries(items)
+	if len(items) == 0 {
+		logger.Info(fmt.Sprintf("No cached databases match %s", StylePattern.Render(query)))
+		exit(ExitNoMatch)
+	}
+
+	logger.Info(fmt.Sprintf("%s count: %s", StyleHeader.Render("Matching databases"), StylePattern.Render(fmt.Sprint(len(items)))))
+	printSummaries(items)
+}
+
+// emitSummaries records a database listing as the command result
+func emitSummaries(items []autotitle.MediaSummary) {
+	if items == nil {
+		items = []autotitle.MediaSummary{}
+	}
+	emitResult(items)
+}
+
+func printSummaries(items []autotitle.MediaSummary) {
 	for _, item := range items {
 		logger.Print(fmt.Sprintf("  %s %s/%s: %s %s",
 			StyleDim.Render("-"),
@@ -126,20 +235,40 @@ func runDBList(ctx context.Context) {
 func runDBInfo(ctx context.Context, target string) {
 	parts := strings.Split(target, "/")
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-		logger.Error("Invalid format. Use: <provider>/<id> (e.g. mal/269)")
-		os.Exit(1)
+		failWith(ExitConfig, "Invalid format. Use: <provider>/<id> (e.g. mal/269)", nil)
 	}
 	prov, id := parts[0], parts[1]
 
+	var only []int
+	if flagDBEpisodes != "" {
+		nums, err := util.ParseRanges(flagDBEpisodes)
+		if err != nil {
+			fail("Invalid episode range", err)
+		}
+		only = nums
+	}
+
 	media, err := autotitle.DBInfo(ctx, prov, id)
 	if err != nil {
-		logger.Error("Failed to get database info", "error", err)
-		os.Exit(1)
+		fail("Failed to get database info", err)
 	}
 	if media == nil {
-		logger.Error("Database not found")
-		os.Exit(1)
+		failWith(ExitNoMatch, fmt.Sprintf("Database not found: %s/%s", prov, id), nil)
+	}
+
+	// Both output modes list only the requested episodes
+	listed := media.Episodes
+	if only != nil {
+		listed = make([]autotitle.Episode, 0, len(only))
+		for _, ep := range media.Episodes {
+			if slices.Contains(only, ep.Number) {
+				listed = append(listed, ep)
+			}
+		}
 	}
+	result := *media
+	result.Episodes = listed
+	emitResult(&result)
 
 	keyStyle := StyleHeader.Width(15)
 
@@ -150,42 +279,234 @@ func runDBInfo(ctx context.Context, target string) {
 	if media.FillerSource != "" {
 		logger.Print(fmt.Sprintf("%s %s", keyStyle.Render("Filler Source:"), media.FillerSource))
 	}
+
+	if only != nil && len(listed) == 0 {
+		logger.Warn(fmt.Sprintf("No episodes match %s", flagDBEpisodes))
+		exit(ExitNoMatch)
+	}
+	if len(listed) == 0 {
+		return
+	}
+	logger.Print("")
+
+	width := len(fmt.Sprint(media.Episodes[len(media.Episodes)-1].Number))
+	for _, ep := range listed {
+		line := fmt.Sprintf("  %s %s", StylePath.Render(fmt.Sprintf("%*d", width, ep.Number)), episodeMarker(&ep))
+		line += " " + ep.Title
+		if ep.AirDate != "" {
+			line += " " + StyleDim.Render("("+ep.AirDate+")")
+		}
+		logger.Print(line)
+	}
+}
+
+// episodeMarker returns a fixed-width filler marker for episode listings
+func episodeMarker(ep *autotitle.Episode) string {
+	switch ep.Classification() {
+	case types.FillerTypeFiller:
+		return styleFlag.Render(types.DefaultFillerMarker)
+	case types.FillerTypeMixed:
+		return StylePattern.Render(types.DefaultMixedMarker)
+	}
+	return strings.Repeat(" ", len(types.DefaultFillerMarker))
 }
 
 func runDBRm(ctx context.Context, args []string) {
 	if flagDBAll {
 		if err := autotitle.DBDeleteAll(ctx); err != nil {
-			logger.Error("Failed to delete all databases", "error", err)
-			os.Exit(1)
+			fail("Failed to delete all databases", err)
 		}
+		emitResult(dbRmResult{All: true})
 		logger.Info(StyleHeader.Render("Deleted all databases"))
 		return
 	}
 
 	if len(args) == 0 {
-		logger.Error("Usage: autotitle db rm <provider>/<id>")
-		os.Exit(1)
+		failWith(ExitConfig, "Usage: autotitle db rm <provider>/<id>", nil)
 	}
 
 	parts := strings.Split(args[0], "/")
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-		logger.Error("Invalid format. Use: <provider>/<id> (e.g. mal/269)")
-		os.Exit(1)
+		failWith(ExitConfig, "Invalid format. Use: <provider>/<id> (e.g. mal/269)", nil)
 	}
 	prov, id := parts[0], parts[1]
 
 	if err := autotitle.DBDelete(ctx, prov, id); err != nil {
-		logger.Error("Failed to delete database", "error", err)
-		os.Exit(1)
+		fail("Failed to delete database", err)
 	}
+	emitResult(dbRmResult{Provider: prov, ID: id})
 	logger.Info(fmt.Sprintf("%s: %s/%s", StyleHeader.Render("Deleted database"), prov, StylePath.Render(id)))
 }
 
 func runDBPath() {
 	path, err := autotitle.DBPath()
 	if err != nil {
-		logger.Error("Failed to get DB path", "error", err)
-		os.Exit(1)
+		fail("Failed to get DB path", err)
 	}
-	logger.Print(path)
+	emitResult(dbPathResult{Path: path})
+	if !structured() {
+		logger.Print(path)
+	}
+}
+
+func runDBConvert(ctx context.Context) {
+	count, err := autotitle.DBConvert(ctx, flagDBFrom, flagDBTo)
+	if err != nil {
+		fail("Failed to convert database", err)
+	}
+	emitResult(dbCountResult{Count: count})
+	logger.Info(fmt.Sprintf("%s: %s", StyleHeader.Render("Imported databases"), StylePattern.Render(fmt.Sprint(count))))
+}
+
+func runDBMigrate(ctx context.Context) {
+	report, err := autotitle.DBMigrate(ctx, flagDBDryRun)
+	if err != nil {
+		fail("Failed to migrate databases", err)
+	}
+
+	emitResult(report)
+	header := "Migrated databases"
+	if flagDBDryRun {
+		header = "Databases needing migration"
+	}
+	logger.Info(fmt.Sprintf("%s: %s of %d", StyleHeader.Render(header), StylePattern.Render(fmt.Sprint(report.Migrated)), report.Total))
+
+	versions := make([]int, 0, len(report.From))
+	for v := range report.From {
+		versions = append(versions, v)
+	}
+	slices.Sort(versions)
+	for _, v := range versions {
+		logger.Print(fmt.Sprintf("  %s from v%d: %d", StyleDim.Render("-"), v, report.From[v]))
+	}
+
+	for _, f := range report.Failed {
+		logger.Warn(fmt.Sprintf("%s/%s: %v", f.Provider, f.ID, f.Err))
+	}
+	if len(report.Failed) > 0 {
+		exit(ExitPartial)
+	}
+}
+
+func runDBRefresh(ctx context.Context) {
+	opts := []autotitle.Option{
+		autotitle.WithEvents(eventHandler(func(e autotitle.Event) {
+			switch e.Type {
+			case autotitle.EventProgress:
+				logger.Info(e.Message)
+			case autotitle.EventWarning:
+				logger.Warn(e.Message)
+			case autotitle.EventError:
+				logger.Error(e.Message)
+			}
+		})),
+	}
+	if flagDBForce {
+		opts = append(opts, autotitle.WithForce())
+	}
+
+	report, err := autotitle.DBRefresh(ctx, opts...)
+	if err != nil {
+		fail("Failed to refresh databases", err)
+	}
+
+	emitResult(report)
+	logger.Info(fmt.Sprintf("%s: %s refreshed, %d skipped, %d failed",
+		StyleHeader.Render("Refresh complete"),
+		StylePattern.Render(fmt.Sprint(report.Refreshed)),
+		report.Skipped,
+		len(report.Failed),
+	))
+	if len(report.Failed) > 0 {
+		exit(ExitPartial)
+	}
+}
+
+func runDBExport(ctx context.Context, refs []string) {
+	if flagDBFile == "-" {
+		if structured() {
+			failWith(ExitConfig, fmt.Sprintf("--file - writes the bundle to stdout, which --output %s already uses", flagOutput), nil)
+		}
+		if _, err := autotitle.DBExport(ctx, os.Stdout, refs, autotitle.WithMapDirs(flagDBMapDirs...)); err != nil {
+			fail("Failed to export databases", err)
+		}
+		return
+	}
+
+	// Build the bundle in memory so a failed export leaves an existing file alone
+	var buf bytes.Buffer
+	count, err := autotitle.DBExport(ctx, &buf, refs, autotitle.WithMapDirs(flagDBMapDirs...))
+	if err != nil {
+		fail("Failed to export databases", err)
+	}
+	if err := util.WriteFileAtomic(flagDBFile, buf.Bytes(), 0644); err != nil {
+		fail("Failed to write bundle", err)
+	}
+
+	emitResult(dbCountResult{Count: count, Path: flagDBFile})
+	logger.Info(fmt.Sprintf("%s: %s %s",
+		StyleHeader.Render("Exported databases"),
+		StylePattern.Render(fmt.Sprint(count)),
+		StyleDim.Render("→ "+flagDBFile),
+	))
+}
+
+func runDBImport(ctx context.Context, path string) {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fail("Failed to open bundle", err)
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+
+	report, err := autotitle.DBImport(ctx, in, flagDBStrategy, autotitle.WithMapDirs(flagDBMapDirs...))
+	if err != nil {
+		fail("Failed to import databases", err)
+	}
+
+	emitResult(report)
+	msg := fmt.Sprintf("%s: %s imported, %d skipped, %d failed",
+		StyleHeader.Render("Import complete"),
+		StylePattern.Render(fmt.Sprint(report.Imported)),
+		report.Skipped,
+		len(report.Failed),
+	)
+	if len(flagDBMapDirs) > 0 {
+		msg += fmt.Sprintf(", %d overrides merged", report.Overrides)
+	}
+	logger.Info(msg)
+
+	for _, f := range report.Failed {
+		logger.Warn(fmt.Sprintf("%s/%s: %v", f.Provider, f.ID, f.Err))
+	}
+	if len(report.Failed) > 0 {
+		exit(ExitPartial)
+	}
+}
+
+// dbGenResult is the result of db gen
+type dbGenResult struct {
+	URL       string `json:"url"`
+	Generated bool   `json:"generated"` // False when the database was already cached
+}
+
+// dbRmResult is the result of db rm
+type dbRmResult struct {
+	Provider string `json:"provider,omitempty"`
+	ID       string `json:"id,omitempty"`
+	All      bool   `json:"all,omitempty"`
+}
+
+// dbPathResult is the result of db path
+type dbPathResult struct {
+	Path string `json:"path"`
+}
+
+// dbCountResult is the result of db convert and db export
+type dbCountResult struct {
+	Count int    `json:"count"`          // Entries written
+	Path  string `json:"path,omitempty"` // Bundle file
 }
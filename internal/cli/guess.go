@@ -2,7 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/mydehq/autotitle/internal/config"
@@ -30,8 +29,7 @@ func init() {
 func runGuessPattern(path string) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to resolve path: %v", err))
-		os.Exit(1)
+		failWith(ExitConfig, "Failed to resolve path", err)
 	}
 
 	// Load global config to get supported formats
@@ -44,13 +42,24 @@ func runGuessPattern(path string) {
 
 	scanResult, err := config.Scan(absPath, formats)
 	if err != nil {
-		logger.Error(fmt.Sprintf("Failed to scan directory: %v", err))
-		os.Exit(1)
+		fail("Failed to scan directory", err)
+	}
+
+	patterns := scanResult.DetectedPatterns
+	if patterns == nil {
+		patterns = []string{}
+	}
+	emitResult(guessResult{Directory: absPath, Patterns: patterns})
+	if structured() {
+		if !scanResult.HasMedia {
+			exit(ExitNoMatch)
+		}
+		return
 	}
 
 	if !scanResult.HasMedia {
 		fmt.Printf("No media files found in: %s\n", StylePath.Render(absPath))
-		return
+		exit(ExitNoMatch)
 	}
 
 	fmt.Printf("%s in: %s\n", StyleHeader.Render("Detected patterns"), StylePath.Render(absPath))
@@ -58,3 +67,9 @@ func runGuessPattern(path string) {
 		fmt.Printf(" %s %s\n", StyleDim.Render("-"), StylePattern.Render(p))
 	}
 }
+
+// guessResult is the result of guess-pattern
+type guessResult struct {
+	Directory string   `json:"directory"`
+	Patterns  []string `json:"patterns"`
+}
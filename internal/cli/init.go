@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,18 +11,27 @@ import (
 )
 
 var (
-	flagInitURL       string
-	flagInitFillerURL string
-	flagInitForce     bool
-	flagInitOffset    int
-	flagInitSeparator string
-	flagInitPadding   int
+	flagInitURL        string
+	flagInitFillerURLs []string
+	flagInitForce      bool
+	flagInitOffset     int
+	flagInitSeparator  string
+	flagInitPadding    int
+	flagInitSearch     bool
+	flagInitQuery      string
+	flagInitNoIdentify bool
 )
 
 var initCmd = &cobra.Command{
 	Use:   "init [path]",
 	Short: "Create a new _autotitle.yml map file",
-	Args:  cobra.MaximumNArgs(1),
+	Long: `Create a new _autotitle.yml map file.
+Without --url, the series is identified from the filenames and written when
+the match is clear; otherwise the best candidates are listed. With --search,
+the directory name is used to search providers and the chosen series' URL
+(plus a matching filler list, if one is found) is written instead of a
+placeholder.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		path := "."
 		if len(args) > 0 {
@@ -34,17 +44,29 @@ var initCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(initCmd)
 	initCmd.Flags().StringVarP(&flagInitURL, "url", "u", "", "Provider URL (MAL, TMDB, etc)")
-	initCmd.Flags().StringVarP(&flagInitFillerURL, "filler", "F", "", "Filler list URL")
+	initCmd.Flags().StringArrayVarP(&flagInitFillerURLs, "filler", "F", nil, "Filler list URL (repeatable)")
 	initCmd.Flags().BoolVarP(&flagInitForce, "force", "f", false, "Overwrite existing config")
 	initCmd.Flags().IntVarP(&flagInitOffset, "offset", "o", 0, "Episode number offset")
 	initCmd.Flags().StringVarP(&flagInitSeparator, "separator", "S", " ", "Output separator")
 	initCmd.Flags().IntVarP(&flagInitPadding, "padding", "p", 0, "Episode number padding (e.g. 2 for 01)")
+	initCmd.Flags().BoolVarP(&flagInitSearch, "search", "s", false, "Search providers for the series and pick its URL")
+	initCmd.Flags().StringVar(&flagInitQuery, "query", "", "Search query for --search (default: derived from the directory name)")
+	initCmd.Flags().BoolVar(&flagInitNoIdentify, "no-identify", false, "Don't identify the series from filenames")
 }
 
 func runInit(cmd *cobra.Command, path string) {
+	url, fillerURLs := flagInitURL, flagInitFillerURLs
+	switch {
+	case url != "":
+	case flagInitSearch || flagInitQuery != "":
+		url, fillerURLs = searchInitURLs(cmd.Context(), path)
+	case !flagInitNoIdentify:
+		url, fillerURLs = identifyInitURLs(cmd.Context(), path)
+	}
+
 	opts := []autotitle.Option{
-		autotitle.WithURL(flagInitURL),
-		autotitle.WithFiller(flagInitFillerURL),
+		autotitle.WithURL(url),
+		autotitle.WithFiller(fillerURLs...),
 		autotitle.WithSeparator(flagInitSeparator),
 		autotitle.WithOffset(flagInitOffset),
 		autotitle.WithPadding(flagInitPadding),
@@ -55,10 +77,101 @@ func runInit(cmd *cobra.Command, path string) {
 	}
 
 	if err := autotitle.Init(cmd.Context(), path, opts...); err != nil {
-		logger.Error("Failed to init config", "error", err)
-		os.Exit(1)
+		fail("Failed to init config", err)
 	}
 
-	mapFile := "_autotitle.yml"
-	logger.Info(fmt.Sprintf("%s: %s", StyleHeader.Render("Created config"), StylePath.Render(filepath.Join(path, mapFile))))
+	mapFile := filepath.Join(path, "_autotitle.yml")
+	emitResult(initResult{Path: mapFile, URL: url, FillerURLs: fillerURLs})
+	logger.Info(fmt.Sprintf("%s: %s", StyleHeader.Render("Created config"), StylePath.Render(mapFile)))
+}
+
+// initResult is the result of init
+type initResult struct {
+	Path       string   `json:"path"` // Map file written
+	URL        string   `json:"url,omitempty"`
+	FillerURLs []string `json:"filler_urls,omitempty"`
+}
+
+// searchInitURLs searches providers for the series in path, lets the user pick
+// a result and returns its URL with a suggested filler list URL
+func searchInitURLs(ctx context.Context, path string) (string, []string) {
+	query := flagInitQuery
+	if query == "" {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			failWith(ExitConfig, "Failed to resolve path", err)
+		}
+		query = autotitle.SeriesQuery(filepath.Base(absPath))
+	}
+	if query == "" {
+		failWith(ExitConfig, "Could not derive a search query from the directory name; use --query", nil)
+	}
+
+	logger.Info(fmt.Sprintf("%s: %s", StyleHeader.Render("Searching"), StylePattern.Render(query)))
+	results, err := autotitle.Search(ctx, query)
+	if err != nil {
+		fail("Search failed", err)
+	}
+	if len(results) == 0 {
+		failWith(ExitNoMatch, fmt.Sprintf("No results for %q; use --query or --url", query), nil)
+	}
+
+	printSearchResults(textOut(), results)
+	choice, err := promptChoice(os.Stdin, len(results))
+	if err != nil {
+		failWith(ExitError, "Failed to read selection", err)
+	}
+	if choice < 0 {
+		failWith(ExitError, "Cancelled", nil)
+	}
+	picked := results[choice]
+	return picked.URL, initFillerURLs(ctx, picked.Title, query)
+}
+
+// identifyInitURLs identifies the series in path from its filenames. When the
+// match is not clear it lists the best candidates and returns no URL.
+func identifyInitURLs(ctx context.Context, path string) (string, []string) {
+	res, err := autotitle.Identify(ctx, path)
+	if err != nil {
+		logger.Warn("Could not identify series", "error", err)
+		return "", flagInitFillerURLs
+	}
+
+	if res.Confident() {
+		best := res.Best()
+		logger.Info(fmt.Sprintf("%s: %s %s",
+			StyleHeader.Render("Identified"),
+			best.Title,
+			StyleDim.Render(fmt.Sprintf("(%s, score %.2f)", best.URL, best.Score)),
+		))
+		return best.URL, initFillerURLs(ctx, best.Title, res.Query)
+	}
+
+	if len(res.Candidates) == 0 {
+		logger.Warn(fmt.Sprintf("No provider results for %q; set --url or use --search", res.Query))
+		return "", flagInitFillerURLs
+	}
+
+	logger.Warn(fmt.Sprintf("Could not identify %q with confidence; set --url or use --search. Best candidates:", res.Query))
+	for _, c := range res.Candidates[:min(3, len(res.Candidates))] {
+		logger.Print(fmt.Sprintf("  %s %.2f %s %s", StyleDim.Render("-"), c.Score, c.Title, StylePath.Render(c.URL)))
+	}
+	return "", flagInitFillerURLs
+}
+
+// initFillerURLs returns the --filler URLs, or a filler list suggested for
+// the series titles
+func initFillerURLs(ctx context.Context, titles ...string) []string {
+	if len(flagInitFillerURLs) > 0 {
+		return flagInitFillerURLs
+	}
+	fillerURL, err := autotitle.SuggestFiller(ctx, titles...)
+	if err != nil {
+		logger.Warn("Filler list lookup failed", "error", err)
+	}
+	if fillerURL == "" {
+		return nil
+	}
+	logger.Info(fmt.Sprintf("%s: %s", StyleHeader.Render("Filler list"), StylePath.Render(fillerURL)))
+	return []string{fillerURL}
 }
@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mydehq/autotitle"
+	"github.com/mydehq/autotitle/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// Output formats for --output
+const (
+	outputText   = "text"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+)
+
+// Exit codes, the same for every command
+const (
+	ExitOK      = 0 // Success
+	ExitError   = 1 // Unclassified failure
+	ExitPartial = 2 // The command ran, but some items failed or were skipped
+	ExitNoMatch = 3 // Nothing to work on: no media files, results, backups or entries
+	ExitConfig  = 4 // Missing or invalid map file, configuration or arguments
+	ExitNetwork = 5 // A provider or network request failed
+)
+
+// exitCodesHelp documents the exit codes in the root command's help
+const exitCodesHelp = `Exit codes:
+  0  success
+  1  unclassified failure
+  2  partial failure: some items failed or were skipped
+  3  nothing matched: no media files, results, backups or entries
+  4  missing or invalid map file, configuration or arguments
+  5  provider or network failure`
+
+var flagOutput string
+
+// record is one line of ndjson output
+type record struct {
+	Type string `json:"type"` // "event", "operation", "result" or "error"
+	Data any    `json:"data"`
+}
+
+// errorRecord describes the failure a command exited with
+type errorRecord struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"` // Exit code
+}
+
+// document is the single JSON value written in json mode when the
+// command exits
+type document struct {
+	Command    string                      `json:"command"`
+	Events     []autotitle.Event           `json:"events"`
+	Operations []autotitle.RenameOperation `json:"operations,omitempty"`
+	Result     any                         `json:"result,omitempty"`
+	Error      *errorRecord                `json:"error,omitempty"`
+	ExitCode   int                         `json:"exit_code"`
+}
+
+var (
+	outputMu      sync.Mutex
+	outputDoc     = document{Events: []autotitle.Event{}}
+	outputFlushed bool
+)
+
+// setupOutput validates --output. In structured modes stdout carries only
+// records, so log lines move to stderr.
+func setupOutput(cmd *cobra.Command) {
+	switch flagOutput {
+	case outputText:
+		fmt.Println()
+	case outputJSON, outputNDJSON:
+		logger.SetOutput(os.Stderr)
+		outputDoc.Command = commandName(cmd)
+	default:
+		out := flagOutput
+		flagOutput = outputText
+		failWith(ExitConfig, fmt.Sprintf("Unknown output format %q (use text, json or ndjson)", out), nil)
+	}
+}
+
+// commandName returns the command path without the program name, with
+// "rename" for the root command
+func commandName(cmd *cobra.Command) string {
+	root := cmd.Root()
+	if cmd == root {
+		return "rename"
+	}
+	return strings.TrimPrefix(cmd.CommandPath(), root.Name()+" ")
+}
+
+// structured reports whether output is json or ndjson
+func structured() bool {
+	return flagOutput == outputJSON || flagOutput == outputNDJSON
+}
+
+// blankLine separates blocks of text output
+func blankLine() {
+	if !structured() {
+		fmt.Println()
+	}
+}
+
+// textOut returns where human-readable text goes: stdout, or stderr in
+// structured modes
+func textOut() io.Writer {
+	if structured() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// writeRecord writes one ndjson line
+func writeRecord(typ string, data any) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	_ = json.NewEncoder(os.Stdout).Encode(record{Type: typ, Data: data})
+}
+
+// emitEvent records a progress event
+func emitEvent(e autotitle.Event) {
+	switch flagOutput {
+	case outputNDJSON:
+		writeRecord("event", e)
+	case outputJSON:
+		outputMu.Lock()
+		outputDoc.Events = append(outputDoc.Events, e)
+		outputMu.Unlock()
+	}
+}
+
+// emitOperations records rename operations
+func emitOperations(ops []autotitle.RenameOperation) {
+	switch flagOutput {
+	case outputNDJSON:
+		for _, op := range ops {
+			writeRecord("operation", op)
+		}
+	case outputJSON:
+		outputDoc.Operations = append(outputDoc.Operations, ops...)
+	}
+}
+
+// emitResult records the final result of a command
+func emitResult(v any) {
+	switch flagOutput {
+	case outputNDJSON:
+		writeRecord("result", v)
+	case outputJSON:
+		outputDoc.Result = v
+	}
+}
+
+// eventHandler returns an event handler that records events in structured
+// modes and passes them to text otherwise
+func eventHandler(text func(autotitle.Event)) autotitle.EventHandler {
+	return func(e autotitle.Event) {
+		if structured() {
+			emitEvent(e)
+			return
+		}
+		text(e)
+	}
+}
+
+// fail reports err and exits with the code matching its kind
+func fail(msg string, err error) {
+	failWith(exitCode(err), msg, err)
+}
+
+// failWith reports a failure and exits with code
+func failWith(code int, msg string, err error) {
+	if !structured() {
+		if err != nil {
+			logger.Error(msg, "error", err)
+		} else {
+			logger.Error(msg)
+		}
+		os.Exit(code)
+	}
+
+	if err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, err)
+	}
+	rec := &errorRecord{Message: msg, Code: code}
+	if flagOutput == outputNDJSON {
+		writeRecord("error", rec)
+	} else {
+		outputDoc.Error = rec
+	}
+	exit(code)
+}
+
+// exit ends the command with code, writing the json document first
+func exit(code int) {
+	flushOutput(code)
+	os.Exit(code)
+}
+
+// flushOutput writes the json document, once
+func flushOutput(code int) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if flagOutput != outputJSON || outputFlushed {
+		return
+	}
+	outputFlushed = true
+	outputDoc.ExitCode = code
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(outputDoc)
+}
+
+// exitCode classifies err into one of the documented exit codes
+func exitCode(err error) int {
+	var (
+		configErr    types.ErrConfigInvalid
+		providerErr  types.ErrProviderNotFound
+		fillerErr    types.ErrFillerSourceNotFound
+		apiErr       types.ErrAPIError
+		netErr       net.Error
+		backupErr    types.ErrBackupNotFound
+		databaseErr  types.ErrDatabaseNotFound
+		noMediaErr   types.ErrNoMediaFiles
+		noPatternErr types.ErrPatternNotMatched
+	)
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &configErr), errors.As(err, &providerErr), errors.As(err, &fillerErr):
+		return ExitConfig
+	case errors.As(err, &apiErr), errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded):
+		return ExitNetwork
+	case errors.As(err, &backupErr), errors.As(err, &databaseErr), errors.As(err, &noMediaErr), errors.As(err, &noPatternErr):
+		return ExitNoMatch
+	}
+	return ExitError
+}
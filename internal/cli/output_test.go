@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestOutputFlag checks that no command shadows the global --output flag
+// with a local one
+func TestOutputFlag(t *testing.T) {
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		if cmd != RootCmd && cmd.LocalNonPersistentFlags().Lookup("output") != nil {
+			t.Errorf("%q defines its own --output flag", cmd.CommandPath())
+		}
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	walk(RootCmd)
+
+	t.Cleanup(func() { flagOutput, flagDBFile = outputText, "" })
+	for _, tt := range []struct {
+		args         []string
+		output, file string
+	}{
+		{[]string{"search", "--output", "ndjson", "frieren"}, "ndjson", ""},
+		{[]string{"db", "export", "--output", "json", "-o", "bundle.json.gz"}, "json", "bundle.json.gz"},
+		{[]string{"backup", "list", "--output", "json"}, "json", ""},
+	} {
+		flagOutput, flagDBFile = outputText, ""
+		cmd, rest, err := RootCmd.Find(tt.args)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		if err := cmd.ParseFlags(rest); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+		if flagOutput != tt.output {
+			t.Errorf("%v: --output = %q, want %q", tt.args, flagOutput, tt.output)
+		}
+		if flagDBFile != tt.file {
+			t.Errorf("%v: --file = %q, want %q", tt.args, flagDBFile, tt.file)
+		}
+	}
+}
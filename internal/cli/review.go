@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mydehq/autotitle"
+)
+
+// reviewAction is the user's decision on one planned rename
+type reviewAction int
+
+const (
+	reviewAccept reviewAction = iota
+	reviewReject
+	reviewAcceptAll // Accept this and every remaining rename
+	reviewDone      // Reject the remaining renames and apply the accepted ones
+	reviewQuit      // Apply nothing
+)
+
+const reviewHelp = "y: rename, n: skip, e: edit the new name, <number>: use that episode number (as in the filename), " +
+	"a: rename this and all remaining, d: skip the rest and apply, q: quit without renaming"
+
+// reviewRenames plans the renames of path and asks for each whether to
+// apply it, then applies the accepted ones with the usual backup
+func reviewRenames(ctx context.Context, path string, opts []autotitle.Option) ([]autotitle.RenameOperation, error) {
+	plan, err := autotitle.PlanRename(ctx, path, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reviewPlan(plan, os.Stdin) {
+		for i := range plan.Operations {
+			plan.Skip(i)
+		}
+		logger.Info("Review cancelled, nothing renamed")
+		return plan.Operations, nil
+	}
+	if plan.Pending() == 0 {
+		return plan.Operations, nil
+	}
+
+	blankLine()
+	return plan.Apply(ctx)
+}
+
+// reviewPlan walks the pending operations of plan, skipping the rejected
+// ones. It returns false if the user quit.
+func reviewPlan(plan *autotitle.RenamePlan, in io.Reader) bool {
+	var pending []int
+	for i, op := range plan.Operations {
+		if op.Status == autotitle.StatusPending {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return true
+	}
+
+	reader := bufio.NewReader(in)
+	_, _ = fmt.Fprintf(textOut(), "%s %s\n\n",
+		StyleHeader.Render(fmt.Sprintf("Review %d renames", len(pending))),
+		StyleDim.Render("(? for help)"))
+
+	for n, i := range pending {
+		switch reviewOperation(plan, i, n+1, len(pending), reader) {
+		case reviewReject:
+			plan.Skip(i)
+		case reviewAcceptAll:
+			return true
+		case reviewDone:
+			for _, j := range pending[n:] {
+				plan.Skip(j)
+			}
+			return true
+		case reviewQuit:
+			return false
+		}
+	}
+	return true
+}
+
+// reviewOperation asks about operation i until the user decides, applying
+// edits of the name or episode number to plan along the way
+func reviewOperation(plan *autotitle.RenamePlan, i, n, total int, reader *bufio.Reader) reviewAction {
+	for {
+		printReviewItem(plan.Operations[i], n, total)
+
+		answer, eof := readAnswer(reader, "Rename? [Y/n/e/a/d/q or episode]:")
+		switch strings.ToLower(answer) {
+		case "":
+			if eof {
+				// Input ended: keep what was accepted so far
+				return reviewDone
+			}
+			return reviewAccept
+		case "y", "yes":
+			return reviewAccept
+		case "n", "no":
+			return reviewReject
+		case "a", "all":
+			return reviewAcceptAll
+		case "d", "done":
+			return reviewDone
+		case "q", "quit":
+			return reviewQuit
+		case "e", "edit":
+			name, _ := readAnswer(reader, "New name:")
+			if name == "" {
+				continue
+			}
+			if err := plan.SetTarget(i, name); err != nil {
+				logger.Warn(err.Error())
+			}
+			continue
+		}
+
+		if episode, err := strconv.Atoi(answer); err == nil {
+			if err := plan.SetEpisode(i, episode); err != nil {
+				logger.Warn(err.Error())
+			}
+			continue
+		}
+		logger.Warn(reviewHelp)
+		if eof {
+			return reviewDone
+		}
+	}
+}
+
+// printReviewItem shows a planned rename
+func printReviewItem(op autotitle.RenameOperation, n, total int) {
+	out := textOut()
+	_, _ = fmt.Fprintf(out, "%s %s\n",
+		StyleDim.Render(fmt.Sprintf("[%d/%d]", n, total)),
+		StylePath.Render(filepath.Base(op.SourcePath)))
+
+	detail := ""
+	if op.Episode != nil {
+		detail = fmt.Sprintf("(episode %d)", op.Episode.Number)
+	}
+	if op.Status == autotitle.StatusSkipped {
+		detail = "(unchanged)"
+	}
+	_, _ = fmt.Fprintf(out, "   → %s %s\n",
+		StylePattern.Render(filepath.Base(op.TargetPath)),
+		StyleDim.Render(detail))
+}
+
+// readAnswer prompts for one line of input. eof reports that input ended.
+func readAnswer(reader *bufio.Reader, prompt string) (answer string, eof bool) {
+	_, _ = fmt.Fprintf(textOut(), "%s ", StyleCommand.Render(prompt))
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		_, _ = fmt.Fprintln(textOut())
+	}
+	return strings.TrimSpace(line), err != nil
+}
@@ -13,14 +13,15 @@ import (
 )
 
 var (
-	flagDryRun    bool
-	flagNoBackup  bool
-	flagVerbose   bool
-	flagQuiet     bool
-	flagNoTag     bool
-	flagOffset    int
-	flagFillerURL string
-	flagForce     bool
+	flagDryRun     bool
+	flagNoBackup   bool
+	flagVerbose    bool
+	flagQuiet      bool
+	flagNoTag      bool
+	flagOffset     int
+	flagFillerURLs []string
+	flagForce      bool
+	flagReview     bool
 
 	logger *log.Logger
 )
@@ -28,12 +29,17 @@ var (
 var RootCmd = &cobra.Command{
 	Use:           "autotitle <path>",
 	Short:         "Rename media files with proper titles",
+	Long:          "Rename media files with proper titles.\n\n" + exitCodesHelp,
 	Version:       version.String(),
 	SilenceErrors: true,
 	SilenceUsage:  true,
 	Args:          cobra.ExactArgs(1),
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		setupLogger()
+		setupOutput(cmd)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		flushOutput(ExitOK)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		runRename(cmd.Context(), cmd, args[0])
@@ -41,15 +47,17 @@ var RootCmd = &cobra.Command{
 }
 
 func Execute() {
-	fmt.Println()
 	if err := RootCmd.Execute(); err != nil {
+		if structured() {
+			failWith(ExitConfig, err.Error(), nil)
+		}
 		if logger != nil {
 			logger.Error(err)
 		} else {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		}
 		RootCmd.Usage()
-		os.Exit(1)
+		os.Exit(ExitConfig)
 	}
 }
 
@@ -58,16 +66,18 @@ func init() {
 	RootCmd.Flags().BoolVarP(&flagNoBackup, "no-backup", "n", false, "Skip backup creation")
 	RootCmd.Flags().BoolVarP(&flagVerbose, "verbose", "V", false, "Verbose output")
 	RootCmd.Flags().IntVarP(&flagOffset, "offset", "o", 0, "Episode number offset (db_num = local_num + offset)")
-	RootCmd.Flags().StringVarP(&flagFillerURL, "filler", "F", "", "Override filler source URL")
+	RootCmd.Flags().StringArrayVarP(&flagFillerURLs, "filler", "F", nil, "Override filler source URL (repeatable)")
 	RootCmd.Flags().BoolVarP(&flagForce, "force", "f", false, "Force database refresh")
 	RootCmd.Flags().BoolVarP(&flagNoTag, "no-tag", "T", false, "Disable MKV metadata tagging (mkvpropedit)")
+	RootCmd.Flags().BoolVarP(&flagReview, "interactive", "i", false, "Review each rename before applying")
 	RootCmd.PersistentFlags().BoolVarP(&flagQuiet, "quiet", "q", false, "Suppress output except errors")
+	RootCmd.PersistentFlags().StringVar(&flagOutput, "output", outputText, "Output format: text, json or ndjson")
 
 	// Default logger setup (before flags parse)
 	logger = log.New(os.Stdout)
 	configureStyles()
 
-	autotitle.SetDefaultEventHandler(func(e autotitle.Event) {
+	autotitle.SetDefaultEventHandler(eventHandler(func(e autotitle.Event) {
 		switch e.Type {
 		case autotitle.EventSuccess:
 			logger.Info(e.Message)
@@ -78,7 +88,7 @@ func init() {
 		default:
 			logger.Debug(e.Message)
 		}
-	})
+	}))
 
 	colorizeHelp(RootCmd)
 
@@ -137,8 +147,8 @@ func runRename(ctx context.Context, cmd *cobra.Command, path string) {
 		opts = append(opts, autotitle.WithOffset(flagOffset))
 	}
 
-	if flagFillerURL != "" {
-		opts = append(opts, autotitle.WithFiller(flagFillerURL))
+	if len(flagFillerURLs) > 0 {
+		opts = append(opts, autotitle.WithFiller(flagFillerURLs...))
 	}
 	if flagForce {
 		opts = append(opts, autotitle.WithForce())
@@ -148,32 +158,53 @@ func runRename(ctx context.Context, cmd *cobra.Command, path string) {
 		// No need to pass events manually anymore, global default is used
 	}
 
-	ops, err := autotitle.Rename(ctx, path, opts...)
+	var ops []autotitle.RenameOperation
+	var err error
+	if flagReview {
+		ops, err = reviewRenames(ctx, path, opts)
+	} else {
+		ops, err = autotitle.Rename(ctx, path, opts...)
+	}
 	if err != nil {
-		logger.Error("Operation failed", "error", err)
-		os.Exit(1)
+		fail("Operation failed", err)
 	}
+	emitOperations(ops)
 
 	// Summary
-	var success, skipped, failed int
-
+	summary := renameSummary{Total: len(ops)}
 	for _, op := range ops {
 		switch op.Status {
 		case autotitle.StatusSuccess:
-			success++
+			summary.Renamed++
 		case autotitle.StatusSkipped:
-			skipped++
+			summary.Skipped++
 		case autotitle.StatusFailed:
-			failed++
+			summary.Failed++
 		}
 	}
+	emitResult(summary)
 
-	if !flagQuiet {
+	if !flagQuiet && !structured() {
 		fmt.Println()
 		logger.Info(fmt.Sprintf("Summary: renamed=%s skipped=%s failed=%s",
-			StyleCommand.Render(fmt.Sprint(success)),
-			StylePattern.Render(fmt.Sprint(skipped)),
-			styleFlag.Render(fmt.Sprint(failed)),
+			StyleCommand.Render(fmt.Sprint(summary.Renamed)),
+			StylePattern.Render(fmt.Sprint(summary.Skipped)),
+			styleFlag.Render(fmt.Sprint(summary.Failed)),
 		))
 	}
+
+	switch {
+	case summary.Failed > 0:
+		exit(ExitPartial)
+	case len(ops) == 0:
+		exit(ExitNoMatch)
+	}
+}
+
+// renameSummary is the result of a rename run
+type renameSummary struct {
+	Total   int `json:"total"`
+	Renamed int `json:"renamed"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
 }
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mydehq/autotitle"
+	"github.com/spf13/cobra"
+)
+
+var flagSearchProvider string
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search providers for a series",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runSearch(cmd, strings.Join(args, " "))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().StringVarP(&flagSearchProvider, "provider", "p", "", "Only search this provider (mal, tmdb, etc)")
+}
+
+func runSearch(cmd *cobra.Command, query string) {
+	var opts []autotitle.Option
+	if flagSearchProvider != "" {
+		opts = append(opts, autotitle.WithProvider(flagSearchProvider))
+	}
+
+	results, err := autotitle.Search(cmd.Context(), query, opts...)
+	if err != nil {
+		fail("Search failed", err)
+	}
+
+	if results == nil {
+		results = []autotitle.SearchResult{}
+	}
+	emitResult(results)
+	if len(results) == 0 {
+		logger.Info(fmt.Sprintf("No results for %s", StylePattern.Render(query)))
+		exit(ExitNoMatch)
+	}
+	printSearchResults(textOut(), results)
+}
+
+// printSearchResults writes results as a numbered table
+func printSearchResults(w io.Writer, results []autotitle.SearchResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, StyleHeader.Render("#")+"\t"+StyleHeader.Render("PROVIDER")+"\t"+StyleHeader.Render("YEAR")+"\t"+StyleHeader.Render("TITLE")+"\t"+StyleHeader.Render("URL"))
+	for i, r := range results {
+		year := "-"
+		if r.Year > 0 {
+			year = strconv.Itoa(r.Year)
+		}
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", i+1, r.Provider, year, r.Title, StylePath.Render(r.URL))
+	}
+	_ = tw.Flush()
+}
+
+// promptChoice asks the user to pick one of n numbered entries.
+// It returns -1 if the user cancels with 0 or an empty answer.
+func promptChoice(in io.Reader, n int) (int, error) {
+	reader := bufio.NewReader(in)
+	for {
+		_, _ = fmt.Fprintf(textOut(), "%s ", StyleCommand.Render(fmt.Sprintf("Select [1-%d, 0 to cancel]:", n)))
+		line, err := reader.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" || answer == "0" {
+			if err != nil && err != io.EOF {
+				return -1, err
+			}
+			return -1, nil
+		}
+		if choice, convErr := strconv.Atoi(answer); convErr == nil && choice >= 1 && choice <= n {
+			return choice - 1, nil
+		}
+		if err != nil {
+			return -1, err
+		}
+		logger.Warn(fmt.Sprintf("Enter a number between 1 and %d", n))
+	}
+}
@@ -2,7 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/mydehq/autotitle"
@@ -25,8 +24,7 @@ Useful for files that are already correctly named.`,
 		}
 		absPath, err := filepath.Abs(path)
 		if err != nil {
-			logger.Error("Invalid path", "error", err)
-			os.Exit(1)
+			failWith(ExitConfig, "Invalid path", err)
 		}
 		runTag(cmd, absPath)
 	},
@@ -38,27 +36,61 @@ func init() {
 
 func runTag(cmd *cobra.Command, path string) {
 	if !tagger.IsAvailable() {
-		logger.Error("mkvpropedit not found. Please install MKVToolNix.")
-		os.Exit(1)
+		failWith(ExitError, "mkvpropedit not found. Please install MKVToolNix.", nil)
 	}
 
+	var summary tagSummary
+	handle := eventHandler(func(e autotitle.Event) {
+		switch e.Type {
+		case autotitle.EventInfo:
+			logger.Info(fmt.Sprintf("%s: %s", StyleHeader.Render("Tag"), e.Message))
+		case autotitle.EventSuccess:
+			logger.Info(fmt.Sprintf("%s: %s", StyleHeader.Render("Tagged"), e.Message))
+		case autotitle.EventWarning:
+			logger.Warn(fmt.Sprintf("%s: %s", StyleHeader.Render("Tag Warning"), e.Message))
+		case autotitle.EventError:
+			logger.Error(fmt.Sprintf("%s: %s", StyleHeader.Render("Tag Error"), e.Message))
+		}
+	})
 	opts := []autotitle.Option{
 		autotitle.WithEvents(func(e autotitle.Event) {
-			switch e.Type {
-			case autotitle.EventInfo:
-				logger.Info(fmt.Sprintf("%s: %s", StyleHeader.Render("Tag"), e.Message))
-			case autotitle.EventSuccess:
-				logger.Info(fmt.Sprintf("%s: %s", StyleHeader.Render("Tagged"), e.Message))
-			case autotitle.EventWarning:
-				logger.Warn(fmt.Sprintf("%s: %s", StyleHeader.Render("Tag Warning"), e.Message))
-			case autotitle.EventError:
-				logger.Error(fmt.Sprintf("%s: %s", StyleHeader.Render("Tag Error"), e.Message))
-			}
+			summary.count(e)
+			handle(e)
 		}),
 	}
 
 	if err := autotitle.Tag(cmd.Context(), path, opts...); err != nil {
-		logger.Error("Tagging failed", "error", err)
-		os.Exit(1)
+		fail("Tagging failed", err)
+	}
+	emitResult(summary)
+
+	switch {
+	case summary.Failed > 0:
+		exit(ExitPartial)
+	case summary.Tagged == 0 && summary.Skipped == 0:
+		exit(ExitNoMatch)
+	}
+}
+
+// tagSummary is the result of a tag run
+type tagSummary struct {
+	Tagged  int `json:"tagged"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// count tallies a tag event
+func (s *tagSummary) count(e autotitle.Event) {
+	data, ok := e.Data.(autotitle.TagEvent)
+	if !ok {
+		return
+	}
+	switch data.Status {
+	case autotitle.StatusSuccess:
+		s.Tagged++
+	case autotitle.StatusSkipped:
+		s.Skipped++
+	case autotitle.StatusFailed:
+		s.Failed++
 	}
 }
@@ -2,16 +2,37 @@ package cli
 
 import (
 	"fmt"
-	"os"
+	"strconv"
 
 	"github.com/mydehq/autotitle"
 	"github.com/spf13/cobra"
 )
 
+var (
+	flagUndoList     bool
+	flagUndoTo       string
+	flagUndoOnly     string
+	flagUndoConflict string
+)
+
 var undoCmd = &cobra.Command{
 	Use:   "undo <path>",
 	Short: "Restore files from backup",
-	Args:  cobra.ExactArgs(1),
+	Long: `Restore files from backup.
+Every rename run keeps a backup generation. By default the latest one is
+undone; --to undoes every generation back to and including the given one
+(by ID or by the number shown by --list).
+
+--only restores a subset: a glob matched against the original and renamed
+names, or an episode range like "1-3,7".
+
+Files changed since the backup (modified, renamed again, or with another
+file now at the original name) are conflicts. --conflict decides what to do:
+  skip          leave them alone and keep them in the backup (default)
+  force         restore anyway, replacing whatever is in the way
+  rename-aside  restore, moving the conflicting file to
+                "<name>.autotitle-conflict" first`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		runUndo(cmd, args[0])
 	},
@@ -19,14 +40,125 @@ var undoCmd = &cobra.Command{
 
 func init() {
 	RootCmd.AddCommand(undoCmd)
+	undoCmd.Flags().BoolVarP(&flagUndoList, "list", "l", false, "List backup generations")
+	undoCmd.Flags().StringVarP(&flagUndoTo, "to", "t", "", "Undo back to and including this generation")
+	undoCmd.Flags().StringVar(&flagUndoOnly, "only", "", "Restore only files matching a glob or episode range")
+	undoCmd.Flags().StringVarP(&flagUndoConflict, "conflict", "c", autotitle.ConflictSkip, "Conflict policy: skip, force or rename-aside")
 }
 
 func runUndo(cmd *cobra.Command, path string) {
-	if err := autotitle.Undo(cmd.Context(), path); err != nil {
-		fmt.Println()
-		logger.Error("Failed to undo", "error", err)
-		os.Exit(1)
+	ctx := cmd.Context()
+	if flagUndoList || flagUndoTo != "" {
+		history, err := autotitle.BackupHistory(ctx, path)
+		if err != nil {
+			fail("Failed to read backups", err)
+		}
+		if flagUndoList {
+			if history == nil {
+				history = []autotitle.BackupRecord{}
+			}
+			emitResult(history)
+			printHistory(path, history)
+			if len(history) == 0 {
+				exit(ExitNoMatch)
+			}
+			return
+		}
+		flagUndoTo = resolveGeneration(history, flagUndoTo)
+	}
+
+	report, err := autotitle.Undo(ctx, path,
+		autotitle.WithGeneration(flagUndoTo),
+		autotitle.WithOnly(flagUndoOnly),
+		autotitle.WithConflictPolicy(flagUndoConflict),
+	)
+	if err != nil {
+		blankLine()
+		fail("Failed to undo", err)
+	}
+	blankLine()
+	emitResult(report)
+	printRestoreReport(report)
+	if code := restoreExitCode(report); code != ExitOK {
+		exit(code)
+	}
+}
+
+// printRestoreReport summarizes an undo
+func printRestoreReport(report *autotitle.RestoreReport) {
+	if structured() {
+		return
+	}
+	skipped := 0
+	for _, c := range report.Conflicts {
+		switch c.Action {
+		case "skipped":
+			skipped++
+			logger.Warn(fmt.Sprintf("Skipped %s", StylePath.Render(c.Renamed)), "conflict", c.Reason)
+		case "failed":
+			logger.Error(fmt.Sprintf("Could not restore %s", StylePath.Render(c.Original)), "conflict", c.Reason, "error", c.Error)
+		case "moved-aside":
+			logger.Warn(fmt.Sprintf("Moved conflicting file aside as %s", StylePath.Render(c.Aside)), "conflict", c.Reason)
+		default:
+			logger.Warn(fmt.Sprintf("Restored %s over a conflict", StylePath.Render(c.Original)), "conflict", c.Reason)
+		}
+	}
+
+	switch {
+	case len(report.Restored) == 0 && len(report.Conflicts) == 0:
+		logger.Info("No backed up files matched")
+	case len(report.Restored) > 0:
+		logger.Info(StyleHeader.Render(fmt.Sprintf("Restored %d file(s) from backup", len(report.Restored))))
+	}
+	if skipped > 0 {
+		logger.Info(fmt.Sprintf("%d file(s) kept in the backup; rerun with %s or %s to restore them",
+			skipped, StyleCommand.Render("--conflict force"), StyleCommand.Render("--conflict rename-aside")))
+	}
+}
+
+// restoreExitCode returns ExitPartial when a selected file was skipped or
+// could not be restored, and ExitNoMatch when nothing was selected
+func restoreExitCode(report *autotitle.RestoreReport) int {
+	for _, c := range report.Conflicts {
+		if c.Action == "skipped" || c.Action == "failed" {
+			return ExitPartial
+		}
+	}
+	if len(report.Restored) == 0 && len(report.Conflicts) == 0 {
+		return ExitNoMatch
+	}
+	return ExitOK
+}
+
+func printHistory(path string, history []autotitle.BackupRecord) {
+	if structured() {
+		return
+	}
+	if len(history) == 0 {
+		logger.Info(fmt.Sprintf("No backups for %s", StylePath.Render(path)))
+		return
+	}
+	logger.Info(fmt.Sprintf("%s for %s", StyleHeader.Render("Backup generations"), StylePath.Render(path)))
+	for i, r := range history {
+		logger.Print(fmt.Sprintf("  %s %s  %s  %s",
+			StylePattern.Render(fmt.Sprintf("%2d", i+1)),
+			r.Generation,
+			r.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			StyleDim.Render(fmt.Sprintf("(%d files)", r.Files)),
+		))
+	}
+}
+
+// resolveGeneration accepts a generation ID or its 1-based number in the list
+func resolveGeneration(history []autotitle.BackupRecord, ref string) string {
+	for _, r := range history {
+		if r.Generation == ref {
+			return ref
+		}
+	}
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(history) {
+		return history[n-1].Generation
 	}
-	fmt.Println()
-	logger.Info(StyleHeader.Render("Files restored from backup"))
+	failWith(ExitNoMatch, fmt.Sprintf("Unknown backup generation %q (see undo --list)", ref), nil)
+	return ""
 }
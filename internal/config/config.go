@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
 	"gopkg.in/yaml.v3"
 )
 
@@ -44,6 +46,7 @@ var defaults = types.GlobalConfig{
 	Backup: types.BackupConfig{
 		Enabled: true,
 		DirName: ".autotitle_backup",
+		Keep:    10,
 	},
 }
 
@@ -51,10 +54,10 @@ var defaults = types.GlobalConfig{
 var defaultMapFile = types.Config{
 	Targets: []types.Target{
 		{
-			Path:      ".",
-			URL:       "https://myanimelist.net/anime/XXXXX/Series_Name",
-			FillerURL: "https://www.animefillerlist.com/shows/series-name",
-			Patterns:  defaults.Patterns,
+			Path:       ".",
+			URL:        "https://myanimelist.net/anime/XXXXX/Series_Name",
+			FillerURLs: types.StringList{"https://www.animefillerlist.com/shows/series-name"},
+			Patterns:   defaults.Patterns,
 		},
 	},
 }
@@ -66,26 +69,41 @@ func GetDefaults() types.GlobalConfig {
 
 // Load loads configuration from a directory
 func Load(dir string) (*types.Config, error) {
+	return LoadFile(MapFilePath(dir))
+}
+
+// MapFilePath returns the map file path for a directory. If neither the
+// configured name nor its alternate extension exists, the configured name
+// is returned.
+func MapFilePath(dir string) string {
 	// Try to get map file name from global config
 	mapFileName := defaults.MapFile
 	if globalCfg, err := LoadGlobal(); err == nil && globalCfg.MapFile != "" {
 		mapFileName = globalCfg.MapFile
 	}
+	return FindMapFile(dir, mapFileName)
+}
+
+// FindMapFile returns the path of the map file named mapFileName in dir,
+// accepting either YAML extension. An empty name uses the default.
+func FindMapFile(dir, mapFileName string) string {
+	if mapFileName == "" {
+		mapFileName = defaults.MapFile
+	}
 
 	// Try primary path first
 	path := filepath.Join(dir, mapFileName)
 	if _, err := os.Stat(path); err == nil {
-		return LoadFile(path)
+		return path
 	}
 
 	// Try alternate extension (.yml <-> .yaml)
 	altPath := swapYAMLExtension(path)
 	if _, err := os.Stat(altPath); err == nil {
-		return LoadFile(altPath)
+		return altPath
 	}
 
-	// Return error for primary path
-	return LoadFile(path)
+	return path
 }
 
 // swapYAMLExtension swaps .yml to .yaml and vice versa
@@ -103,16 +121,16 @@ func swapYAMLExtension(path string) string {
 func LoadFile(path string) (*types.Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read map file: %w", err)
+		return nil, types.ErrConfigInvalid{Path: path, Reason: "failed to read map file", Err: err}
 	}
 
 	var cfg types.Config
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse map file: %w", err)
+		return nil, types.ErrConfigInvalid{Path: path, Reason: "failed to parse map file", Err: err}
 	}
 
 	if err := Validate(&cfg); err != nil {
-		return nil, err
+		return nil, types.ErrConfigInvalid{Path: path, Reason: err.Error()}
 	}
 
 	absPath, err := filepath.Abs(path)
@@ -162,7 +180,16 @@ func LoadGlobal() (*types.GlobalConfig, error) {
 	}
 
 	if err := yaml.Unmarshal(data, cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse global config: %w", err)
+		return nil, types.ErrConfigInvalid{Path: configPath, Reason: "failed to parse global config", Err: err}
+	}
+	if err := validateRefresh(cfg.Refresh); err != nil {
+		return nil, types.ErrConfigInvalid{Path: configPath, Reason: err.Error()}
+	}
+	if err := validateBackup(cfg.Backup); err != nil {
+		return nil, types.ErrConfigInvalid{Path: configPath, Reason: err.Error()}
+	}
+	if err := validateAPI(cfg.API); err != nil {
+		return nil, types.ErrConfigInvalid{Path: configPath, Reason: err.Error()}
 	}
 
 	return cfg, nil
@@ -198,6 +225,20 @@ func Validate(cfg *types.Config) error {
 		if len(target.Patterns) == 0 {
 			return fmt.Errorf("target %d: at least one pattern is required", i)
 		}
+		if err := validateMerge(target.Merge); err != nil {
+			return fmt.Errorf("target %d: %w", i, err)
+		}
+		switch target.FillerPolicy {
+		case "", types.FillerPolicyFirst, types.FillerPolicyUnion, types.FillerPolicyPreferCanon:
+		default:
+			return fmt.Errorf("target %d: unknown filler_policy %q", i, target.FillerPolicy)
+		}
+		if err := validateOverrides(target.Overrides); err != nil {
+			return fmt.Errorf("target %d: %w", i, err)
+		}
+		if err := validateMapping(target.Mapping); err != nil {
+			return fmt.Errorf("target %d: %w", i, err)
+		}
 
 		for j, pattern := range target.Patterns {
 			if len(pattern.Input) == 0 {
@@ -212,6 +253,75 @@ func Validate(cfg *types.Config) error {
 	return nil
 }
 
+// validateMerge checks the merge policy and precedence field names
+func validateMerge(m *types.MergeConfig) error {
+	if m == nil {
+		return nil
+	}
+	switch m.Policy {
+	case "", types.MergeFillGaps, types.MergePrecedence:
+	default:
+		return fmt.Errorf("unknown merge policy %q (use %s or %s)", m.Policy, types.MergeFillGaps, types.MergePrecedence)
+	}
+	for field := range m.Precedence {
+		if !slices.Contains(types.MergeFields, field) {
+			return fmt.Errorf("unknown merge field %q", field)
+		}
+	}
+	return nil
+}
+
+// validateRefresh checks the refresh mode and max ages
+func validateRefresh(r types.RefreshConfig) error {
+	switch r.Mode {
+	case "", types.RefreshAuto, types.RefreshAlways, types.RefreshNever:
+	default:
+		return fmt.Errorf("unknown refresh mode %q (use %s, %s or %s)", r.Mode, types.RefreshAuto, types.RefreshAlways, types.RefreshNever)
+	}
+	for status, age := range r.MaxAge {
+		if _, err := util.ParseAge(age); err != nil {
+			return fmt.Errorf("refresh max_age for %q: %w", status, err)
+		}
+	}
+	return nil
+}
+
+// validateBackup checks the backup mode, location and retention settings
+func validateBackup(b types.BackupConfig) error {
+	switch b.Mode {
+	case "", types.BackupModeCopy, types.BackupModeJournal:
+	default:
+		return fmt.Errorf("unknown backup mode %q (use %s or %s)", b.Mode, types.BackupModeCopy, types.BackupModeJournal)
+	}
+	switch b.Location {
+	case "", types.BackupLocationDirectory, types.BackupLocationCentral:
+	default:
+		return fmt.Errorf("unknown backup location %q (use %s or %s)", b.Location, types.BackupLocationDirectory, types.BackupLocationCentral)
+	}
+	if b.Keep < 0 {
+		return fmt.Errorf("backup keep must not be negative")
+	}
+	if b.MaxAge != "" {
+		if _, err := util.ParseAge(b.MaxAge); err != nil {
+			return fmt.Errorf("backup max_age: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateAPI checks the per-provider HTTP settings
+func validateAPI(api types.APIConfig) error {
+	for name, s := range api.Providers {
+		if s.Proxy == "" {
+			continue
+		}
+		if _, err := util.ParseProxy(s.Proxy); err != nil {
+			return fmt.Errorf("api provider %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
 // GenerateDefault creates a default config with auto-detected pattern
 func GenerateDefault(url, fillerURL string, inputPatterns []string, separator string, offset, padding int) *types.Config {
 
@@ -225,7 +335,7 @@ func GenerateDefault(url, fillerURL string, inputPatterns []string, separator st
 	}
 
 	if fillerURL != "" {
-		target.FillerURL = fillerURL
+		target.FillerURLs = types.StringList{fillerURL}
 	}
 
 	// If input patterns are provided, we only want those.
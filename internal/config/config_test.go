@@ -3,7 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/mydehq/autotitle/internal/types"
 )
 
 func TestValidate(t *testing.T) {
@@ -38,6 +41,47 @@ func TestValidate(t *testing.T) {
 			},
 			shouldError: true,
 		},
+		{
+			name: "unknown merge policy",
+			cfg: &Config{
+				Targets: []Target{
+					{
+						Path:  ".",
+						URL:   "https://myanimelist.net/anime/1",
+						Merge: &types.MergeConfig{Policy: "newest"},
+						Patterns: []Pattern{
+							{
+								Input:  []string{"Episode {{EP_NUM}}"},
+								Output: OutputConfig{Fields: []string{"SERIES", "EP_NUM"}},
+							},
+						},
+					},
+				},
+			},
+			shouldError: true,
+		},
+		{
+			name: "overlapping mapping segments",
+			cfg: &Config{
+				Targets: []Target{
+					{
+						Path: ".",
+						URL:  "https://myanimelist.net/anime/1",
+						Mapping: []types.MappingSegment{
+							{Episodes: "1-24", URL: "https://myanimelist.net/anime/1"},
+							{Episodes: "24-48", URL: "https://myanimelist.net/anime/2"},
+						},
+						Patterns: []Pattern{
+							{
+								Input:  []string{"Episode {{EP_NUM}}"},
+								Output: OutputConfig{Fields: []string{"SERIES", "EP_NUM"}},
+							},
+						},
+					},
+				},
+			},
+			shouldError: true,
+		},
 		{
 			name: "valid config",
 			cfg: &Config{
@@ -105,8 +149,8 @@ func TestLoadFile(t *testing.T) {
 	if target.URL != "https://myanimelist.net/anime/12345" {
 		t.Errorf("unexpected URL: %s", target.URL)
 	}
-	if target.FillerURL != "https://animefillerlist.com/shows/test" {
-		t.Errorf("unexpected FillerURL: %s", target.FillerURL)
+	if len(target.FillerURLs) != 1 || target.FillerURLs[0] != "https://animefillerlist.com/shows/test" {
+		t.Errorf("unexpected FillerURLs: %v", target.FillerURLs)
 	}
 	if len(target.Patterns) == 0 {
 		t.Fatal("expected at least one pattern")
@@ -208,3 +252,183 @@ func TestGenerateDefaultFieldsSideEffects(t *testing.T) {
 		t.Error("defaultMapFile affected by cfg1 modification! Global Fields slice was mutated.")
 	}
 }
+
+func TestApplyOverrides(t *testing.T) {
+	media := &types.Media{
+		Title: "Test",
+		Episodes: []types.Episode{
+			{Number: 1, Title: "One"},
+			{Number: 2, Title: "Two", IsFiller: true, FillerType: types.FillerTypeFiller},
+			{Number: 3, Title: "Three"},
+		},
+	}
+	yes, no := true, false
+	overrides := map[string]types.EpisodeOverride{
+		"1-3": {Filler: &yes},
+		"2":   {Filler: &no, Title: "Two (fixed)"},
+		"3":   {Skip: true},
+		"5":   {Title: "Special", AirDate: "2020-01-01"},
+	}
+
+	got, err := ApplyOverrides(media, overrides)
+	if err != nil {
+		t.Fatalf("ApplyOverrides failed: %v", err)
+	}
+
+	if ep := got.GetEpisode(1); !ep.IsFiller {
+		t.Error("episode 1 should be marked filler by the range override")
+	}
+	if ep := got.GetEpisode(2); ep.IsFiller || ep.FillerType != types.FillerTypeCanon || ep.Title != "Two (fixed)" {
+		t.Errorf("episode 2 specific override should win, got %+v", ep)
+	}
+	if ep := got.GetEpisode(3); !ep.Skip {
+		t.Error("episode 3 should be skipped")
+	}
+	if ep := got.GetEpisode(5); ep == nil || ep.Title != "Special" {
+		t.Errorf("episode 5 should be created from override, got %+v", ep)
+	}
+
+	// Original media must not be modified
+	if media.Episodes[0].IsFiller || media.Episodes[1].Title != "Two" || len(media.Episodes) != 3 {
+		t.Error("ApplyOverrides mutated the input media")
+	}
+}
+
+func TestValidateOverrides(t *testing.T) {
+	if err := validateOverrides(map[string]types.EpisodeOverride{"120-135": {}}); err != nil {
+		t.Errorf("valid range rejected: %v", err)
+	}
+	if err := validateOverrides(map[string]types.EpisodeOverride{"abc": {}}); err == nil {
+		t.Error("expected error for invalid range key")
+	}
+	if err := validateOverrides(map[string]types.EpisodeOverride{"1": {FillerType: "recap"}}); err == nil {
+		t.Error("expected error for unknown filler_type")
+	}
+}
+
+func TestValidateRefresh(t *testing.T) {
+	if err := validateRefresh(types.RefreshConfig{Mode: "auto", MaxAge: map[string]string{"default": "7d", "Currently Airing": "12h"}}); err != nil {
+		t.Errorf("valid refresh config rejected: %v", err)
+	}
+	if err := validateRefresh(types.RefreshConfig{Mode: "sometimes"}); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+	if err := validateBackup(types.BackupConfig{Location: "nas"}); err == nil {
+		t.Error("expected error for unknown location")
+	}
+	if err := validateRefresh(types.RefreshConfig{MaxAge: map[string]string{"default": "a week"}}); err == nil {
+		t.Error("expected error for invalid max_age")
+	}
+}
+
+func TestMergeOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "_autotitle.yml")
+	content := `# Library map
+targets:
+  - path: "."
+    url: "https://myanimelist.net/anime/12345"
+    patterns:
+      - input: ["{{EP_NUM}}.{{EXT}}"]
+        output:
+          fields: [EP_NUM, EP_NAME]
+    overrides:
+      "5": {title: "Local title"}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	overrides := map[string]types.EpisodeOverride{
+		"5":     {Title: "Bundled title"},
+		"10-12": {FillerType: types.FillerTypeFiller},
+	}
+	n, err := MergeOverrides(path, 0, overrides, false)
+	if err != nil || n != 1 {
+		t.Fatalf("MergeOverrides = %d, %v; want 1, nil", n, err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("merged map file does not load: %v", err)
+	}
+	got := cfg.Targets[0].Overrides
+	if got["5"].Title != "Local title" || got["10-12"].FillerType != types.FillerTypeFiller {
+		t.Errorf("unexpected overrides: %+v", got)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# Library map") {
+		t.Error("comment was lost")
+	}
+
+	if n, err := MergeOverrides(path, 0, overrides, true); err != nil || n != 2 {
+		t.Fatalf("MergeOverrides(replace) = %d, %v; want 2, nil", n, err)
+	}
+	cfg, _ = LoadFile(path)
+	if cfg.Targets[0].Overrides["5"].Title != "Bundled title" {
+		t.Errorf("override not replaced: %+v", cfg.Targets[0].Overrides)
+	}
+
+	if _, err := MergeOverrides(path, 3, overrides, false); err == nil {
+		t.Error("expected error for missing target")
+	}
+}
+
+func TestMergeOverrides_EmptySection(t *testing.T) {
+	for _, section := range []string{"overrides:", "overrides: ~"} {
+		path := filepath.Join(t.TempDir(), "_autotitle.yml")
+		content := `targets:
+  - path: "."
+    url: "https://myanimelist.net/anime/12345"
+    patterns:
+      - input: ["{{EP_NUM}}.{{EXT}}"]
+        output:
+          fields: [EP_NUM, EP_NAME]
+    ` + section + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		overrides := map[string]types.EpisodeOverride{"3": {Title: "Bundled title"}}
+		if n, err := MergeOverrides(path, 0, overrides, false); err != nil || n != 1 {
+			t.Fatalf("%s: MergeOverrides = %d, %v; want 1, nil", section, n, err)
+		}
+		cfg, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("%s: merged map file does not load: %v", section, err)
+		}
+		if cfg.Targets[0].Overrides["3"].Title != "Bundled title" {
+			t.Errorf("%s: unexpected overrides: %+v", section, cfg.Targets[0].Overrides)
+		}
+	}
+}
+
+func TestValidateBackup(t *testing.T) {
+	if err := validateBackup(types.BackupConfig{Mode: "journal", Location: "central", Keep: 5, MaxAge: "90d"}); err != nil {
+		t.Errorf("valid backup config rejected: %v", err)
+	}
+	if err := validateBackup(types.BackupConfig{Mode: "snapshot"}); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+	if err := validateBackup(types.BackupConfig{Location: "nas"}); err == nil {
+		t.Error("expected error for unknown location")
+	}
+	if err := validateBackup(types.BackupConfig{Keep: -1}); err == nil {
+		t.Error("expected error for negative keep")
+	}
+	if err := validateBackup(types.BackupConfig{MaxAge: "soon"}); err == nil {
+		t.Error("expected error for invalid max_age")
+	}
+}
+
+func TestValidateAPI(t *testing.T) {
+	valid := types.APIConfig{Providers: map[string]types.ProviderSettings{"mal": {Proxy: "socks5://127.0.0.1:1080"}}}
+	if err := validateAPI(valid); err != nil {
+		t.Errorf("valid proxy rejected: %v", err)
+	}
+	for _, proxy := range []string{"127.0.0.1:1080", "ftp://proxy:21", "http://"} {
+		api := types.APIConfig{Providers: map[string]types.ProviderSettings{"mal": {Proxy: proxy}}}
+		if err := validateAPI(api); err == nil {
+			t.Errorf("expected error for proxy %q", proxy)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
+)
+
+// validateMapping checks segment ranges and URLs and rejects overlapping segments
+func validateMapping(segments []types.MappingSegment) error {
+	owner := make(map[int]int)
+	for i, seg := range segments {
+		if seg.URL == "" {
+			return fmt.Errorf("mapping %d: url is required", i)
+		}
+		if seg.Start < 0 {
+			return fmt.Errorf("mapping %d: start must not be negative", i)
+		}
+		nums, err := util.ParseRanges(seg.Episodes)
+		if err != nil {
+			return fmt.Errorf("mapping %d: %w", i, err)
+		}
+		if len(nums) == 0 {
+			return fmt.Errorf("mapping %d: episodes is required", i)
+		}
+		for _, n := range nums {
+			if prev, ok := owner[n]; ok {
+				return fmt.Errorf("mapping %d: episode %d already mapped by segment %d", i, n, prev)
+			}
+			owner[n] = i
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// validFillerTypes lists the values accepted for filler_type overrides
+var validFillerTypes = []types.FillerType{
+	types.FillerTypeCanon,
+	types.FillerTypeMixed,
+	types.FillerTypeFiller,
+	types.FillerTypeAnimeCanon,
+}
+
+// validateOverrides checks override keys and filler types
+func validateOverrides(overrides map[string]types.EpisodeOverride) error {
+	for key, o := range overrides {
+		nums, err := util.ParseRanges(key)
+		if err != nil {
+			return fmt.Errorf("override %q: %w", key, err)
+		}
+		if len(nums) == 0 {
+			return fmt.Errorf("override %q: no episodes", key)
+		}
+		if o.FillerType != "" && !slices.Contains(validFillerTypes, o.FillerType) {
+			return fmt.Errorf("override %q: unknown filler_type %q", key, o.FillerType)
+		}
+	}
+	return nil
+}
+
+// ApplyOverrides returns a copy of media with the episode overrides applied.
+// Wider ranges are applied first so more specific keys win on overlap.
+// Episodes missing from media are created when an override gives them data.
+func ApplyOverrides(media *types.Media, overrides map[string]types.EpisodeOverride) (*types.Media, error) {
+	return ApplyMappedOverrides(media, overrides, nil)
+}
+
+// ApplyMappedOverrides is ApplyOverrides for the media of a mapping segment.
+// Override keys are local episode numbers, which resolve translates to the
+// episode numbers of media; keys resolve does not map are ignored. A nil
+// resolve keeps the numbers as they are.
+func ApplyMappedOverrides(media *types.Media, overrides map[string]types.EpisodeOverride, resolve func(int) (int, bool)) (*types.Media, error) {
+	if media == nil || len(overrides) == 0 {
+		return media, nil
+	}
+
+	type rangedOverride struct {
+		key      string
+		episodes []int
+		override types.EpisodeOverride
+	}
+
+	ordered := make([]rangedOverride, 0, len(overrides))
+	for key, o := range overrides {
+		nums, err := util.ParseRanges(key)
+		if err != nil {
+			return nil, fmt.Errorf("override %q: %w", key, err)
+		}
+		ordered = append(ordered, rangedOverride{key: key, episodes: nums, override: o})
+	}
+	slices.SortFunc(ordered, func(a, b rangedOverride) int {
+		if len(a.episodes) != len(b.episodes) {
+			return len(b.episodes) - len(a.episodes)
+		}
+		return strings.Compare(a.key, b.key)
+	})
+
+	res := *media
+	res.Episodes = slices.Clone(media.Episodes)
+
+	index := make(map[int]int, len(res.Episodes))
+	for i, ep := range res.Episodes {
+		index[ep.Number] = i
+	}
+
+	for _, ro := range ordered {
+		for _, num := range ro.episodes {
+			if resolve != nil {
+				mapped, ok := resolve(num)
+				if !ok {
+					continue
+				}
+				num = mapped
+			}
+			i, ok := index[num]
+			if !ok {
+				o := ro.override
+				if o.Title == "" && o.AirDate == "" && !o.Skip {
+					continue // Nothing to create from
+				}
+				res.Episodes = append(res.Episodes, types.Episode{Number: num})
+				i = len(res.Episodes) - 1
+				index[num] = i
+			}
+			applyOverride(&res.Episodes[i], ro.override)
+		}
+	}
+
+	slices.SortFunc(res.Episodes, func(a, b types.Episode) int { return a.Number - b.Number })
+	return &res, nil
+}
+
+func applyOverride(ep *types.Episode, o types.EpisodeOverride) {
+	if o.Title != "" {
+		ep.Title = o.Title
+	}
+	if o.AirDate != "" {
+		ep.AirDate = o.AirDate
+	}
+	switch {
+	case o.FillerType != "":
+		ep.SetFillerType(o.FillerType)
+	case o.Filler != nil && *o.Filler:
+		ep.SetFillerType(types.FillerTypeFiller)
+	case o.Filler != nil:
+		ep.SetFillerType(types.FillerTypeCanon)
+	}
+	if o.Skip {
+		ep.Skip = true
+	}
+}
+
+// MergeOverrides adds overrides to the target at index of the map file at path.
+// The YAML is edited in place so comments survive. Existing keys are kept
+// unless replace is set. It returns the number of keys written.
+func MergeOverrides(path string, index int, overrides map[string]types.EpisodeOverride, replace bool) (int, error) {
+	if err := validateOverrides(overrides); err != nil {
+		return 0, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read map file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, fmt.Errorf("failed to parse map file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return 0, fmt.Errorf("map file is empty: %s", path)
+	}
+
+	targets := mappingValue(doc.Content[0], "targets")
+	if targets == nil || targets.Kind != yaml.SequenceNode || index >= len(targets.Content) {
+		return 0, fmt.Errorf("map file has no target %d: %s", index, path)
+	}
+	target := targets.Content[index]
+
+	section := &yaml.Node{Kind: yaml.MappingNode}
+	switch i := mappingIndex(target, "overrides"); {
+	case i < 0:
+		target.Content = append(target.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "overrides"},
+			section,
+		)
+	case target.Content[i+1].Kind == yaml.ScalarNode:
+		// An empty or null "overrides:" key
+		target.Content[i+1] = section
+	case target.Content[i+1].Kind == yaml.MappingNode:
+		section = target.Content[i+1]
+	default:
+		return 0, fmt.Errorf("overrides of target %d is not a mapping: %s", index, path)
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	written := 0
+	for _, key := range keys {
+		var value yaml.Node
+		if err := value.Encode(overrides[key]); err != nil {
+			return written, fmt.Errorf("override %q: %w", key, err)
+		}
+		value.Style = yaml.FlowStyle
+
+		if i := mappingIndex(section, key); i >= 0 {
+			if !replace {
+				continue
+			}
+			section.Content[i+1] = &value
+		} else {
+			section.Content = append(section.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: key, Style: yaml.DoubleQuotedStyle},
+				&value,
+			)
+		}
+		written++
+	}
+	if written == 0 {
+		return 0, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return 0, fmt.Errorf("failed to marshal map file: %w", err)
+	}
+	if err := util.WriteFileAtomic(path, buf.Bytes(), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write map file: %w", err)
+	}
+	return written, nil
+}
+
+// mappingIndex returns the index of key in a YAML mapping node, or -1
+func mappingIndex(node *yaml.Node, key string) int {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingValue returns the value node for key in a YAML mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if i := mappingIndex(node, key); i >= 0 {
+		return node.Content[i+1]
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// Database backends selectable in the global config
+const (
+	BackendJSON   = "json"   // One JSON file per series under <cache>/db (default)
+	BackendSQLite = "sqlite" // Single SQLite file at <cache>/db.sqlite
+)
+
+// Open returns the default repository for a backend
+func Open(backend string) (types.DatabaseRepository, error) {
+	return OpenIn("", backend)
+}
+
+// OpenIn returns the repository for a backend under cacheDir. An empty
+// cacheDir uses ~/.cache/autotitle.
+func OpenIn(cacheDir, backend string) (types.DatabaseRepository, error) {
+	switch backend {
+	case "", BackendJSON:
+		if cacheDir == "" {
+			return NewRepository("")
+		}
+		return NewRepository(filepath.Join(cacheDir, "db"))
+	case BackendSQLite:
+		if cacheDir == "" {
+			return NewSQLiteRepository("")
+		}
+		return NewSQLiteRepository(filepath.Join(cacheDir, SQLiteFile))
+	default:
+		return nil, fmt.Errorf("unknown database backend %q (use %s or %s)", backend, BackendJSON, BackendSQLite)
+	}
+}
+
+// Copy saves every entry of src into dst and returns the number copied
+func Copy(ctx context.Context, dst, src types.DatabaseRepository) (int, error) {
+	items, err := src.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	copied := 0
+	for _, item := range items {
+		media, err := src.Load(ctx, item.Provider, item.ID)
+		if err != nil {
+			return copied, fmt.Errorf("%s/%s: %w", item.Provider, item.ID, err)
+		}
+		if media == nil {
+			continue
+		}
+		if err := dst.Save(ctx, media); err != nil {
+			return copied, fmt.Errorf("%s/%s: %w", item.Provider, item.ID, err)
+		}
+		copied++
+	}
+	return copied, nil
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// BundleVersion is the current export bundle format version
+const BundleVersion = 1
+
+// Import strategies for entries that already exist in the target database
+const (
+	ImportNewer     = "newer"     // Keep whichever copy has the later LastUpdate (default)
+	ImportOverwrite = "overwrite" // Always replace the local copy
+	ImportSkip      = "skip"      // Never replace the local copy
+)
+
+// Bundle is a portable set of cached media, written as gzipped JSON
+type Bundle struct {
+	Version int           `json:"version"`
+	Created time.Time     `json:"created"`
+	Entries []BundleEntry `json:"entries"`
+}
+
+// BundleEntry holds one cached series and the map file overrides that
+// target it, if any were exported
+type BundleEntry struct {
+	Media     *types.Media                     `json:"media"`
+	Overrides map[string]types.EpisodeOverride `json:"overrides,omitempty"`
+}
+
+// bundleEntryDoc defers media decoding so documents can be migrated
+type bundleEntryDoc struct {
+	Media     map[string]any                   `json:"media"`
+	Overrides map[string]types.EpisodeOverride `json:"overrides,omitempty"`
+}
+
+// ImportReport summarizes an Import run
+type ImportReport struct {
+	Imported  int          `json:"imported"`         // Entries saved
+	Skipped   int          `json:"skipped"`          // Entries kept as they were by the strategy
+	Overrides int          `json:"overrides"`        // Override keys merged into map files
+	Failed    []EntryError `json:"failed,omitempty"` // Entries that could not be saved
+}
+
+// Export collects entries from repo. refs are "provider/id" strings; an
+// empty list exports every entry.
+func Export(ctx context.Context, repo types.DatabaseRepository, refs []string) (*Bundle, error) {
+	if len(refs) == 0 {
+		items, err := repo.List(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			refs = append(refs, item.Provider+"/"+item.ID)
+		}
+	}
+
+	bundle := &Bundle{Version: BundleVersion, Created: time.Now().UTC()}
+	for _, ref := range refs {
+		prov, id, err := SplitRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		media, err := repo.Load(ctx, prov, id)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ref, err)
+		}
+		if media == nil {
+			return nil, types.ErrDatabaseNotFound{Provider: prov, ID: id}
+		}
+		media.SchemaVersion = types.MediaSchemaVersion
+		bundle.Entries = append(bundle.Entries, BundleEntry{Media: media})
+	}
+	return bundle, nil
+}
+
+// Import saves bundle entries into repo according to strategy
+func Import(ctx context.Context, repo types.DatabaseRepository, bundle *Bundle, strategy string) (*ImportReport, error) {
+	switch strategy {
+	case "", ImportNewer, ImportOverwrite, ImportSkip:
+	default:
+		return nil, fmt.Errorf("unknown import strategy %q (use %s, %s or %s)", strategy, ImportNewer, ImportOverwrite, ImportSkip)
+	}
+
+	report := &ImportReport{}
+	for _, entry := range bundle.Entries {
+		media := entry.Media
+		if media == nil {
+			continue
+		}
+		if err := ValidateKey(media.Provider, media.ID); err != nil {
+			report.Failed = append(report.Failed, EntryError{media.Provider, media.ID, err})
+			continue
+		}
+
+		if strategy != ImportOverwrite && repo.Exists(media.Provider, media.ID) {
+			keep := strategy == ImportSkip
+			if !keep {
+				existing, err := repo.Load(ctx, media.Provider, media.ID)
+				if err != nil {
+					// Which one is newer is unknown; don't overwrite the local entry blindly
+					report.Failed = append(report.Failed, EntryError{media.Provider, media.ID, err})
+					continue
+				}
+				keep = existing != nil && !media.LastUpdate.After(existing.LastUpdate)
+			}
+			if keep {
+				report.Skipped++
+				continue
+			}
+		}
+
+		if err := repo.Save(ctx, media); err != nil {
+			report.Failed = append(report.Failed, EntryError{media.Provider, media.ID, err})
+			continue
+		}
+		report.Imported++
+	}
+	return report, nil
+}
+
+// WriteBundle writes bundle as gzipped JSON
+func WriteBundle(w io.Writer, bundle *Bundle) error {
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	if err := enc.Encode(bundle); err != nil {
+		_ = gz.Close()
+		return fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress bundle: %w", err)
+	}
+	return nil
+}
+
+// ReadBundle reads a gzipped JSON bundle, upgrading media from older schemas
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not an autotitle bundle: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+
+	var doc struct {
+		Version int              `json:"version"`
+		Created time.Time        `json:"created"`
+		Entries []bundleEntryDoc `json:"entries"`
+	}
+	if err := json.NewDecoder(gz).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode bundle: %w", err)
+	}
+	if doc.Version < 1 || doc.Version > BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (supported: %d)", doc.Version, BundleVersion)
+	}
+
+	bundle := &Bundle{Version: doc.Version, Created: doc.Created}
+	for i, e := range doc.Entries {
+		if e.Media == nil {
+			return nil, fmt.Errorf("bundle entry %d has no media", i)
+		}
+		media, err := migrateDocument(e.Media)
+		if err != nil {
+			return nil, fmt.Errorf("bundle entry %d: %w", i, err)
+		}
+		bundle.Entries = append(bundle.Entries, BundleEntry{Media: media, Overrides: e.Overrides})
+	}
+	return bundle, nil
+}
+
+// SplitRef splits a "provider/id" reference
+func SplitRef(ref string) (provider, id string, err error) {
+	provider, id, _ = strings.Cut(ref, "/")
+	if provider == "" || id == "" {
+		return "", "", fmt.Errorf("invalid reference %q: use <provider>/<id> (e.g. mal/269)", ref)
+	}
+	if err := ValidateKey(provider, id); err != nil {
+		return "", "", fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	return provider, id, nil
+}
@@ -1,9 +1,13 @@
 package database_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -69,7 +73,7 @@ func TestRepository_Search(t *testing.T) {
 	ctx := context.Background()
 	media1 := &types.Media{ID: "1", Provider: "mal", Title: "Naruto", Slug: "naruto"}
 	media2 := &types.Media{ID: "2", Provider: "mal", Title: "Naruto Shippuden", Slug: "naruto-shippuden"}
-	media3 := &types.Media{ID: "3", Provider: "tmdb", Title: "Bleach", Slug: "bleach"}
+	media3 := &types.Media{ID: "3", Provider: "tmdb", Title: "Bleach", Slug: "bleach", TitleJP: "ブリーチ", Aliases: []string{"Burichi"}}
 
 	_ = repo.Save(ctx, media1)
 	_ = repo.Save(ctx, media2)
@@ -82,6 +86,9 @@ func TestRepository_Search(t *testing.T) {
 		{"naruto", 2},
 		{"shippuden", 1},
 		{"bleach", 1},
+		{"burichi", 1}, // Alias
+		{"ブリーチ", 1},    // Japanese title
+		{"2", 1},       // ID
 		{"one piece", 0},
 		{"", 3}, // Empty query should return all
 	}
@@ -122,3 +129,414 @@ func TestRepository_Delete(t *testing.T) {
 		t.Error("Exists returned true after delete")
 	}
 }
+
+func newSQLiteRepo(t *testing.T) *database.SQLiteRepository {
+	t.Helper()
+	repo, err := database.NewSQLiteRepository(filepath.Join(t.TempDir(), "db.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository failed: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+func TestSQLiteRepository_SaveAndLoad(t *testing.T) {
+	repo := newSQLiteRepo(t)
+	ctx := context.Background()
+
+	media := &types.Media{
+		ID:           "12345",
+		Provider:     "mal",
+		Title:        "Test Anime",
+		EpisodeCount: 12,
+		Episodes: []types.Episode{
+			{Number: 2, Title: "Ep 2", IsFiller: true, FillerType: types.FillerTypeFiller},
+			{Number: 1, Title: "Ep 1"},
+		},
+		LastUpdate: time.Now().UTC(),
+	}
+	if err := repo.Save(ctx, media); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Saving again replaces the entry instead of duplicating it
+	media.Episodes = media.Episodes[:1]
+	if err := repo.Save(ctx, media); err != nil {
+		t.Fatalf("second Save failed: %v", err)
+	}
+
+	loaded, err := repo.Load(ctx, "mal", "12345")
+	if err != nil || loaded == nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Title != media.Title || !loaded.LastUpdate.Equal(media.LastUpdate) {
+		t.Errorf("loaded %+v, want %+v", loaded, media)
+	}
+	if len(loaded.Episodes) != 1 || !loaded.Episodes[0].IsFiller {
+		t.Errorf("unexpected episodes: %+v", loaded.Episodes)
+	}
+
+	items, err := repo.List(ctx, "")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(items) != 1 || items[0].EpisodeCount != 1 {
+		t.Errorf("unexpected list: %+v", items)
+	}
+
+	if missing, err := repo.Load(ctx, "mal", "999"); err != nil || missing != nil {
+		t.Errorf("Load of missing entry = %v, %v; want nil, nil", missing, err)
+	}
+}
+
+func TestSQLiteRepository_Search(t *testing.T) {
+	repo := newSQLiteRepo(t)
+	ctx := context.Background()
+
+	_ = repo.Save(ctx, &types.Media{ID: "1", Provider: "mal", Title: "Naruto"})
+	_ = repo.Save(ctx, &types.Media{ID: "2", Provider: "mal", Title: "Naruto Shippuden", Aliases: []string{"Naruto: Hurricane Chronicles"}})
+	_ = repo.Save(ctx, &types.Media{ID: "3", Provider: "tmdb", Title: "Shingeki no Kyojin", TitleEN: "Attack on Titan"})
+
+	tests := []struct {
+		query     string
+		wantCount int
+	}{
+		{"naruto", 2},
+		{"shipp", 1},
+		{"hurricane", 1},
+		{"attack titan", 1},
+		{"3", 1}, // ID match
+		{"one piece", 0},
+		{"", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := repo.Search(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(results) != tt.wantCount {
+				t.Errorf("Search(%q) returned %d results, want %d", tt.query, len(results), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestSQLiteRepository_Delete(t *testing.T) {
+	repo := newSQLiteRepo(t)
+	ctx := context.Background()
+
+	_ = repo.Save(ctx, &types.Media{ID: "1", Provider: "mal", Title: "Test", Episodes: []types.Episode{{Number: 1}}})
+	if err := repo.Delete(ctx, "mal", "1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if repo.Exists("mal", "1") {
+		t.Error("Exists returned true after delete")
+	}
+	if results, _ := repo.Search(ctx, "test"); len(results) != 0 {
+		t.Error("deleted entry still searchable")
+	}
+	if err := repo.Delete(ctx, "mal", "1"); err == nil {
+		t.Error("expected error deleting missing entry")
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src, err := database.NewRepository(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := newSQLiteRepo(t)
+	ctx := context.Background()
+
+	_ = src.Save(ctx, &types.Media{ID: "1", Provider: "mal", Title: "One", Episodes: []types.Episode{{Number: 1, Title: "Pilot"}}})
+	_ = src.Save(ctx, &types.Media{ID: "2", Provider: "tmdb", Title: "Two"})
+
+	n, err := database.Copy(ctx, dst, src)
+	if err != nil || n != 2 {
+		t.Fatalf("Copy = %d, %v; want 2, nil", n, err)
+	}
+	loaded, _ := dst.Load(ctx, "mal", "1")
+	if loaded == nil || len(loaded.Episodes) != 1 || loaded.Episodes[0].Title != "Pilot" {
+		t.Errorf("copied entry mismatch: %+v", loaded)
+	}
+}
+
+func TestRepository_ConcurrentSave(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo, err := database.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatalf("NewRepository failed: %v", err)
+	}
+
+	ctx := context.Background()
+	errs := make(chan error, 8)
+	for i := range 8 {
+		go func() {
+			media := &types.Media{ID: "1", Provider: "mal", Title: "Test", Slug: fmt.Sprintf("slug-%d", i)}
+			errs <- repo.Save(ctx, media)
+		}()
+	}
+	for range 8 {
+		if err := <-errs; err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(tmpDir, "mal", "1@*.json"))
+	if len(matches) != 1 {
+		t.Errorf("expected exactly one file after concurrent saves, got %v", matches)
+	}
+}
+
+func TestMigrate_LegacyDocument(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo, err := database.NewRepository(tmpDir)
+	if err != nil {
+		t.Fatalf("NewRepository failed: %v", err)
+	}
+
+	// Entry written before schema versioning and filler_type existed
+	legacy := `{
+  "id": "20",
+  "provider": "mal",
+  "title": "Naruto",
+  "filler_source": "animefillerlist",
+  "episodes": [
+    {"number": 1, "title": "Enter: Naruto Uzumaki!"},
+    {"number": 2, "title": "My Name is Konohamaru!", "is_mixed": true},
+    {"number": 3, "title": "Sasuke and Sakura", "is_filler": true}
+  ]
+}`
+	if err := os.MkdirAll(filepath.Join(tmpDir, "mal"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "mal", "20@naruto.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	media, err := repo.Load(ctx, "mal", "20")
+	if err != nil || media == nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if media.SchemaVersion != 0 {
+		t.Errorf("SchemaVersion = %d, want stored version 0", media.SchemaVersion)
+	}
+	want := []types.FillerType{types.FillerTypeCanon, types.FillerTypeMixed, types.FillerTypeFiller}
+	for i, ft := range want {
+		if media.Episodes[i].FillerType != ft {
+			t.Errorf("episode %d filler_type = %q, want %q", i+1, media.Episodes[i].FillerType, ft)
+		}
+	}
+
+	report, err := database.Migrate(ctx, repo, false)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if report.Total != 1 || report.Migrated != 1 || report.From[0] != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	media, _ = repo.Load(ctx, "mal", "20")
+	if media.SchemaVersion != types.MediaSchemaVersion {
+		t.Errorf("SchemaVersion after migrate = %d, want %d", media.SchemaVersion, types.MediaSchemaVersion)
+	}
+
+	report, _ = database.Migrate(ctx, repo, false)
+	if report.Migrated != 0 {
+		t.Errorf("second Migrate rewrote %d entries, want 0", report.Migrated)
+	}
+}
+
+func TestLoad_NewerSchemaVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo, _ := database.NewRepository(tmpDir)
+
+	_ = os.MkdirAll(filepath.Join(tmpDir, "mal"), 0755)
+	doc := fmt.Sprintf(`{"schema_version": %d, "id": "1", "provider": "mal", "title": "Future"}`, types.MediaSchemaVersion+1)
+	_ = os.WriteFile(filepath.Join(tmpDir, "mal", "1@future.json"), []byte(doc), 0644)
+
+	if _, err := repo.Load(context.Background(), "mal", "1"); err == nil {
+		t.Error("expected error loading a document from a newer schema")
+	}
+}
+
+func TestNeedsRefresh(t *testing.T) {
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	future := now.Add(72 * time.Hour).Format(time.RFC3339)
+	finished := &types.Media{Status: "Finished Airing", LastUpdate: now.Add(-40 * 24 * time.Hour), Episodes: []types.Episode{{Number: 1, Title: "Pilot"}}}
+	airing := &types.Media{Status: "Currently Airing", LastUpdate: now.Add(-2 * time.Hour), NextEpisodeAirDate: &future}
+	untitled := &types.Media{Status: "Finished Airing", LastUpdate: now, Episodes: []types.Episode{{Number: 1, Title: "Episode 1"}}}
+
+	tests := []struct {
+		name   string
+		media  *types.Media
+		policy types.RefreshConfig
+		want   bool
+	}{
+		{"finished, built-in rule", finished, types.RefreshConfig{}, false},
+		{"airing, next episode in future", airing, types.RefreshConfig{}, false},
+		{"finished, older than max age", finished, types.RefreshConfig{MaxAge: map[string]string{"finished airing": "30d"}}, true},
+		{"finished, default max age", finished, types.RefreshConfig{MaxAge: map[string]string{"default": "8w"}}, false},
+		{"airing, within max age", airing, types.RefreshConfig{MaxAge: map[string]string{"Currently Airing": "12h"}}, false},
+		{"placeholder titles", untitled, types.RefreshConfig{OnMissingTitles: true}, true},
+		{"mode always", airing, types.RefreshConfig{Mode: types.RefreshAlways}, true},
+		{"mode never", untitled, types.RefreshConfig{Mode: types.RefreshNever, OnMissingTitles: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, reason := database.NeedsRefresh(tt.media, tt.policy, now); got != tt.want {
+				t.Errorf("NeedsRefresh = %v (%s), want %v", got, reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestBundle_RoundTrip(t *testing.T) {
+	src, err := database.NewRepository(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := old.Add(48 * time.Hour)
+
+	_ = src.Save(ctx, &types.Media{ID: "1", Provider: "mal", Title: "One (remote)", LastUpdate: recent, Episodes: []types.Episode{{Number: 1, Title: "Pilot"}}})
+	_ = src.Save(ctx, &types.Media{ID: "2", Provider: "mal", Title: "Two (remote)", LastUpdate: old})
+
+	bundle, err := database.Export(ctx, src, nil)
+	if err != nil || len(bundle.Entries) != 2 {
+		t.Fatalf("Export = %v, %v; want 2 entries", bundle, err)
+	}
+	bundle.Entries[0].Overrides = map[string]types.EpisodeOverride{"1": {Title: "Fixed"}}
+
+	var buf bytes.Buffer
+	if err := database.WriteBundle(&buf, bundle); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	read, err := database.ReadBundle(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadBundle: %v", err)
+	}
+	if len(read.Entries) != 2 || read.Entries[0].Overrides["1"].Title != "Fixed" {
+		t.Fatalf("round trip mismatch: %+v", read.Entries)
+	}
+
+	tests := []struct {
+		strategy string
+		imported int
+		titles   [2]string
+	}{
+		{database.ImportNewer, 1, [2]string{"One (remote)", "Two (local)"}},
+		{database.ImportSkip, 0, [2]string{"One (local)", "Two (local)"}},
+		{database.ImportOverwrite, 2, [2]string{"One (remote)", "Two (remote)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			dst, err := database.NewRepository(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = dst.Save(ctx, &types.Media{ID: "1", Provider: "mal", Title: "One (local)", LastUpdate: old})
+			_ = dst.Save(ctx, &types.Media{ID: "2", Provider: "mal", Title: "Two (local)", LastUpdate: recent})
+
+			report, err := database.Import(ctx, dst, read, tt.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Imported != tt.imported || report.Skipped != 2-tt.imported {
+				t.Errorf("report = %+v, want %d imported", report, tt.imported)
+			}
+			for i, want := range tt.titles {
+				m, _ := dst.Load(ctx, "mal", fmt.Sprint(i+1))
+				if m == nil || m.Title != want {
+					t.Errorf("entry %d = %+v, want title %q", i+1, m, want)
+				}
+			}
+		})
+	}
+
+	if _, err := database.Import(ctx, src, read, "merge"); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+
+	// An unreadable local entry fails instead of being overwritten
+	dir := t.TempDir()
+	dst, err := database.NewRepository(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = dst.Save(ctx, &types.Media{ID: "1", Provider: "mal", Title: "One (local)", LastUpdate: old})
+	files, _ := filepath.Glob(filepath.Join(dir, "mal", "1@*.json"))
+	if len(files) != 1 {
+		t.Fatalf("expected one database file, got %v", files)
+	}
+	if err := os.WriteFile(files[0], []byte("{broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := database.Import(ctx, dst, read, database.ImportNewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Failed) != 1 || report.Failed[0].ID != "1" || report.Imported != 1 {
+		t.Errorf("report = %+v, want entry 1 failed and entry 2 imported", report)
+	}
+	if data, _ := os.ReadFile(files[0]); string(data) != "{broken" {
+		t.Errorf("unreadable entry was overwritten: %s", data)
+	}
+}
+
+func TestImport_RejectsUnsafeKeys(t *testing.T) {
+	root := t.TempDir()
+	dbDir := filepath.Join(root, "cache", "db")
+	repo, err := database.NewRepository(dbDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	_ = repo.Save(ctx, &types.Media{ID: "1", Provider: "mal", Title: "Kept"})
+
+	bundle := &database.Bundle{Entries: []database.BundleEntry{
+		{Media: &types.Media{Provider: "../../..", ID: "escape"}},
+		{Media: &types.Media{Provider: "mal", ID: "../escape"}},
+		{Media: &types.Media{Provider: "mal", ID: "*"}},
+		{Media: &types.Media{Provider: "..", ID: "1"}},
+		{Media: &types.Media{Provider: "mal", ID: ""}},
+		{Media: &types.Media{Provider: "mal", ID: "2", Slug: "../../escape"}},
+	}}
+	report, err := database.Import(ctx, repo, bundle, database.ImportOverwrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Imported != 0 || len(report.Failed) != len(bundle.Entries) {
+		t.Errorf("report = %+v, want every entry failed", report)
+	}
+
+	// Nothing was written outside the database directory
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if rel, _ := filepath.Rel(dbDir, path); strings.HasPrefix(rel, "..") {
+			t.Errorf("file written outside the database: %s", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := repo.Load(ctx, "mal", "1"); m == nil || m.Title != "Kept" {
+		t.Errorf("existing entry was touched: %+v", m)
+	}
+
+	for _, ref := range []string{"mal/../x", "mal/*", "../mal"} {
+		if _, _, err := database.SplitRef(ref); err == nil {
+			t.Errorf("SplitRef(%q) accepted an unsafe reference", ref)
+		}
+	}
+}
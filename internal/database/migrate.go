@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// Migration upgrades a raw media document to Version from Version-1
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(doc map[string]any) error
+}
+
+// migrations is the ordered migration registry. Version N must be registered
+// for every N up to types.MediaSchemaVersion.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "derive filler_type from is_filler/is_mixed",
+		Apply:       migrateFillerType,
+	},
+}
+
+// decodeMedia parses a stored media document and upgrades it to the current
+// schema. The returned media keeps the stored SchemaVersion so callers can
+// tell whether it needs rewriting.
+func decodeMedia(data []byte) (*types.Media, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return migrateDocument(doc)
+}
+
+// migrateDocument applies every pending migration to doc and decodes it
+func migrateDocument(doc map[string]any) (*types.Media, error) {
+	stored := 0
+	if v, ok := doc["schema_version"].(float64); ok {
+		stored = int(v)
+	}
+	if stored > types.MediaSchemaVersion {
+		return nil, fmt.Errorf("schema version %d is newer than supported version %d; upgrade autotitle", stored, types.MediaSchemaVersion)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= stored {
+			continue
+		}
+		if err := m.Apply(doc); err != nil {
+			return nil, fmt.Errorf("migration to v%d failed: %w", m.Version, err)
+		}
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var media types.Media
+	if err := json.Unmarshal(data, &media); err != nil {
+		return nil, err
+	}
+	media.SchemaVersion = stored
+	return &media, nil
+}
+
+// migrateFillerType sets filler_type on episodes cached before it existed.
+// Unflagged episodes become canon when the media had a filler source.
+func migrateFillerType(doc map[string]any) error {
+	episodes, _ := doc["episodes"].([]any)
+	hasFillerSource := doc["filler_source"] != nil && doc["filler_source"] != ""
+
+	for _, raw := range episodes {
+		ep, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		if t, _ := ep["filler_type"].(string); t != "" {
+			continue
+		}
+		switch {
+		case ep["is_mixed"] == true:
+			ep["filler_type"] = string(types.FillerTypeMixed)
+		case ep["is_filler"] == true:
+			ep["filler_type"] = string(types.FillerTypeFiller)
+		case hasFillerSource:
+			ep["filler_type"] = string(types.FillerTypeCanon)
+		}
+	}
+	return nil
+}
+
+// MigrationReport summarizes a Migrate run
+type MigrationReport struct {
+	Total    int          `json:"total"`            // Entries inspected
+	Migrated int          `json:"migrated"`         // Entries rewritten at the current version
+	From     map[int]int  `json:"from"`             // Migrated entry count by stored version
+	Failed   []EntryError `json:"failed,omitempty"` // Entries that could not be loaded or saved
+}
+
+// EntryError records a database entry that failed during a bulk operation
+type EntryError struct {
+	Provider string
+	ID       string
+	Err      error
+}
+
+// MarshalJSON encodes Err as its message
+func (e EntryError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Provider string `json:"provider"`
+		ID       string `json:"id"`
+		Error    string `json:"error"`
+	}{e.Provider, e.ID, e.Err.Error()})
+}
+
+// Migrate rewrites every entry stored below the current schema version.
+// With dryRun set, entries are only counted.
+func Migrate(ctx context.Context, repo types.DatabaseRepository, dryRun bool) (*MigrationReport, error) {
+	items, err := repo.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MigrationReport{From: make(map[int]int)}
+	for _, item := range items {
+		report.Total++
+
+		media, err := repo.Load(ctx, item.Provider, item.ID)
+		if err != nil {
+			report.Failed = append(report.Failed, EntryError{item.Provider, item.ID, err})
+			continue
+		}
+		if media == nil || media.SchemaVersion >= types.MediaSchemaVersion {
+			continue
+		}
+
+		from := media.SchemaVersion
+		if !dryRun {
+			if err := repo.Save(ctx, media); err != nil {
+				report.Failed = append(report.Failed, EntryError{item.Provider, item.ID, err})
+				continue
+			}
+		}
+		report.Migrated++
+		report.From[from]++
+	}
+	return report, nil
+}
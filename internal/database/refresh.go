@@ -0,0 +1,94 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
+)
+
+// placeholderTitle matches generic titles providers use before real ones exist
+var placeholderTitle = regexp.MustCompile(`(?i)^episode\s*\d+$`)
+
+// RefreshReport summarizes a bulk refresh
+type RefreshReport struct {
+	Total     int          `json:"total"`            // Entries inspected
+	Refreshed int          `json:"refreshed"`        // Entries refetched and saved
+	Skipped   int          `json:"skipped"`          // Entries not yet due
+	Failed    []EntryError `json:"failed,omitempty"` // Entries that could not be refreshed
+}
+
+// NeedsRefresh reports whether cached media is due for a refetch under
+// policy, with a short reason for progress output
+func NeedsRefresh(media *types.Media, policy types.RefreshConfig, now time.Time) (bool, string) {
+	switch policy.Mode {
+	case types.RefreshAlways:
+		return true, "refresh mode is always"
+	case types.RefreshNever:
+		return false, "refresh mode is never"
+	}
+
+	if policy.OnMissingTitles {
+		if n := missingTitles(media); n > 0 {
+			return true, fmt.Sprintf("%d episodes without titles", n)
+		}
+	}
+
+	if age, ok := maxAge(policy.MaxAge, media.Status); ok {
+		if elapsed := now.Sub(media.LastUpdate); elapsed > age {
+			return true, fmt.Sprintf("older than %s", age)
+		}
+		return false, "up to date"
+	}
+
+	// Built-in rule: finished shows get no new episodes
+	if media.Status == "Finished Airing" {
+		return false, "finished airing"
+	}
+
+	// If next episode is known and in the future, wait
+	if media.NextEpisodeAirDate != nil {
+		t, err := time.Parse(time.RFC3339, *media.NextEpisodeAirDate)
+		if err == nil && t.After(now) {
+			return false, "next episode not aired yet"
+		}
+	}
+	return true, "airing"
+}
+
+// maxAge returns the configured age for a status, falling back to "default".
+// Invalid ages are ignored; the config loader rejects them.
+func maxAge(ages map[string]string, status string) (time.Duration, bool) {
+	value, ok := "", false
+	for key, v := range ages {
+		if strings.EqualFold(key, status) {
+			value, ok = v, true
+			break
+		}
+	}
+	if !ok {
+		value, ok = ages["default"]
+	}
+	if !ok {
+		return 0, false
+	}
+	age, err := util.ParseAge(value)
+	if err != nil {
+		return 0, false
+	}
+	return age, true
+}
+
+// missingTitles counts episodes with an empty or placeholder title
+func missingTitles(media *types.Media) int {
+	n := 0
+	for _, ep := range media.Episodes {
+		if ep.Title == "" || placeholderTitle.MatchString(ep.Title) {
+			n++
+		}
+	}
+	return n
+}
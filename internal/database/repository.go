@@ -11,8 +11,12 @@ import (
 	"strings"
 
 	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
 )
 
+// lockFileName guards writes to the database directory across processes
+const lockFileName = ".lock"
+
 // Repository implements types.DatabaseRepository
 type Repository struct {
 	baseDir string
@@ -36,8 +40,46 @@ func NewRepository(customDir string) (*Repository, error) {
 	return &Repository{baseDir: dir}, nil
 }
 
+// lock acquires the database directory lock
+func (r *Repository) lock() (*util.FileLock, error) {
+	l, err := util.Lock(filepath.Join(r.baseDir, lockFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock database: %w", err)
+	}
+	return l, nil
+}
+
+// ValidateKey rejects a provider name or ID that could address files
+// outside its directory or match other entries: empty, "." or "..", or
+// containing a path separator or a glob metacharacter
+func ValidateKey(provider, id string) error {
+	if err := validateName("provider", provider); err != nil {
+		return err
+	}
+	return validateName("ID", id)
+}
+
+func validateName(kind, name string) error {
+	if name == "" || name == "." || name == ".." ||
+		strings.ContainsAny(name, `/\*?[`) || strings.ContainsRune(name, filepath.Separator) {
+		return fmt.Errorf("invalid %s %q", kind, name)
+	}
+	return nil
+}
+
 // Save saves media data to the database
 func (r *Repository) Save(ctx context.Context, media *types.Media) error {
+	if err := ValidateKey(media.Provider, media.ID); err != nil {
+		return err
+	}
+	if strings.ContainsAny(media.Slug, `/\`) || strings.ContainsRune(media.Slug, filepath.Separator) {
+		return fmt.Errorf("invalid slug %q", media.Slug)
+	}
+	l, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = l.Unlock() }()
 
 	// Create provider subdirectory
 	providerDir := filepath.Join(r.baseDir, media.Provider)
@@ -45,14 +87,6 @@ func (r *Repository) Save(ctx context.Context, media *types.Media) error {
 		return fmt.Errorf("failed to create provider directory: %w", err)
 	}
 
-	// Delete old files with same ID (handles slug changes)
-	pattern := filepath.Join(providerDir, media.ID+"@*.json")
-	if oldMatches, _ := filepath.Glob(pattern); len(oldMatches) > 0 {
-		for _, oldPath := range oldMatches {
-			_ = os.Remove(oldPath)
-		}
-	}
-
 	// Truncate slug if filename would exceed 255 chars
 	slug := media.Slug
 	maxSlugLen := 255 - len(media.ID) - len("@") - len(".json")
@@ -62,20 +96,35 @@ func (r *Repository) Save(ctx context.Context, media *types.Media) error {
 
 	path := filepath.Join(providerDir, media.ID+"@"+slug+".json")
 
-	data, err := json.MarshalIndent(media, "", "  ")
+	doc := *media
+	doc.SchemaVersion = types.MediaSchemaVersion
+	data, err := json.MarshalIndent(&doc, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal media data: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := util.WriteFileAtomic(path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write database file: %w", err)
 	}
 
+	// Delete old files with same ID (handles slug changes)
+	pattern := filepath.Join(providerDir, media.ID+"@*.json")
+	if oldMatches, _ := filepath.Glob(pattern); len(oldMatches) > 0 {
+		for _, oldPath := range oldMatches {
+			if oldPath != path {
+				_ = os.Remove(oldPath)
+			}
+		}
+	}
+
 	return nil
 }
 
 // Load loads media data from the database
 func (r *Repository) Load(ctx context.Context, provider, id string) (*types.Media, error) {
+	if err := ValidateKey(provider, id); err != nil {
+		return nil, err
+	}
 	providerDir := filepath.Join(r.baseDir, provider)
 	pattern := filepath.Join(providerDir, id+"@*.json")
 
@@ -99,16 +148,19 @@ func (r *Repository) Load(ctx context.Context, provider, id string) (*types.Medi
 		return nil, fmt.Errorf("failed to read database file: %w", err)
 	}
 
-	var media types.Media
-	if err := json.Unmarshal(data, &media); err != nil {
+	media, err := decodeMedia(data)
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse database file: %w", err)
 	}
 
-	return &media, nil
+	return media, nil
 }
 
 // Exists checks if a database entry exists
 func (r *Repository) Exists(provider, id string) bool {
+	if ValidateKey(provider, id) != nil {
+		return false
+	}
 	providerDir := filepath.Join(r.baseDir, provider)
 	pattern := filepath.Join(providerDir, id+"@*.json")
 	matches, _ := filepath.Glob(pattern)
@@ -117,6 +169,15 @@ func (r *Repository) Exists(provider, id string) bool {
 
 // Delete removes a database entry
 func (r *Repository) Delete(ctx context.Context, provider, id string) error {
+	if err := ValidateKey(provider, id); err != nil {
+		return err
+	}
+	l, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = l.Unlock() }()
+
 	providerDir := filepath.Join(r.baseDir, provider)
 	pattern := filepath.Join(providerDir, id+"@*.json")
 
@@ -140,6 +201,12 @@ func (r *Repository) Delete(ctx context.Context, provider, id string) error {
 
 // DeleteAll removes all database entries
 func (r *Repository) DeleteAll(ctx context.Context) error {
+	l, err := r.lock()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = l.Unlock() }()
+
 	entries, err := os.ReadDir(r.baseDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -163,7 +230,43 @@ func (r *Repository) DeleteAll(ctx context.Context) error {
 // List returns all database entries for a provider (or all if empty)
 func (r *Repository) List(ctx context.Context, provider string) ([]types.MediaSummary, error) {
 	var summaries []types.MediaSummary
+	err := r.walk(ctx, provider, func(media *types.Media) {
+		summaries = append(summaries, summarize(media))
+	})
+	return summaries, err
+}
 
+// Search finds entries whose ID matches the query or whose title, English
+// or Japanese title or aliases contain it
+func (r *Repository) Search(ctx context.Context, query string) ([]types.MediaSummary, error) {
+	if query == "" {
+		return r.List(ctx, "")
+	}
+
+	queryLower := strings.ToLower(query)
+	var results []types.MediaSummary
+
+	err := r.walk(ctx, "", func(media *types.Media) {
+		if media.ID == query || slices.ContainsFunc(searchTitles(media), func(t string) bool {
+			return strings.Contains(strings.ToLower(t), queryLower)
+		}) {
+			results = append(results, summarize(media))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort by title
+	slices.SortFunc(results, func(a, b types.MediaSummary) int {
+		return strings.Compare(a.Title, b.Title)
+	})
+
+	return results, nil
+}
+
+// walk loads every entry for a provider (or all if empty) and passes it to fn
+func (r *Repository) walk(ctx context.Context, provider string, fn func(*types.Media)) error {
 	// If provider specified, only list that provider
 	var providers []string
 	if provider != "" {
@@ -173,9 +276,9 @@ func (r *Repository) List(ctx context.Context, provider string) ([]types.MediaSu
 		entries, err := os.ReadDir(r.baseDir)
 		if err != nil {
 			if os.IsNotExist(err) {
-				return nil, nil
+				return nil
 			}
-			return nil, fmt.Errorf("failed to read database directory: %w", err)
+			return fmt.Errorf("failed to read database directory: %w", err)
 		}
 		for _, entry := range entries {
 			if entry.IsDir() {
@@ -208,51 +311,26 @@ func (r *Repository) List(ctx context.Context, provider string) ([]types.MediaSu
 			}
 			seen[id] = true
 
-			// Load to get title and episode count
 			media, err := r.Load(ctx, prov, id)
 			if err != nil || media == nil {
 				continue
 			}
-
-			summaries = append(summaries, types.MediaSummary{
-				Provider:     prov,
-				ID:           id,
-				Title:        media.Title,
-				EpisodeCount: len(media.Episodes),
-			})
+			media.Provider, media.ID = prov, id
+			fn(media)
 		}
 	}
 
-	return summaries, nil
+	return nil
 }
 
-// Search finds entries matching a query
-func (r *Repository) Search(ctx context.Context, query string) ([]types.MediaSummary, error) {
-	all, err := r.List(ctx, "")
-	if err != nil {
-		return nil, err
-	}
-
-	if query == "" {
-		return all, nil
-	}
-
-	queryLower := strings.ToLower(query)
-	var results []types.MediaSummary
-
-	for _, s := range all {
-		// Match by ID or title
-		if s.ID == query || strings.Contains(strings.ToLower(s.Title), queryLower) {
-			results = append(results, s)
-		}
+// summarize returns the listing summary of a media entry
+func summarize(media *types.Media) types.MediaSummary {
+	return types.MediaSummary{
+		Provider:     media.Provider,
+		ID:           media.ID,
+		Title:        media.Title,
+		EpisodeCount: len(media.Episodes),
 	}
-
-	// Sort by title
-	slices.SortFunc(results, func(a, b types.MediaSummary) int {
-		return strings.Compare(a.Title, b.Title)
-	})
-
-	return results, nil
 }
 
 // Path returns the base database directory
@@ -260,6 +338,11 @@ func (r *Repository) Path() string {
 	return r.baseDir
 }
 
+// Close does nothing; the files are only open during each operation
+func (r *Repository) Close() error {
+	return nil
+}
+
 func (r *Repository) newestFile(files []string) string {
 	var newest string
 	var newestTime int64
@@ -0,0 +1,325 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mydehq/autotitle/internal/types"
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver
+)
+
+// SQLiteFile is the default database file name inside the cache directory
+const SQLiteFile = "db.sqlite"
+
+// sqliteSchema creates the media, episode and title search tables.
+// Media rows keep the document without episodes in data; episodes live in
+// their own table so listing never decodes episode lists.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS media (
+	provider      TEXT NOT NULL,
+	id            TEXT NOT NULL,
+	slug          TEXT NOT NULL DEFAULT '',
+	title         TEXT NOT NULL DEFAULT '',
+	episode_count INTEGER NOT NULL DEFAULT 0,
+	last_update   TEXT NOT NULL DEFAULT '',
+	data          TEXT NOT NULL,
+	PRIMARY KEY (provider, id)
+);
+CREATE TABLE IF NOT EXISTS episodes (
+	provider TEXT NOT NULL,
+	id       TEXT NOT NULL,
+	number   INTEGER NOT NULL,
+	data     TEXT NOT NULL,
+	PRIMARY KEY (provider, id, number)
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS titles USING fts5(
+	provider UNINDEXED,
+	id UNINDEXED,
+	title,
+	tokenize = 'unicode61 remove_diacritics 2'
+);
+`
+
+// SQLiteRepository implements types.DatabaseRepository on an embedded SQLite file
+type SQLiteRepository struct {
+	path string
+	db   *sql.DB
+}
+
+// NewSQLiteRepository opens (and creates if needed) a SQLite database.
+// An empty path uses ~/.cache/autotitle/db.sqlite.
+func NewSQLiteRepository(path string) (*SQLiteRepository, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		path = filepath.Join(home, ".cache", "autotitle", SQLiteFile)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	dsn := "file:" + filepath.ToSlash(path) + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	return &SQLiteRepository{path: path, db: db}, nil
+}
+
+// Close releases the database handle
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// Save saves media data to the database, replacing any previous entry
+func (r *SQLiteRepository) Save(ctx context.Context, media *types.Media) error {
+	doc := *media
+	doc.SchemaVersion = types.MediaSchemaVersion
+	doc.Episodes = nil
+	data, err := json.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal media data: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := deleteMedia(ctx, tx, media.Provider, media.ID); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO media (provider, id, slug, title, episode_count, last_update, data) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		media.Provider, media.ID, media.Slug, media.Title, len(media.Episodes),
+		media.LastUpdate.Format(time.RFC3339Nano), string(data),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write media: %w", err)
+	}
+
+	for _, ep := range media.Episodes {
+		epData, err := json.Marshal(&ep)
+		if err != nil {
+			return fmt.Errorf("failed to marshal episode %d: %w", ep.Number, err)
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT OR REPLACE INTO episodes (provider, id, number, data) VALUES (?, ?, ?, ?)`,
+			media.Provider, media.ID, ep.Number, string(epData),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write episode %d: %w", ep.Number, err)
+		}
+	}
+
+	for _, title := range searchTitles(media) {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO titles (provider, id, title) VALUES (?, ?, ?)`,
+			media.Provider, media.ID, title,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to index title: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit media: %w", err)
+	}
+	return nil
+}
+
+// Load loads media data from the database
+func (r *SQLiteRepository) Load(ctx context.Context, provider, id string) (*types.Media, error) {
+	var data string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT data FROM media WHERE provider = ? AND id = ?`, provider, id,
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read media: %w", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(data), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse media: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT data FROM episodes WHERE provider = ? AND id = ? ORDER BY number`, provider, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read episodes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	// Reassemble the full document so migrations see episodes too
+	var episodes []any
+	for rows.Next() {
+		var epData string
+		if err := rows.Scan(&epData); err != nil {
+			return nil, fmt.Errorf("failed to read episode: %w", err)
+		}
+		var ep map[string]any
+		if err := json.Unmarshal([]byte(epData), &ep); err != nil {
+			return nil, fmt.Errorf("failed to parse episode: %w", err)
+		}
+		episodes = append(episodes, ep)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read episodes: %w", err)
+	}
+	if len(episodes) > 0 {
+		doc["episodes"] = episodes
+	}
+
+	media, err := migrateDocument(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse media: %w", err)
+	}
+	return media, nil
+}
+
+// Exists checks if a database entry exists
+func (r *SQLiteRepository) Exists(provider, id string) bool {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM media WHERE provider = ? AND id = ?`, provider, id).Scan(&n)
+	return err == nil && n > 0
+}
+
+// Delete removes a database entry
+func (r *SQLiteRepository) Delete(ctx context.Context, provider, id string) error {
+	if !r.Exists(provider, id) {
+		return types.ErrDatabaseNotFound{Provider: provider, ID: id}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := deleteMedia(ctx, tx, provider, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteAll removes all database entries
+func (r *SQLiteRepository) DeleteAll(ctx context.Context) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range []string{"media", "episodes", "titles"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// List returns all database entries for a provider (or all if empty)
+func (r *SQLiteRepository) List(ctx context.Context, provider string) ([]types.MediaSummary, error) {
+	query := `SELECT provider, id, title, episode_count FROM media`
+	var args []any
+	if provider != "" {
+		query += ` WHERE provider = ?`
+		args = append(args, provider)
+	}
+	query += ` ORDER BY provider, id`
+
+	return r.querySummaries(ctx, query, args...)
+}
+
+// Search finds entries whose ID matches the query or whose title, English
+// or Japanese title or aliases contain words starting with the query words
+func (r *SQLiteRepository) Search(ctx context.Context, query string) ([]types.MediaSummary, error) {
+	if strings.TrimSpace(query) == "" {
+		return r.List(ctx, "")
+	}
+
+	return r.querySummaries(ctx, `
+		SELECT provider, id, title, episode_count FROM media
+		WHERE id = ? OR (provider, id) IN (SELECT provider, id FROM titles WHERE titles MATCH ?)
+		ORDER BY title`,
+		query, ftsQuery(query),
+	)
+}
+
+// Path returns the database file path
+func (r *SQLiteRepository) Path() string {
+	return r.path
+}
+
+func (r *SQLiteRepository) querySummaries(ctx context.Context, query string, args ...any) ([]types.MediaSummary, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query media: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var summaries []types.MediaSummary
+	for rows.Next() {
+		var s types.MediaSummary
+		if err := rows.Scan(&s.Provider, &s.ID, &s.Title, &s.EpisodeCount); err != nil {
+			return nil, fmt.Errorf("failed to read media: %w", err)
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
+// deleteMedia removes every row belonging to a media entry
+func deleteMedia(ctx context.Context, tx *sql.Tx, provider, id string) error {
+	for _, table := range []string{"media", "episodes", "titles"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE provider = ? AND id = ?", provider, id); err != nil {
+			return fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// searchTitles returns the distinct titles and aliases indexed for search
+func searchTitles(media *types.Media) []string {
+	var titles []string
+	seen := make(map[string]bool)
+	for _, t := range append([]string{media.Title, media.TitleEN, media.TitleJP}, media.Aliases...) {
+		key := strings.ToLower(t)
+		if t == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		titles = append(titles, t)
+	}
+	return titles
+}
+
+// ftsQuery turns free text into an FTS5 query matching every word as a prefix
+func ftsQuery(query string) string {
+	words := strings.Fields(query)
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		terms = append(terms, `"`+strings.ReplaceAll(w, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,228 @@
+// Package identify guesses which provider entry a directory of media files
+// belongs to.
+package identify
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mydehq/autotitle/internal/matcher"
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// Confidence thresholds for Result.Confident
+const (
+	MinScore  = 0.8  // Best candidate must score at least this
+	MinMargin = 0.08 // and lead the runner-up by at least this
+)
+
+// Score weights; they sum to 1
+const (
+	weightTitle    = 0.7
+	weightYear     = 0.15
+	weightEpisodes = 0.15
+)
+
+var (
+	reEpisodeMarker = regexp.MustCompile(`(?i)(\s*[-_.]\s*|\s+(episode|ep\.?|e)\s*|\s*s\d+\s*[ex]\s*)$`)
+	rePlaceholder   = regexp.MustCompile(`\{\{[A-Z_]+\}\}`)
+	reYear          = regexp.MustCompile(`\b(19[5-9]\d|20[0-4]\d)\b`)
+)
+
+// Candidate is a search result with its identification score
+type Candidate struct {
+	types.SearchResult
+	Score float64 `json:"score"` // 0 (no match) to 1 (certain)
+}
+
+// Result holds the identification of a directory
+type Result struct {
+	Query      string      // Series name extracted from filenames
+	Year       int         // Year found in the names, 0 if none
+	Files      int         // Number of episode files
+	Candidates []Candidate // Best first
+}
+
+// Best returns the top candidate, or nil if there are none
+func (r *Result) Best() *Candidate {
+	if len(r.Candidates) == 0 {
+		return nil
+	}
+	return &r.Candidates[0]
+}
+
+// Confident reports whether the top candidate is a clear match
+func (r *Result) Confident() bool {
+	best := r.Best()
+	if best == nil || best.Score < MinScore {
+		return false
+	}
+	return len(r.Candidates) == 1 || best.Score-r.Candidates[1].Score >= MinMargin
+}
+
+// SeriesName extracts the series name shared by filenames. Patterns
+// containing {{SERIES}} are tried first; otherwise the text before the
+// episode number in GuessPattern's output is used. The most common name wins.
+func SeriesName(filenames []string, patterns []string) string {
+	var compiled []*matcher.Pattern
+	for _, p := range patterns {
+		if !strings.Contains(p, matcher.PlaceholderSeries) {
+			continue
+		}
+		if c, err := matcher.Compile(p); err == nil {
+			compiled = append(compiled, c)
+		}
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	for _, name := range filenames {
+		series := seriesFromPatterns(name, compiled)
+		if series == "" {
+			series = seriesFromGuess(name)
+		}
+		if series == "" {
+			continue
+		}
+		if counts[series] == 0 {
+			order = append(order, series)
+		}
+		counts[series]++
+	}
+
+	best := ""
+	for _, s := range order {
+		if counts[s] > counts[best] {
+			best = s
+		}
+	}
+	return best
+}
+
+func seriesFromPatterns(filename string, patterns []*matcher.Pattern) string {
+	for _, p := range patterns {
+		if m := p.Match(filename); m != nil && m["Series"] != "" {
+			return matcher.SeriesQuery(m["Series"])
+		}
+	}
+	return ""
+}
+
+func seriesFromGuess(filename string) string {
+	pattern := matcher.GuessPattern(filename)
+	idx := strings.Index(pattern, matcher.PlaceholderEpNum)
+	if idx < 0 {
+		return ""
+	}
+	prefix := rePlaceholder.ReplaceAllString(pattern[:idx], " ")
+	prefix = reEpisodeMarker.ReplaceAllString(prefix, "")
+	return matcher.SeriesQuery(prefix)
+}
+
+// Year returns the first plausible release year in names, or 0
+func Year(names ...string) int {
+	for _, name := range names {
+		if m := reYear.FindString(name); m != "" {
+			year, _ := strconv.Atoi(m)
+			return year
+		}
+	}
+	return 0
+}
+
+// Rank scores search results against the extracted query, year and file
+// count and returns them best first
+func Rank(results []types.SearchResult, query string, year, files int) []Candidate {
+	candidates := make([]Candidate, 0, len(results))
+	for _, r := range results {
+		candidates = append(candidates, Candidate{SearchResult: r, Score: score(r, query, year, files)})
+	}
+	slices.SortStableFunc(candidates, func(a, b Candidate) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return candidates
+}
+
+func score(r types.SearchResult, query string, year, files int) float64 {
+	title := 0.0
+	for _, t := range append([]string{r.Title}, r.Aliases...) {
+		title = max(title, Similarity(query, t))
+	}
+	return weightTitle*title + weightYear*yearScore(r.Year, year) + weightEpisodes*episodeScore(r.Episodes, files)
+}
+
+// yearScore is neutral when either year is unknown
+func yearScore(got, want int) float64 {
+	switch {
+	case got == 0 || want == 0:
+		return 0.5
+	case got == want:
+		return 1
+	case got-want == 1 || want-got == 1:
+		return 0.5
+	}
+	return 0
+}
+
+// episodeScore rewards entries with room for every file, most of all when
+// the counts agree, and is neutral when either count is unknown
+func episodeScore(episodes, files int) float64 {
+	switch {
+	case episodes == 0 || files == 0:
+		return 0.5
+	case files > episodes:
+		return 0
+	case files == episodes:
+		return 1
+	}
+	return 0.5 + 0.5*float64(files)/float64(episodes)
+}
+
+// Similarity compares two titles with the Sørensen–Dice coefficient of their
+// character bigrams after lowercasing and dropping punctuation
+func Similarity(a, b string) float64 {
+	ga, gb := bigrams(normalize(a)), bigrams(normalize(b))
+	if len(ga) == 0 || len(gb) == 0 {
+		if normalize(a) == normalize(b) && normalize(a) != "" {
+			return 1
+		}
+		return 0
+	}
+
+	remaining := make(map[string]int, len(gb))
+	for _, g := range gb {
+		remaining[g]++
+	}
+	shared := 0
+	for _, g := range ga {
+		if remaining[g] > 0 {
+			remaining[g]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ga)+len(gb))
+}
+
+// normalize lowercases s and keeps letters and digits separated by single spaces
+func normalize(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+func bigrams(s string) []string {
+	runes := []rune(s)
+	if len(runes) < 2 {
+		return nil
+	}
+	grams := make([]string, 0, len(runes)-1)
+	for i := 0; i+1 < len(runes); i++ {
+		grams = append(grams, string(runes[i:i+2]))
+	}
+	return grams
+}
@@ -0,0 +1,109 @@
+package identify
+
+import (
+	"testing"
+
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+func TestSeriesName(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		patterns []string
+		want     string
+	}{
+		{
+			name:  "release group and resolution",
+			files: []string{"[SubsPlease] Naruto - 01 [1080p].mkv", "[SubsPlease] Naruto - 02 [1080p].mkv"},
+			want:  "Naruto",
+		},
+		{
+			name:  "dotted scene names",
+			files: []string{"Cowboy.Bebop.S01E01.1080p.mkv", "Cowboy.Bebop.S01E02.1080p.mkv"},
+			want:  "Cowboy Bebop",
+		},
+		{
+			name:  "episode prefix",
+			files: []string{"Monster Episode 01.mp4", "Monster Episode 02.mp4", "Extra 1.mp4"},
+			want:  "Monster",
+		},
+		{
+			name:     "series capture",
+			files:    []string{"01 - Frieren Beyond Journey's End.mkv"},
+			patterns: []string{"{{EP_NUM}} - {{SERIES}}.{{EXT}}"},
+			want:     "Frieren Beyond Journey's End",
+		},
+		{
+			name:  "no series text",
+			files: []string{"01.mkv", "02.mkv"},
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SeriesName(tt.files, tt.patterns); got != tt.want {
+				t.Errorf("SeriesName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYear(t *testing.T) {
+	if got := Year("[Group] Fullmetal Alchemist (2009) - 01 [1080p].mkv"); got != 2009 {
+		t.Errorf("Year = %d, want 2009", got)
+	}
+	if got := Year("Naruto - 01 [1080p].mkv", "Naruto 2002"); got != 2002 {
+		t.Errorf("Year = %d, want 2002", got)
+	}
+	if got := Year("Steins;Gate - 01.mkv"); got != 0 {
+		t.Errorf("Year = %d, want 0", got)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if s := Similarity("Naruto", "NARUTO"); s != 1 {
+		t.Errorf("case-insensitive identical titles scored %v", s)
+	}
+	if s := Similarity("Shingeki no Kyojin", "Attack on Titan"); s > 0.3 {
+		t.Errorf("unrelated titles scored %v", s)
+	}
+	if a, b := Similarity("Naruto Shippuden", "Naruto: Shippuuden"), Similarity("Naruto Shippuden", "Naruto"); a <= b {
+		t.Errorf("closer title scored %v, not above %v", a, b)
+	}
+}
+
+func TestRank(t *testing.T) {
+	results := []types.SearchResult{
+		{ID: "1735", Title: "Naruto: Shippuuden", Year: 2007, Episodes: 500},
+		{ID: "20", Title: "Naruto", Year: 2002, Episodes: 220},
+		{ID: "5114", Title: "Fullmetal Alchemist: Brotherhood", Aliases: []string{"Hagane no Renkinjutsushi"}, Year: 2009, Episodes: 64},
+	}
+
+	r := &Result{Query: "Naruto", Year: 2002, Files: 220}
+	r.Candidates = Rank(results, r.Query, r.Year, r.Files)
+	if r.Best().ID != "20" {
+		t.Fatalf("best = %s, want 20 (%+v)", r.Best().ID, r.Candidates)
+	}
+	if !r.Confident() {
+		t.Errorf("expected a confident match, got %+v", r.Candidates)
+	}
+
+	// An alias match counts like a title match
+	r = &Result{Query: "Hagane no Renkinjutsushi", Files: 64}
+	r.Candidates = Rank(results, r.Query, r.Year, r.Files)
+	if r.Best().ID != "5114" || !r.Confident() {
+		t.Errorf("alias match not identified: %+v", r.Candidates)
+	}
+
+	// Two near-identical candidates are not a confident match
+	r = &Result{Query: "Naruto", Files: 12}
+	r.Candidates = Rank([]types.SearchResult{{ID: "a", Title: "Naruto"}, {ID: "b", Title: "Naruto"}}, r.Query, 0, r.Files)
+	if r.Confident() {
+		t.Errorf("ambiguous candidates reported as confident: %+v", r.Candidates)
+	}
+
+	if (&Result{}).Confident() {
+		t.Error("empty result reported as confident")
+	}
+}
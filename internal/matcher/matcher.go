@@ -9,41 +9,47 @@ import (
 )
 
 const (
-	FieldGlue           = "+"
-	PlaceholderSeries   = "{{SERIES}}"
-	PlaceholderSeriesEn = "{{SERIES_EN}}"
-	PlaceholderSeriesJp = "{{SERIES_JP}}"
-	PlaceholderEpNum    = "{{EP_NUM}}"
-	PlaceholderEpName   = "{{EP_NAME}}"
-	PlaceholderFiller   = "{{FILLER}}"
-	PlaceholderRes      = "{{RES}}"
-	PlaceholderExt      = "{{EXT}}"
-	PlaceholderAny      = "{{ANY}}"
+	FieldGlue             = "+"
+	PlaceholderSeries     = "{{SERIES}}"
+	PlaceholderSeriesEn   = "{{SERIES_EN}}"
+	PlaceholderSeriesJp   = "{{SERIES_JP}}"
+	PlaceholderEpNum      = "{{EP_NUM}}"
+	PlaceholderEpName     = "{{EP_NAME}}"
+	PlaceholderFiller     = "{{FILLER}}"
+	PlaceholderMixed      = "{{MIXED}}"
+	PlaceholderAnimeCanon = "{{ANIME_CANON}}"
+	PlaceholderRes        = "{{RES}}"
+	PlaceholderExt        = "{{EXT}}"
+	PlaceholderAny        = "{{ANY}}"
 )
 
 var (
 	// placeholderRegexMap maps placeholder base names to their regex definitions
 	placeholderRegexMap = map[string]string{
-		"SERIES":    ".+?",
-		"SERIES_EN": ".+?",
-		"SERIES_JP": ".+?",
-		"EP_NUM":    `\d+`,
-		"EP_NAME":   ".+?",
-		"FILLER":    ".*?",
-		"RES":       `\d{3,4}p|\d{3,4}x\d{3,4}`,
-		"ANY":       ".*?",
+		"SERIES":      ".+?",
+		"SERIES_EN":   ".+?",
+		"SERIES_JP":   ".+?",
+		"EP_NUM":      `\d+`,
+		"EP_NAME":     ".+?",
+		"FILLER":      ".*?",
+		"MIXED":       ".*?",
+		"ANIME_CANON": ".*?",
+		"RES":         `\d{3,4}p|\d{3,4}x\d{3,4}`,
+		"ANY":         ".*?",
 	}
 )
 
 type TemplateVars struct {
-	Series   string
-	SeriesEn string
-	SeriesJp string
-	EpNum    string
-	EpName   string
-	Filler   string
-	Res      string
-	Ext      string
+	Series     string
+	SeriesEn   string
+	SeriesJp   string
+	EpNum      string
+	EpName     string
+	Filler     string
+	Mixed      string
+	AnimeCanon string
+	Res        string
+	Ext        string
 }
 
 // MatchResult contains extracted values from a filename match
@@ -254,6 +260,10 @@ func resolveField(field string, vars TemplateVars, padding int) (string, error)
 		return vars.EpName, nil
 	case "FILLER":
 		return vars.Filler, nil
+	case "MIXED":
+		return vars.Mixed, nil
+	case "ANIME_CANON":
+		return vars.AnimeCanon, nil
 	case "RES":
 		return vars.Res, nil
 	}
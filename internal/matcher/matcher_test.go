@@ -187,3 +187,21 @@ func TestMultiplePlaceholders(t *testing.T) {
 		t.Errorf("Series = %q, want %q", match["Series"], "My show")
 	}
 }
+
+func TestSeriesQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Naruto", "Naruto"},
+		{"[SubsPlease] Naruto.Shippuden (2007) [1080p]", "Naruto Shippuden"},
+		{"Cowboy_Bebop_BD_1080p_x265_10bit", "Cowboy Bebop"},
+		{"Attack on Titan - Complete Series [Dual Audio]", "Attack on Titan"},
+		{"One Piece WEB-DL", "One Piece"},
+	}
+	for _, tt := range tests {
+		if got := SeriesQuery(tt.name); got != tt.want {
+			t.Errorf("SeriesQuery(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package matcher
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	reGroups    = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)|\{[^}]*\}`)
+	reReleaseTk = regexp.MustCompile(`(?i)\b(\d{3,4}p|\d{3,4}x\d{3,4}|[xh]\.?26[45]|hevc|avc|av1|10-?bit|bd(rip)?|blu-?ray|web(-?dl|-?rip)?|dual[- ]audio|multi-?subs?|batch|complete( series)?|uncensored)\b`)
+	reSeparator = regexp.MustCompile(`[._]+`)
+)
+
+// SeriesQuery turns a directory or release name into a search query by
+// dropping bracketed tags, release tokens and separators.
+// "[Group] Naruto.Shippuden (2007) [1080p]" becomes "Naruto Shippuden".
+func SeriesQuery(name string) string {
+	q := reGroups.ReplaceAllString(name, " ")
+	q = reSeparator.ReplaceAllString(q, " ")
+	q = reReleaseTk.ReplaceAllString(q, " ")
+	q = strings.Join(strings.Fields(q), " ")
+	return strings.Trim(q, " -")
+}
@@ -15,7 +15,8 @@ import (
 	"golang.org/x/net/html"
 )
 
-const fillerListURL = "https://www.animefillerlist.com/shows"
+// DefaultFillerListURL is the AnimeFillerList shows root used unless a base_url is configured
+const DefaultFillerListURL = "https://www.animefillerlist.com/shows"
 
 // aflURLPatterns are URL patterns that this filler source handles
 var aflURLPatterns = []string{
@@ -25,7 +26,9 @@ var aflURLPatterns = []string{
 
 // AnimeFillerListSource implements FillerSource for AnimeFillerList.com
 type AnimeFillerListSource struct {
-	client *http.Client
+	client   *http.Client
+	baseURL  string
+	settings types.ProviderSettings
 }
 
 // NewAnimeFillerListSource creates a new AnimeFillerList source
@@ -34,9 +37,35 @@ func NewAnimeFillerListSource() *AnimeFillerListSource {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		baseURL: DefaultFillerListURL,
 	}
 }
 
+// Configure applies the global timeout and the "animefillerlist" provider settings
+func (s *AnimeFillerListSource) Configure(cfg *types.APIConfig) {
+	if cfg == nil {
+		return
+	}
+	if cfg.Timeout > 0 {
+		s.client.Timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	s.settings = cfg.Settings(s.Name())
+	s.baseURL = DefaultFillerListURL
+	if s.settings.BaseURL != "" {
+		s.baseURL = strings.TrimSuffix(s.settings.BaseURL, "/")
+	}
+	// An invalid proxy fails every request; LoadGlobal rejects it up front
+	_ = provider.ApplyProxy(s.client, s.settings.Proxy)
+}
+
+// Clone returns a copy with its own HTTP client, configured independently
+func (s *AnimeFillerListSource) Clone() types.FillerSource {
+	c := *s
+	client := *s.client
+	c.client = &client
+	return &c
+}
+
 // Name returns the filler source identifier
 func (s *AnimeFillerListSource) Name() string {
 	return "animefillerlist"
@@ -62,18 +91,17 @@ func (s *AnimeFillerListSource) ExtractSlug(url string) (string, error) {
 	return "", fmt.Errorf("could not extract slug from URL: %s", url)
 }
 
-// FetchFillers fetches filler episode numbers from AnimeFillerList
-func (s *AnimeFillerListSource) FetchFillers(ctx context.Context, slug string) ([]int, error) {
-	url := fmt.Sprintf("%s/%s", fillerListURL, slug)
+// FetchFillers fetches the episode classification from AnimeFillerList
+func (s *AnimeFillerListSource) FetchFillers(ctx context.Context, slug string) (map[int]types.FillerType, error) {
+	url := fmt.Sprintf("%s/%s", s.baseURL, slug)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	// NewRequest always sets a User-Agent to avoid blocking
+	req, err := provider.NewRequest(ctx, url, s.settings)
 	if err != nil {
 		return nil, err
 	}
-	// Add User-Agent to avoid blocking
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Autotitle/2.0; +https://github.com/mydehq/autotitle)")
 
-	resp, err := s.client.Do(req)
+	resp, err := provider.HTTPClient(ctx, s.client).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch filler list: %w", err)
 	}
@@ -94,35 +122,65 @@ func (s *AnimeFillerListSource) FetchFillers(ctx context.Context, slug string) (
 	return parseFillerHTML(resp.Body)
 }
 
-func parseFillerHTML(r io.Reader) ([]int, error) {
+// SuggestURL returns the AnimeFillerList page for a series title if one exists.
+// AnimeFillerList slugs are the lowercased title with words joined by "-".
+func (s *AnimeFillerListSource) SuggestURL(ctx context.Context, title string) (string, error) {
+	slug := aflSlug(title)
+	if slug == "" {
+		return "", nil
+	}
+	url := fmt.Sprintf("%s/%s", s.baseURL, slug)
+
+	req, err := provider.NewRequest(ctx, url, s.settings)
+	if err != nil {
+		return "", err
+	}
+	resp, err := provider.HTTPClient(ctx, s.client).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to check filler list: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return url, nil
+	case http.StatusNotFound:
+		return "", nil
+	}
+	return "", types.ErrAPIError{
+		Service:    "AnimeFillerList",
+		StatusCode: resp.StatusCode,
+		Message:    fmt.Sprintf("failed to check filler list for %s", slug),
+	}
+}
+
+var reSlugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// aflSlug converts a series title to an AnimeFillerList slug
+func aflSlug(title string) string {
+	return strings.Trim(reSlugSeparators.ReplaceAllString(strings.ToLower(title), "-"), "-")
+}
+
+func parseFillerHTML(r io.Reader) (map[int]types.FillerType, error) {
 	doc, err := html.Parse(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
 
-	var fillers []int
-	seen := make(map[int]bool)
+	fillers := make(map[int]types.FillerType)
 	var crawler func(*html.Node)
 
 	crawler = func(node *html.Node) {
 		// Look for table rows: <tr class="filler ...">
 		if node.Type == html.ElementNode && node.Data == "tr" {
-			class := getAttr(node, "class")
-			// Capture all filler types:
-			// - "filler" - pure filler
-			// - "mostly-filler" or "mostly_filler" - mostly filler content
-			// - "mixed-filler" or "mixed_filler" - mixed filler/canon
-			// Exclude "canon" only entries
-			isFiller := strings.Contains(class, "filler") && !strings.HasPrefix(strings.TrimSpace(class), "canon")
-			if isFiller {
+			if t := classifyRow(getAttr(node, "class")); t != "" {
 				// Find <td class="Number">
 				if td := findChildByClass(node, "td", "Number"); td != nil {
 					text := getText(td)
 					var num int
 					if _, err := fmt.Sscanf(strings.TrimSpace(text), "%d", &num); err == nil {
-						if !seen[num] {
-							fillers = append(fillers, num)
-							seen[num] = true
+						if _, seen := fillers[num]; !seen {
+							fillers[num] = t
 						}
 					}
 				}
@@ -137,6 +195,28 @@ func parseFillerHTML(r io.Reader) ([]int, error) {
 	return fillers, nil
 }
 
+// classifyRow maps an episode row's CSS classes to a filler type:
+//   - "anime_canon"                    - anime-original but canon
+//   - "mixed_canon/filler", "mixed-…"  - mixed canon and filler
+//   - "filler", "mostly-filler"        - filler
+//   - "manga_canon", "canon"           - canon
+//
+// Rows without a recognised class return an empty type.
+func classifyRow(class string) types.FillerType {
+	class = strings.ToLower(class)
+	switch {
+	case strings.Contains(class, "anime_canon"), strings.Contains(class, "anime-canon"):
+		return types.FillerTypeAnimeCanon
+	case strings.Contains(class, "mixed"):
+		return types.FillerTypeMixed
+	case strings.Contains(class, "filler"):
+		return types.FillerTypeFiller
+	case strings.Contains(class, "canon"):
+		return types.FillerTypeCanon
+	}
+	return ""
+}
+
 func getAttr(n *html.Node, key string) string {
 	for _, a := range n.Attr {
 		if a.Key == key {
@@ -169,5 +249,5 @@ func getText(n *html.Node) string {
 
 // init registers the AnimeFillerList source
 func init() {
-	provider.RegisterFillerSource(NewAnimeFillerListSource())
+	_ = provider.RegisterFillerSource(NewAnimeFillerListSource())
 }
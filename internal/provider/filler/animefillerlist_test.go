@@ -0,0 +1,54 @@
+package filler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+const sampleFillerHTML = `<html><body><table class="EpisodeList"><tbody>
+<tr class="manga_canon odd"><td class="Number">1</td><td class="Title">Enter: Naruto Uzumaki!</td></tr>
+<tr class="mixed_canon/filler even"><td class="Number">2</td><td class="Title">My Name is Konohamaru!</td></tr>
+<tr class="filler odd"><td class="Number">3</td><td class="Title">Sasuke and Sakura</td></tr>
+<tr class="anime_canon even"><td class="Number">4</td><td class="Title">Pass or Fail</td></tr>
+<tr class="mostly-filler odd"><td class="Number">5</td><td class="Title">You Failed!</td></tr>
+<tr class="header"><td class="Number">#</td></tr>
+</tbody></table></body></html>`
+
+func TestParseFillerHTML_Classification(t *testing.T) {
+	fillers, err := parseFillerHTML(strings.NewReader(sampleFillerHTML))
+	if err != nil {
+		t.Fatalf("parseFillerHTML failed: %v", err)
+	}
+
+	want := map[int]types.FillerType{
+		1: types.FillerTypeCanon,
+		2: types.FillerTypeMixed,
+		3: types.FillerTypeFiller,
+		4: types.FillerTypeAnimeCanon,
+		5: types.FillerTypeFiller,
+	}
+	if len(fillers) != len(want) {
+		t.Fatalf("got %d classified episodes, want %d: %v", len(fillers), len(want), fillers)
+	}
+	for num, typ := range want {
+		if fillers[num] != typ {
+			t.Errorf("episode %d: got %q, want %q", num, fillers[num], typ)
+		}
+	}
+}
+
+func TestAFLSlug(t *testing.T) {
+	tests := map[string]string{
+		"Naruto":                     "naruto",
+		"Naruto: Shippuuden":         "naruto-shippuuden",
+		"Fullmetal Alchemist (2003)": "fullmetal-alchemist-2003",
+		"  ":                         "",
+	}
+	for title, want := range tests {
+		if got := aflSlug(title); got != want {
+			t.Errorf("aflSlug(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package filler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mydehq/autotitle/internal/provider"
+	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// datasetExtensions are the file types recognised as filler datasets
+var datasetExtensions = []string{".json", ".yml", ".yaml"}
+
+// Dataset is a community-maintained filler list covering one or more series.
+// Episode lists use range syntax ("1-3, 7, 10-12").
+//
+//	version: 1
+//	series:
+//	  naruto:
+//	    title: Naruto
+//	    filler: "26, 97-106, 136-220"
+//	    mixed: "7"
+//	    anime_canon: ""
+//	    canon: "1-6, 8-25"
+type Dataset struct {
+	Version int                      `json:"version" yaml:"version"`
+	Series  map[string]DatasetSeries `json:"series" yaml:"series"`
+}
+
+// DatasetSeries holds the episode ranges for each classification
+type DatasetSeries struct {
+	Title      string `json:"title,omitempty" yaml:"title,omitempty"`
+	Canon      string `json:"canon,omitempty" yaml:"canon,omitempty"`
+	Mixed      string `json:"mixed,omitempty" yaml:"mixed,omitempty"`
+	Filler     string `json:"filler,omitempty" yaml:"filler,omitempty"`
+	AnimeCanon string `json:"anime_canon,omitempty" yaml:"anime_canon,omitempty"`
+}
+
+// DatasetSource implements FillerSource for structured JSON/YAML datasets.
+// A filler URL is a local path, file:// URL or http(s) URL to a dataset file,
+// with the series key as fragment: "~/fillers.yml#naruto". The fragment may be
+// omitted when the dataset contains a single series.
+type DatasetSource struct {
+	client   *http.Client
+	settings types.ProviderSettings
+}
+
+// NewDatasetSource creates a new dataset filler source
+func NewDatasetSource() *DatasetSource {
+	return &DatasetSource{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns the filler source identifier
+func (s *DatasetSource) Name() string {
+	return "dataset"
+}
+
+// Configure applies the global timeout and the "dataset" provider settings
+func (s *DatasetSource) Configure(cfg *types.APIConfig) {
+	if cfg == nil {
+		return
+	}
+	if cfg.Timeout > 0 {
+		s.client.Timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	s.settings = cfg.Settings(s.Name())
+	// An invalid proxy fails every request; LoadGlobal rejects it up front
+	_ = provider.ApplyProxy(s.client, s.settings.Proxy)
+}
+
+// Clone returns a copy with its own HTTP client, configured independently
+func (s *DatasetSource) Clone() types.FillerSource {
+	c := *s
+	client := *s.client
+	c.client = &client
+	return &c
+}
+
+// MatchesURL returns true for JSON/YAML dataset locations
+func (s *DatasetSource) MatchesURL(url string) bool {
+	location, _ := splitDatasetURL(url)
+	location, _, _ = strings.Cut(location, "?")
+	ext := strings.ToLower(filepath.Ext(location))
+	for _, e := range datasetExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractSlug returns the full dataset locator; FetchFillers needs both the
+// location and the series key
+func (s *DatasetSource) ExtractSlug(url string) (string, error) {
+	if !s.MatchesURL(url) {
+		return "", fmt.Errorf("not a filler dataset location: %s", url)
+	}
+	return url, nil
+}
+
+// FetchFillers loads the dataset and returns the classification for the series
+func (s *DatasetSource) FetchFillers(ctx context.Context, slug string) (map[int]types.FillerType, error) {
+	location, key := splitDatasetURL(slug)
+
+	data, err := s.read(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset, err := parseDataset(data, location)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := dataset.lookup(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", location, err)
+	}
+	return series.classify()
+}
+
+func (s *DatasetSource) read(ctx context.Context, location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		path := util.ExpandHome(strings.TrimPrefix(location, "file://"))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read filler dataset: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := provider.NewRequest(ctx, location, s.settings)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := provider.HTTPClient(ctx, s.client).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch filler dataset: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, types.ErrAPIError{
+			Service:    "Filler dataset",
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("failed to fetch %s", location),
+		}
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// ResolveDatasetURL resolves a relative local dataset path in url against
+// dir, keeping the "#series" fragment. Remote URLs, absolute and "~" paths,
+// and URLs of other sources are returned unchanged.
+func ResolveDatasetURL(url, dir string) string {
+	location, key := splitDatasetURL(url)
+	if !(&DatasetSource{}).MatchesURL(url) || strings.Contains(location, "://") ||
+		strings.HasPrefix(location, "~") || filepath.IsAbs(location) {
+		return url
+	}
+	resolved := filepath.Join(dir, location)
+	if key != "" {
+		resolved += "#" + key
+	}
+	return resolved
+}
+
+// splitDatasetURL separates "location#series" into its parts
+func splitDatasetURL(url string) (location, key string) {
+	location, key, _ = strings.Cut(url, "#")
+	return location, key
+}
+
+func parseDataset(data []byte, location string) (*Dataset, error) {
+	var dataset Dataset
+	var err error
+
+	// Strip any query string before checking the extension
+	path, _, _ := strings.Cut(location, "?")
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &dataset)
+	} else {
+		err = yaml.Unmarshal(data, &dataset)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse filler dataset: %w", err)
+	}
+	return &dataset, nil
+}
+
+func (d *Dataset) lookup(key string) (*DatasetSeries, error) {
+	if key == "" {
+		if len(d.Series) == 1 {
+			for _, series := range d.Series {
+				return &series, nil
+			}
+		}
+		return nil, fmt.Errorf("dataset has %d series; add #<series> to the filler URL", len(d.Series))
+	}
+	series, ok := d.Series[key]
+	if !ok {
+		return nil, fmt.Errorf("series %q not found in dataset", key)
+	}
+	return &series, nil
+}
+
+// classify expands the ranges into a per-episode classification.
+// Later classes win on overlap: canon < anime_canon < mixed < filler.
+func (s *DatasetSeries) classify() (map[int]types.FillerType, error) {
+	fillers := make(map[int]types.FillerType)
+	classes := []struct {
+		ranges string
+		typ    types.FillerType
+	}{
+		{s.Canon, types.FillerTypeCanon},
+		{s.AnimeCanon, types.FillerTypeAnimeCanon},
+		{s.Mixed, types.FillerTypeMixed},
+		{s.Filler, types.FillerTypeFiller},
+	}
+	for _, c := range classes {
+		nums, err := util.ParseRanges(c.ranges)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s ranges: %w", c.typ, err)
+		}
+		for _, n := range nums {
+			fillers[n] = c.typ
+		}
+	}
+	return fillers, nil
+}
+
+// init registers the dataset source
+func init() {
+	_ = provider.RegisterFillerSource(NewDatasetSource())
+}
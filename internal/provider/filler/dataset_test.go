@@ -0,0 +1,102 @@
+package filler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+const sampleDatasetYAML = `version: 1
+series:
+  naruto:
+    title: Naruto
+    canon: "1-5"
+    mixed: "3"
+    filler: "4-5"
+  bleach:
+    title: Bleach
+    filler: "33, 50-54"
+`
+
+func TestDatasetSource_FetchFillers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fillers.yml")
+	if err := os.WriteFile(path, []byte(sampleDatasetYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewDatasetSource()
+	url := path + "#naruto"
+	if !s.MatchesURL(url) {
+		t.Fatalf("MatchesURL(%q) = false", url)
+	}
+
+	slug, err := s.ExtractSlug(url)
+	if err != nil {
+		t.Fatalf("ExtractSlug failed: %v", err)
+	}
+	fillers, err := s.FetchFillers(context.Background(), slug)
+	if err != nil {
+		t.Fatalf("FetchFillers failed: %v", err)
+	}
+
+	want := map[int]types.FillerType{
+		1: types.FillerTypeCanon,
+		2: types.FillerTypeCanon,
+		3: types.FillerTypeMixed,
+		4: types.FillerTypeFiller,
+		5: types.FillerTypeFiller,
+	}
+	if len(fillers) != len(want) {
+		t.Fatalf("got %d episodes, want %d: %v", len(fillers), len(want), fillers)
+	}
+	for num, typ := range want {
+		if fillers[num] != typ {
+			t.Errorf("episode %d = %q, want %q", num, fillers[num], typ)
+		}
+	}
+
+	// Several series require a fragment
+	if _, err := s.FetchFillers(context.Background(), path); err == nil {
+		t.Error("expected error when series key is missing")
+	}
+	if _, err := s.FetchFillers(context.Background(), path+"#onepiece"); err == nil {
+		t.Error("expected error for unknown series")
+	}
+}
+
+func TestDatasetSource_MatchesURL(t *testing.T) {
+	s := NewDatasetSource()
+	tests := map[string]bool{
+		"https://example.com/fillers.json#naruto":       true,
+		"file:///data/fillers.yaml":                     true,
+		"~/fillers.yml#bleach":                          true,
+		"https://www.animefillerlist.com/shows/naruto":  false,
+		"https://example.com/fillers.json?raw=1#naruto": true,
+	}
+	for url, want := range tests {
+		if got := s.MatchesURL(url); got != want {
+			t.Errorf("MatchesURL(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
+
+func TestResolveDatasetURL(t *testing.T) {
+	dir := filepath.Join(string(filepath.Separator), "media", "show")
+	abs := filepath.Join(dir, "abs.yml")
+	tests := map[string]string{
+		"fillers.yml#naruto":                           filepath.Join(dir, "fillers.yml") + "#naruto",
+		"../shared/fillers.json":                       filepath.Join(dir, "..", "shared", "fillers.json"),
+		abs + "#naruto":                                abs + "#naruto",
+		"~/fillers.yml#bleach":                         "~/fillers.yml#bleach",
+		"https://example.com/fillers.json#naruto":      "https://example.com/fillers.json#naruto",
+		"https://www.animefillerlist.com/shows/naruto": "https://www.animefillerlist.com/shows/naruto",
+	}
+	for url, want := range tests {
+		if got := ResolveDatasetURL(url, dir); got != want {
+			t.Errorf("ResolveDatasetURL(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package provider
+
+import "github.com/mydehq/autotitle/internal/types"
+
+// fillerRank orders classifications from least to most filler-like
+var fillerRank = map[types.FillerType]int{
+	types.FillerTypeCanon:      0,
+	types.FillerTypeAnimeCanon: 1,
+	types.FillerTypeMixed:      2,
+	types.FillerTypeFiller:     3,
+}
+
+// CombineFillers merges classifications from several filler sources.
+// lists must be ordered by source priority. Policies:
+//   - first (default): the first source that classifies an episode wins
+//   - union: the most filler-like classification wins
+//   - prefer-canon: the least filler-like classification wins
+func CombineFillers(policy string, lists []map[int]types.FillerType) map[int]types.FillerType {
+	combined := make(map[int]types.FillerType)
+	for _, list := range lists {
+		for num, t := range list {
+			current, seen := combined[num]
+			switch {
+			case !seen:
+				combined[num] = t
+			case policy == types.FillerPolicyUnion && fillerRank[t] > fillerRank[current]:
+				combined[num] = t
+			case policy == types.FillerPolicyPreferCanon && fillerRank[t] < fillerRank[current]:
+				combined[num] = t
+			}
+		}
+	}
+	return combined
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
+)
+
+// DefaultUserAgent is sent when a provider has no user agent configured
+const DefaultUserAgent = "Mozilla/5.0 (compatible; Autotitle/2.0; +https://github.com/mydehq/autotitle)"
+
+// ApplyProxy routes the client's requests through the configured proxy.
+// An empty proxy restores the default transport. An invalid proxy makes
+// every request fail with the returned error rather than go out directly.
+func ApplyProxy(client *http.Client, proxy string) error {
+	if proxy == "" {
+		client.Transport = nil
+		return nil
+	}
+	u, err := util.ParseProxy(proxy)
+	if err != nil {
+		client.Transport = failingTransport{err: err}
+		return err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(u)
+	client.Transport = transport
+	return nil
+}
+
+// failingTransport fails every request with err
+type failingTransport struct {
+	err error
+}
+
+func (t failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// NewRequest creates a GET request carrying the configured user agent and headers
+func NewRequest(ctx context.Context, rawURL string, s types.ProviderSettings) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	ua := s.UserAgent
+	if ua == "" {
+		ua = DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", ua)
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+type httpClientKey struct{}
+
+// ContextWithHTTPClient returns a context whose provider and filler source
+// requests go through client instead of the source's own client
+func ContextWithHTTPClient(ctx context.Context, client *http.Client) context.Context {
+	if client == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, httpClientKey{}, client)
+}
+
+// HTTPClient returns the client carried by ctx, or fallback if there is none
+func HTTPClient(ctx context.Context, fallback *http.Client) *http.Client {
+	if c, ok := ctx.Value(httpClientKey{}).(*http.Client); ok {
+		return c
+	}
+	return fallback
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -16,7 +17,8 @@ import (
 )
 
 const (
-	jikanAPIURL = "https://api.jikan.moe/v4"
+	// DefaultJikanURL is the public Jikan API root used unless a base_url is configured
+	DefaultJikanURL = "https://api.jikan.moe/v4"
 )
 
 // malURLPatterns are URL patterns that this provider handles
@@ -29,6 +31,8 @@ var malURLPatterns = []string{
 type MALProvider struct {
 	client    *http.Client
 	rateLimit time.Duration
+	baseURL   string
+	settings  types.ProviderSettings
 }
 
 // NewMALProvider creates a new MAL provider
@@ -47,12 +51,15 @@ func NewMALProvider(cfg *types.APIConfig) *MALProvider {
 		}
 	}
 
-	return &MALProvider{
+	p := &MALProvider{
 		client: &http.Client{
 			Timeout: timeout,
 		},
 		rateLimit: rateLimit,
+		baseURL:   DefaultJikanURL,
 	}
+	p.applySettings(cfg.Settings(p.Name()))
+	return p
 }
 
 // Name returns the provider identifier
@@ -71,6 +78,26 @@ func (p *MALProvider) Configure(cfg *types.APIConfig) {
 	if cfg.RateLimit > 0 {
 		p.rateLimit = time.Duration(float64(time.Second) / cfg.RateLimit)
 	}
+	p.applySettings(cfg.Settings(p.Name()))
+}
+
+// Clone returns a copy with its own HTTP client, configured independently
+func (p *MALProvider) Clone() types.Provider {
+	c := *p
+	client := *p.client
+	c.client = &client
+	return &c
+}
+
+// applySettings applies per-provider HTTP settings (base URL, proxy, headers)
+func (p *MALProvider) applySettings(s types.ProviderSettings) {
+	p.settings = s
+	p.baseURL = DefaultJikanURL
+	if s.BaseURL != "" {
+		p.baseURL = strings.TrimSuffix(s.BaseURL, "/")
+	}
+	// An invalid proxy fails every request; LoadGlobal rejects it up front
+	_ = ApplyProxy(p.client, s.Proxy)
 }
 
 // Type returns the media type this provider handles
@@ -161,13 +188,13 @@ type animeInfoResponse struct {
 func (p *MALProvider) fetchAnimeInfo(ctx context.Context, malID int) (*animeInfoResponse, error) {
 	p.sleep()
 
-	url := fmt.Sprintf("%s/anime/%d", jikanAPIURL, malID)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	url := fmt.Sprintf("%s/anime/%d", p.baseURL, malID)
+	req, err := NewRequest(ctx, url, p.settings)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.Do(req)
+	resp, err := HTTPClient(ctx, p.client).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch anime info: %w", err)
 	}
@@ -217,13 +244,13 @@ func (p *MALProvider) fetchEpisodes(ctx context.Context, malID int) ([]types.Epi
 	for {
 		p.sleep()
 
-		url := fmt.Sprintf("%s/anime/%d/episodes?page=%d", jikanAPIURL, malID, page)
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		url := fmt.Sprintf("%s/anime/%d/episodes?page=%d", p.baseURL, malID, page)
+		req, err := NewRequest(ctx, url, p.settings)
 		if err != nil {
 			return nil, err
 		}
 
-		resp, err := p.client.Do(req)
+		resp, err := HTTPClient(ctx, p.client).Do(req)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch episodes: %w", err)
 		}
@@ -250,7 +277,8 @@ func (p *MALProvider) fetchEpisodes(ctx context.Context, malID int) ([]types.Epi
 				Aired string `json:"aired"`
 			} `json:"data"`
 			Pagination struct {
-				HasNextPage bool `json:"has_next_page"`
+				LastVisiblePage int  `json:"last_visible_page"`
+				HasNextPage     bool `json:"has_next_page"`
 			} `json:"pagination"`
 		}
 
@@ -267,6 +295,17 @@ func (p *MALProvider) fetchEpisodes(ctx context.Context, malID int) ([]types.Epi
 				AirDate: ep.Aired,
 			})
 		}
+		types.EmitContext(ctx, types.Event{
+			Type:    types.EventProgress,
+			Message: fmt.Sprintf("Fetched episodes page %d of %d for %s/%d", page, max(page, result.Pagination.LastVisiblePage), p.Name(), malID),
+			Data: types.FetchEvent{
+				Provider: p.Name(),
+				ID:       strconv.Itoa(malID),
+				Page:     page,
+				Pages:    result.Pagination.LastVisiblePage,
+				Episodes: len(episodes),
+			},
+		})
 
 		if !result.Pagination.HasNextPage {
 			break
@@ -280,13 +319,13 @@ func (p *MALProvider) fetchEpisodes(ctx context.Context, malID int) ([]types.Epi
 func (p *MALProvider) Search(ctx context.Context, query string) ([]types.SearchResult, error) {
 	p.sleep()
 
-	urlStr := fmt.Sprintf("%s/anime?q=%s&limit=5", jikanAPIURL, url.QueryEscape(query))
-	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
+	urlStr := fmt.Sprintf("%s/anime?q=%s&limit=5", p.baseURL, url.QueryEscape(query))
+	req, err := NewRequest(ctx, urlStr, p.settings)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := p.client.Do(req)
+	resp, err := HTTPClient(ctx, p.client).Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search anime: %w", err)
 	}
@@ -307,10 +346,14 @@ func (p *MALProvider) Search(ctx context.Context, query string) ([]types.SearchR
 
 	var result struct {
 		Data []struct {
-			MalID int    `json:"mal_id"`
-			Title string `json:"title"`
-			Year  *int   `json:"year"`
-			Aired struct {
+			MalID         int      `json:"mal_id"`
+			Title         string   `json:"title"`
+			TitleEnglish  string   `json:"title_english"`
+			TitleJapanese string   `json:"title_japanese"`
+			TitleSynonyms []string `json:"title_synonyms"`
+			Episodes      *int     `json:"episodes"`
+			Year          *int     `json:"year"`
+			Aired         struct {
 				Prop struct {
 					From struct {
 						Year *int `json:"year"`
@@ -334,12 +377,26 @@ func (p *MALProvider) Search(ctx context.Context, query string) ([]types.SearchR
 			year = *item.Aired.Prop.From.Year
 		}
 
+		var episodes int
+		if item.Episodes != nil {
+			episodes = *item.Episodes
+		}
+
+		var aliases []string
+		for _, alias := range append([]string{item.TitleEnglish, item.TitleJapanese}, item.TitleSynonyms...) {
+			if alias != "" && alias != item.Title && !slices.Contains(aliases, alias) {
+				aliases = append(aliases, alias)
+			}
+		}
+
 		searchResults = append(searchResults, types.SearchResult{
 			Provider: p.Name(),
 			ID:       strconv.Itoa(item.MalID),
 			Title:    item.Title,
 			Year:     year,
 			URL:      item.URL,
+			Aliases:  aliases,
+			Episodes: episodes,
 		})
 	}
 
@@ -361,5 +418,5 @@ func generateSlug(title string) string {
 
 // init registers the MAL provider
 func init() {
-	RegisterProvider(NewMALProvider(nil))
+	_ = RegisterProvider(NewMALProvider(nil))
 }
@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// mediaField describes a mergeable string field of types.Media
+type mediaField struct {
+	name string
+	get  func(*types.Media) string
+	set  func(*types.Media, string)
+}
+
+var mediaFields = []mediaField{
+	{"title", func(m *types.Media) string { return m.Title }, func(m *types.Media, v string) { m.Title = v }},
+	{"title_en", func(m *types.Media) string { return m.TitleEN }, func(m *types.Media, v string) { m.TitleEN = v }},
+	{"title_jp", func(m *types.Media) string { return m.TitleJP }, func(m *types.Media, v string) { m.TitleJP = v }},
+	{"status", func(m *types.Media) string { return m.Status }, func(m *types.Media, v string) { m.Status = v }},
+}
+
+// episodeField describes a mergeable string field of types.Episode
+type episodeField struct {
+	name string
+	get  func(*types.Episode) string
+	set  func(*types.Episode, string)
+}
+
+var episodeFields = []episodeField{
+	{"episode_title", func(e *types.Episode) string { return e.Title }, func(e *types.Episode, v string) { e.Title = v }},
+	{"air_date", func(e *types.Episode) string { return e.AirDate }, func(e *types.Episode, v string) { e.AirDate = v }},
+}
+
+// episodeSourceKey maps merge field names to the keys used in Episode.Sources
+var episodeSourceKey = map[string]string{
+	"episode_title": "title",
+	"air_date":      "air_date",
+}
+
+// MergeMedia combines media fetched from several providers into one.
+// sources[0] is the primary: its ID and provider identify the merged result.
+// Every non-empty field records the provider it was taken from in Sources.
+func MergeMedia(sources []*types.Media, cfg *types.MergeConfig) *types.Media {
+	var valid []*types.Media
+	for _, m := range sources {
+		if m != nil {
+			valid = append(valid, m)
+		}
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+
+	primary := valid[0]
+	merged := *primary
+	merged.Aliases = nil
+	merged.Episodes = nil
+	merged.Sources = make(map[string]string)
+
+	order := func(field string) []*types.Media {
+		if cfg == nil || cfg.Policy != types.MergePrecedence {
+			return valid
+		}
+		return precedenceOrder(valid, cfg.Precedence[field])
+	}
+
+	for _, f := range mediaFields {
+		for _, m := range order(f.name) {
+			if v := f.get(m); v != "" {
+				f.set(&merged, v)
+				merged.Sources[f.name] = m.Provider
+				break
+			}
+		}
+	}
+
+	for _, m := range order("aliases") {
+		if len(m.Aliases) > 0 {
+			merged.Aliases = append([]string(nil), m.Aliases...)
+			merged.Sources["aliases"] = m.Provider
+			break
+		}
+	}
+
+	merged.Episodes = mergeEpisodes(valid, order)
+	merged.EpisodeCount = max(merged.EpisodeCount, len(merged.Episodes))
+	for _, m := range valid[1:] {
+		merged.EpisodeCount = max(merged.EpisodeCount, m.EpisodeCount)
+	}
+
+	return &merged
+}
+
+// mergeEpisodes builds the union of all episode numbers and fills each field
+// from the first source (in field order) that has a value for it.
+func mergeEpisodes(sources []*types.Media, order func(string) []*types.Media) []types.Episode {
+	var numbers []int
+	seen := make(map[int]bool)
+	for _, m := range sources {
+		for _, ep := range m.Episodes {
+			if !seen[ep.Number] {
+				seen[ep.Number] = true
+				numbers = append(numbers, ep.Number)
+			}
+		}
+	}
+	slices.Sort(numbers)
+
+	episodes := make([]types.Episode, 0, len(numbers))
+	for _, num := range numbers {
+		var ep types.Episode
+		if base := firstEpisode(sources, num); base != nil {
+			ep = *base
+		}
+		ep.Number = num
+		ep.Sources = make(map[string]string)
+
+		for _, f := range episodeFields {
+			f.set(&ep, "")
+			for _, m := range order(f.name) {
+				src := m.GetEpisode(num)
+				if src == nil {
+					continue
+				}
+				if v := f.get(src); v != "" {
+					f.set(&ep, v)
+					ep.Sources[episodeSourceKey[f.name]] = m.Provider
+					break
+				}
+			}
+		}
+		if len(ep.Sources) == 0 {
+			ep.Sources = nil
+		}
+		episodes = append(episodes, ep)
+	}
+	return episodes
+}
+
+func firstEpisode(sources []*types.Media, num int) *types.Episode {
+	for _, m := range sources {
+		if ep := m.GetEpisode(num); ep != nil {
+			return ep
+		}
+	}
+	return nil
+}
+
+// precedenceOrder reorders sources so the listed providers come first.
+// Unlisted providers keep their original order after the listed ones.
+func precedenceOrder(sources []*types.Media, providers []string) []*types.Media {
+	if len(providers) == 0 {
+		return sources
+	}
+	ordered := make([]*types.Media, 0, len(sources))
+	used := make([]bool, len(sources))
+	for _, name := range providers {
+		for i, m := range sources {
+			if !used[i] && strings.EqualFold(m.Provider, name) {
+				ordered = append(ordered, m)
+				used[i] = true
+			}
+		}
+	}
+	for i, m := range sources {
+		if !used[i] {
+			ordered = append(ordered, m)
+		}
+	}
+	return ordered
+}
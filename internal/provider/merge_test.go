@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+func mergeFixtures() (*types.Media, *types.Media) {
+	primary := &types.Media{
+		ID:       "1",
+		Provider: "mal",
+		Title:    "Shingeki no Kyojin",
+		Status:   "Finished Airing",
+		Episodes: []types.Episode{
+			{Number: 1, Title: "To You, in 2000 Years", AirDate: "2013-04-07"},
+			{Number: 2, Title: ""},
+		},
+	}
+	fallback := &types.Media{
+		ID:       "99",
+		Provider: "anilist",
+		Title:    "Attack on Titan",
+		TitleEN:  "Attack on Titan",
+		Episodes: []types.Episode{
+			{Number: 1, Title: "Episode 1"},
+			{Number: 2, Title: "That Day", AirDate: "2013-04-14"},
+			{Number: 3, Title: "A Dim Light Amid Despair"},
+		},
+	}
+	return primary, fallback
+}
+
+func TestMergeMedia_FillGaps(t *testing.T) {
+	primary, fallback := mergeFixtures()
+
+	merged := MergeMedia([]*types.Media{primary, fallback}, nil)
+
+	if merged.ID != "1" || merged.Provider != "mal" {
+		t.Errorf("merged identity should come from primary, got %s/%s", merged.Provider, merged.ID)
+	}
+	if merged.Title != "Shingeki no Kyojin" || merged.Sources["title"] != "mal" {
+		t.Errorf("title = %q from %q, want primary title", merged.Title, merged.Sources["title"])
+	}
+	if merged.TitleEN != "Attack on Titan" || merged.Sources["title_en"] != "anilist" {
+		t.Errorf("title_en = %q from %q, want fallback title", merged.TitleEN, merged.Sources["title_en"])
+	}
+	if len(merged.Episodes) != 3 {
+		t.Fatalf("expected 3 merged episodes, got %d", len(merged.Episodes))
+	}
+
+	ep1 := merged.GetEpisode(1)
+	if ep1.Title != "To You, in 2000 Years" || ep1.Sources["title"] != "mal" {
+		t.Errorf("episode 1 title = %q from %q", ep1.Title, ep1.Sources["title"])
+	}
+
+	ep2 := merged.GetEpisode(2)
+	if ep2.Title != "That Day" || ep2.Sources["title"] != "anilist" {
+		t.Errorf("episode 2 title = %q from %q, want gap filled by fallback", ep2.Title, ep2.Sources["title"])
+	}
+	if ep2.AirDate != "2013-04-14" || ep2.Sources["air_date"] != "anilist" {
+		t.Errorf("episode 2 air date = %q from %q", ep2.AirDate, ep2.Sources["air_date"])
+	}
+
+	if merged.EpisodeCount != 3 {
+		t.Errorf("EpisodeCount = %d, want 3", merged.EpisodeCount)
+	}
+}
+
+func TestMergeMedia_Precedence(t *testing.T) {
+	primary, fallback := mergeFixtures()
+
+	cfg := &types.MergeConfig{
+		Policy: types.MergePrecedence,
+		Precedence: map[string][]string{
+			"title":         {"anilist"},
+			"episode_title": {"anilist", "mal"},
+		},
+	}
+	merged := MergeMedia([]*types.Media{primary, fallback}, cfg)
+
+	if merged.Title != "Attack on Titan" || merged.Sources["title"] != "anilist" {
+		t.Errorf("title = %q from %q, want anilist precedence", merged.Title, merged.Sources["title"])
+	}
+	if merged.Status != "Finished Airing" || merged.Sources["status"] != "mal" {
+		t.Errorf("status should fall back to URL order, got %q from %q", merged.Status, merged.Sources["status"])
+	}
+	if ep1 := merged.GetEpisode(1); ep1.Title != "Episode 1" {
+		t.Errorf("episode 1 title = %q, want anilist title", ep1.Title)
+	}
+	if ep1 := merged.GetEpisode(1); ep1.AirDate != "2013-04-07" || ep1.Sources["air_date"] != "mal" {
+		t.Errorf("episode 1 air date = %q from %q, want mal", ep1.AirDate, ep1.Sources["air_date"])
+	}
+}
+
+func TestCombineFillers(t *testing.T) {
+	primary := map[int]types.FillerType{
+		1: types.FillerTypeCanon,
+		2: types.FillerTypeFiller,
+	}
+	secondary := map[int]types.FillerType{
+		1: types.FillerTypeMixed,
+		2: types.FillerTypeCanon,
+		3: types.FillerTypeFiller,
+	}
+	lists := []map[int]types.FillerType{primary, secondary}
+
+	tests := []struct {
+		policy string
+		want   map[int]types.FillerType
+	}{
+		{"", map[int]types.FillerType{1: types.FillerTypeCanon, 2: types.FillerTypeFiller, 3: types.FillerTypeFiller}},
+		{types.FillerPolicyUnion, map[int]types.FillerType{1: types.FillerTypeMixed, 2: types.FillerTypeFiller, 3: types.FillerTypeFiller}},
+		{types.FillerPolicyPreferCanon, map[int]types.FillerType{1: types.FillerTypeCanon, 2: types.FillerTypeCanon, 3: types.FillerTypeFiller}},
+	}
+	for _, tt := range tests {
+		got := CombineFillers(tt.policy, lists)
+		for num, typ := range tt.want {
+			if got[num] != typ {
+				t.Errorf("policy %q: episode %d = %q, want %q", tt.policy, num, got[num], typ)
+			}
+		}
+	}
+}
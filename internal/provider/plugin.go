@@ -0,0 +1,416 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
+)
+
+// PluginPrefix is the executable name prefix used to discover provider plugins on $PATH
+const PluginPrefix = "autotitle-provider-"
+
+// PluginProtocolVersion is the version of the JSON protocol spoken with plugins
+const PluginProtocolVersion = 1
+
+// Plugin protocol
+//
+// A provider plugin is an executable that is started once per call. autotitle
+// writes a single JSON request to its stdin and reads a single JSON response
+// from its stdout. Anything written to stderr is included in error messages.
+//
+// Request:
+//
+//	{"version": 1, "method": "fetch_media", "params": {"id": "123"}, "config": {"rate_limit": 2, "timeout": 30}}
+//
+// "config" also carries the plugin's entry under api.providers of the
+// global config, if any: "base_url", "user_agent", "proxy" and "headers".
+//
+// Response:
+//
+//	{"result": <method result>}            on success
+//	{"error": "human readable message"}    on failure
+//
+// Methods:
+//
+//	info         params: {}                result: {"name": "anilist", "type": "anime"}   (optional)
+//	match_url    params: {"url": "..."}    result: true | false
+//	extract_id   params: {"url": "..."}    result: "123"
+//	fetch_media  params: {"id": "123"}     result: Media object (same JSON as the database files)
+//	search       params: {"query": "..."}  result: [{"provider", "id", "title", "year", "url", "aliases", "episodes"}, ...]
+//
+// The plugin name defaults to the executable name without the
+// "autotitle-provider-" prefix; "info" may override it and the media type.
+
+// pluginRequest is the JSON document written to a plugin's stdin
+type pluginRequest struct {
+	Version int               `json:"version"`
+	Method  string            `json:"method"`
+	Params  map[string]string `json:"params"`
+	Config  *pluginConfig     `json:"config,omitempty"`
+}
+
+type pluginConfig struct {
+	RateLimit float64           `json:"rate_limit,omitempty"`
+	Timeout   int               `json:"timeout,omitempty"`
+	BaseURL   string            `json:"base_url,omitempty"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	Proxy     string            `json:"proxy,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// pluginResponse is the JSON document read from a plugin's stdout
+type pluginResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// PluginProvider adapts an external executable to the types.Provider interface
+type PluginProvider struct {
+	path string
+	info *pluginInfo
+
+	mu      sync.Mutex // Guards the fields below
+	timeout time.Duration
+	cfg     *types.APIConfig
+	matches map[string]bool
+}
+
+// pluginInfo holds the name and media type of a plugin executable. It is
+// shared by every provider for the same path, so building another registry
+// doesn't start the plugin again.
+type pluginInfo struct {
+	once      sync.Once
+	name      string
+	mediaType types.MediaType
+}
+
+var (
+	pluginInfosMu sync.Mutex
+	pluginInfos   = make(map[string]*pluginInfo)
+)
+
+// NewPluginProvider creates a provider backed by the executable at path
+func NewPluginProvider(path string) *PluginProvider {
+	return &PluginProvider{
+		path:    path,
+		info:    infoFor(path),
+		timeout: 30 * time.Second,
+		matches: make(map[string]bool),
+	}
+}
+
+// infoFor returns the shared info of the plugin at path, holding the
+// defaults derived from the filename until the plugin is asked
+func infoFor(path string) *pluginInfo {
+	pluginInfosMu.Lock()
+	defer pluginInfosMu.Unlock()
+	if info, ok := pluginInfos[path]; ok {
+		return info
+	}
+	base := filepath.Base(path)
+	if runtime.GOOS == "windows" {
+		base = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	info := &pluginInfo{name: strings.TrimPrefix(base, PluginPrefix), mediaType: types.MediaTypeAnime}
+	pluginInfos[path] = info
+	return info
+}
+
+// Path returns the plugin executable path
+func (p *PluginProvider) Path() string {
+	return p.path
+}
+
+// Name returns the provider identifier
+func (p *PluginProvider) Name() string {
+	p.loadInfo()
+	return p.info.name
+}
+
+// Type returns the media type this provider handles
+func (p *PluginProvider) Type() types.MediaType {
+	p.loadInfo()
+	return p.info.mediaType
+}
+
+// Configure updates provider settings and forwards them with every request
+func (p *PluginProvider) Configure(cfg *types.APIConfig) {
+	if cfg == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cfg = cfg
+	if cfg.Timeout > 0 {
+		p.timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+}
+
+// MatchesURL returns true if the plugin reports it can handle the given URL
+func (p *PluginProvider) MatchesURL(url string) bool {
+	p.mu.Lock()
+	cached, ok := p.matches[url]
+	p.mu.Unlock()
+	if ok {
+		return cached
+	}
+
+	var matched bool
+	if err := p.call(context.Background(), "match_url", map[string]string{"url": url}, &matched); err != nil {
+		matched = false
+	}
+
+	p.mu.Lock()
+	p.matches[url] = matched
+	p.mu.Unlock()
+	return matched
+}
+
+// ExtractID extracts the media ID from a provider URL
+func (p *PluginProvider) ExtractID(url string) (string, error) {
+	var id string
+	if err := p.call(context.Background(), "extract_id", map[string]string{"url": url}, &id); err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", fmt.Errorf("plugin %s returned an empty ID for URL: %s", p.Name(), url)
+	}
+	return id, nil
+}
+
+// FetchMedia fetches media data from the plugin
+func (p *PluginProvider) FetchMedia(ctx context.Context, id string) (*types.Media, error) {
+	var media types.Media
+	if err := p.call(ctx, "fetch_media", map[string]string{"id": id}, &media); err != nil {
+		return nil, err
+	}
+
+	// Normalize identity fields so the database layout stays consistent
+	media.Provider = p.Name()
+	if media.ID == "" {
+		media.ID = id
+	}
+	if media.Type == "" {
+		media.Type = p.Type()
+	}
+	if media.EpisodeCount == 0 {
+		media.EpisodeCount = len(media.Episodes)
+	}
+	if media.LastUpdate.IsZero() {
+		media.LastUpdate = time.Now()
+	}
+	return &media, nil
+}
+
+// Search queries the plugin and returns matching media
+func (p *PluginProvider) Search(ctx context.Context, query string) ([]types.SearchResult, error) {
+	var results []types.SearchResult
+	if err := p.call(ctx, "search", map[string]string{"query": query}, &results); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		if results[i].Provider == "" {
+			results[i].Provider = p.Name()
+		}
+	}
+	return results, nil
+}
+
+// loadInfo asks the plugin for its name and media type, once per executable.
+// Plugins that don't implement "info" keep the defaults derived from the filename.
+func (p *PluginProvider) loadInfo() {
+	p.info.once.Do(func() {
+		var info struct {
+			Name string          `json:"name"`
+			Type types.MediaType `json:"type"`
+		}
+		if err := p.call(context.Background(), "info", map[string]string{}, &info); err != nil {
+			return
+		}
+		if info.Name != "" {
+			p.info.name = info.Name
+		}
+		if info.Type != "" {
+			p.info.mediaType = info.Type
+		}
+	})
+}
+
+// call runs the plugin once for a single method and decodes its result into out
+func (p *PluginProvider) call(ctx context.Context, method string, params map[string]string, out any) error {
+	name := p.info.name
+	if method != "info" {
+		name = p.Name() // As reported by info; the info call uses the default name
+	}
+	p.mu.Lock()
+	cfg, timeout := p.cfg, p.timeout
+	p.mu.Unlock()
+
+	req := pluginRequest{
+		Version: PluginProtocolVersion,
+		Method:  method,
+		Params:  params,
+	}
+	if cfg != nil {
+		s := cfg.Settings(name)
+		req.Config = &pluginConfig{
+			RateLimit: cfg.RateLimit,
+			Timeout:   cfg.Timeout,
+			BaseURL:   s.BaseURL,
+			UserAgent: s.UserAgent,
+			Proxy:     s.Proxy,
+			Headers:   s.Headers,
+		}
+	}
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("plugin %s: %s failed: %s", name, method, msg)
+	}
+
+	var resp pluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return fmt.Errorf("plugin %s returned invalid JSON for %s: %w", name, method, err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("plugin %s: %s: %s", name, method, resp.Error)
+	}
+	if len(resp.Result) == 0 {
+		return fmt.Errorf("plugin %s returned no result for %s", name, method)
+	}
+	if err := json.Unmarshal(resp.Result, out); err != nil {
+		return fmt.Errorf("plugin %s returned an unexpected result for %s: %w", name, method, err)
+	}
+	return nil
+}
+
+// DiscoverPlugins returns the plugin executables found on $PATH plus any extra paths.
+// Duplicate names are resolved in favour of the first occurrence. Extra paths
+// may start with "~" or contain environment variables; those that are not
+// executable files are left out and reported in the error.
+func DiscoverPlugins(extra []string) ([]string, error) {
+	var found []string
+	seen := make(map[string]bool)
+
+	add := func(path string) {
+		name := filepath.Base(path)
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		found = append(found, path)
+	}
+
+	var errs []error
+	for _, configured := range extra {
+		if configured == "" {
+			continue
+		}
+		path := util.ExpandHome(os.ExpandEnv(configured))
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if !isExecutable(path) {
+			errs = append(errs, fmt.Errorf("plugin %s: no executable at %s", configured, path))
+			continue
+		}
+		add(path)
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), PluginPrefix) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			add(path)
+		}
+	}
+
+	return found, errors.Join(errs...)
+}
+
+// LoadPlugins discovers provider plugins and registers them globally.
+// Plugins whose name collides with an already registered provider are skipped.
+func LoadPlugins(extra []string) ([]string, error) {
+	return registry.LoadPlugins(extra)
+}
+
+// LoadPlugins discovers provider plugins and adds them to r, skipping those
+// whose name collides with a provider r already knows. The error reports
+// extra paths without a plugin; the others are loaded regardless.
+func (r *Registry) LoadPlugins(extra []string) ([]string, error) {
+	var loaded []string
+	paths, err := DiscoverPlugins(extra)
+	for _, path := range paths {
+		if r.hasPlugin(path) {
+			continue
+		}
+		p := NewPluginProvider(path)
+		if _, err := r.Provider(p.Name()); err == nil {
+			continue
+		}
+		if err := r.AddProvider(p); err != nil {
+			continue
+		}
+		loaded = append(loaded, p.Name())
+	}
+	return loaded, err
+}
+
+func (r *Registry) hasPlugin(path string) bool {
+	for _, p := range r.Providers() {
+		if pp, ok := p.(*PluginProvider); ok && pp.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(path))
+		return ext == ".exe" || ext == ".bat" || ext == ".cmd"
+	}
+	return info.Mode()&0111 != 0
+}
@@ -0,0 +1,215 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// TestPluginHelperProcess is not a real test. It is executed as the plugin
+// binary by writeTestPlugin and answers requests on stdin.
+func TestPluginHelperProcess(t *testing.T) {
+	if os.Getenv("AUTOTITLE_TEST_PLUGIN") != "1" {
+		return
+	}
+
+	var req pluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, "bad request:", err)
+		os.Exit(2)
+	}
+
+	if log := os.Getenv("AUTOTITLE_TEST_PLUGIN_LOG"); log != "" {
+		if f, err := os.OpenFile(log, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err == nil {
+			fmt.Fprintln(f, req.Method)
+			f.Close()
+		}
+	}
+
+	var result any
+	switch req.Method {
+	case "info":
+		result = map[string]string{"name": "testsrc", "type": "anime"}
+	case "match_url":
+		result = strings.Contains(req.Params["url"], "example.test/show/")
+	case "extract_id":
+		result = strings.TrimPrefix(req.Params["url"], "https://example.test/show/")
+	case "fetch_media":
+		result = map[string]any{
+			"title":    "Plugin Show",
+			"episodes": []map[string]any{{"number": 1, "title": "Pilot"}},
+		}
+	case "search":
+		title := "Plugin Show"
+		if req.Config != nil && req.Config.BaseURL != "" {
+			title = "Plugin Show from " + req.Config.BaseURL // Echo the forwarded settings
+		}
+		result = []map[string]any{{"id": "7", "title": title, "url": "https://example.test/show/7"}}
+	default:
+		_ = json.NewEncoder(os.Stdout).Encode(map[string]string{"error": "unknown method " + req.Method})
+		os.Exit(0)
+	}
+
+	_ = json.NewEncoder(os.Stdout).Encode(map[string]any{"result": result})
+	os.Exit(0)
+}
+
+func writeTestPlugin(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell plugin wrapper not supported on windows")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, PluginPrefix+"fromfile")
+	script := fmt.Sprintf("#!/bin/sh\nAUTOTITLE_TEST_PLUGIN=1 exec %q -test.run=TestPluginHelperProcess\n", os.Args[0])
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPluginProvider_Protocol(t *testing.T) {
+	p := NewPluginProvider(writeTestPlugin(t))
+	ctx := context.Background()
+
+	if got := p.Name(); got != "testsrc" {
+		t.Errorf("Name() = %q, want %q", got, "testsrc")
+	}
+	if !p.MatchesURL("https://example.test/show/7") {
+		t.Error("MatchesURL returned false for a handled URL")
+	}
+	if p.MatchesURL("https://myanimelist.net/anime/1") {
+		t.Error("MatchesURL returned true for a foreign URL")
+	}
+
+	id, err := p.ExtractID("https://example.test/show/7")
+	if err != nil || id != "7" {
+		t.Fatalf("ExtractID = %q, %v; want \"7\"", id, err)
+	}
+
+	media, err := p.FetchMedia(ctx, id)
+	if err != nil {
+		t.Fatalf("FetchMedia failed: %v", err)
+	}
+	if media.Provider != "testsrc" || media.ID != "7" || media.EpisodeCount != 1 {
+		t.Errorf("unexpected media identity: %+v", media)
+	}
+	if ep := media.GetEpisode(1); ep == nil || ep.Title != "Pilot" {
+		t.Errorf("expected episode 1 'Pilot', got %+v", ep)
+	}
+
+	results, err := p.Search(ctx, "plugin")
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Provider != "testsrc" {
+		t.Errorf("unexpected search results: %+v", results)
+	}
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	path := writeTestPlugin(t)
+	t.Setenv("PATH", filepath.Dir(path))
+
+	found, err := DiscoverPlugins(nil)
+	if err != nil || len(found) != 1 || found[0] != path {
+		t.Errorf("DiscoverPlugins() = %v, %v; want [%s]", found, err, path)
+	}
+
+	// Extra paths take precedence over $PATH entries with the same name
+	found, _ = DiscoverPlugins([]string{path, path})
+	if len(found) != 1 {
+		t.Errorf("expected duplicates to be collapsed, got %v", found)
+	}
+
+	// "~" expands to the home directory; missing plugins are reported
+	t.Setenv("HOME", filepath.Dir(path))
+	t.Setenv("USERPROFILE", filepath.Dir(path))
+	found, err = DiscoverPlugins([]string{"~/" + filepath.Base(path), "~/autotitle-provider-missing"})
+	if len(found) != 1 || found[0] != path {
+		t.Errorf("DiscoverPlugins(~) = %v, want [%s]", found, path)
+	}
+	if err == nil || !strings.Contains(err.Error(), "autotitle-provider-missing") {
+		t.Errorf("expected the missing plugin to be reported, got %v", err)
+	}
+}
+
+func TestPluginProvider_ForwardsSettings(t *testing.T) {
+	p := NewPluginProvider(writeTestPlugin(t))
+	p.Configure(&types.APIConfig{Providers: map[string]types.ProviderSettings{
+		"testsrc": {BaseURL: "https://mirror.test"},
+	}})
+
+	results, err := p.Search(context.Background(), "plugin")
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Title != "Plugin Show from https://mirror.test" {
+		t.Errorf("provider settings not forwarded: %+v", results)
+	}
+}
+
+func TestRegistry_LoadPlugins(t *testing.T) {
+	path := writeTestPlugin(t)
+	t.Setenv("PATH", "")
+
+	// Plugins load into the registry they are listed for, not the global one
+	r := NewRegistry(Global())
+	if loaded, err := r.LoadPlugins([]string{path}); err != nil || len(loaded) != 1 || loaded[0] != "testsrc" {
+		t.Fatalf("LoadPlugins() = %v, %v; want [testsrc]", loaded, err)
+	}
+	if _, err := r.Provider("testsrc"); err != nil {
+		t.Errorf("plugin missing from its registry: %v", err)
+	}
+	if _, err := GetProvider("testsrc"); err == nil {
+		t.Error("plugin leaked into the global registry")
+	}
+	if _, err := r.Provider("mal"); err != nil {
+		t.Errorf("global provider not visible through the layered registry: %v", err)
+	}
+	if loaded, _ := r.LoadPlugins([]string{path}); len(loaded) != 0 {
+		t.Errorf("second LoadPlugins() = %v, want none", loaded)
+	}
+}
+
+func TestPluginProvider_InfoOnce(t *testing.T) {
+	path := writeTestPlugin(t)
+	log := filepath.Join(t.TempDir(), "calls")
+	t.Setenv("AUTOTITLE_TEST_PLUGIN_LOG", log)
+	t.Setenv("PATH", "")
+
+	// Every registry gets its own provider, but the plugin is asked once
+	for range 2 {
+		if loaded, err := NewRegistry(nil).LoadPlugins([]string{path}); err != nil || len(loaded) != 1 || loaded[0] != "testsrc" {
+			t.Fatalf("LoadPlugins() = %v, %v; want [testsrc]", loaded, err)
+		}
+	}
+
+	// Configuring while a call runs is safe
+	p := NewPluginProvider(path)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Configure(&types.APIConfig{Timeout: 10})
+	}()
+	if _, err := p.Search(context.Background(), "plugin"); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	<-done
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "info\n"); n != 1 {
+		t.Errorf("info ran %d times, want 1", n)
+	}
+}
@@ -1,7 +1,14 @@
 package provider
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/mydehq/autotitle/internal/types"
 )
 
 func TestMALProvider_MatchesURL(t *testing.T) {
@@ -91,3 +98,85 @@ func TestGetProviderForURL(t *testing.T) {
 		})
 	}
 }
+
+func TestMALProvider_FetchMedia_BaseURL(t *testing.T) {
+	var userAgent, token string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		token = r.Header.Get("X-Token")
+		switch r.URL.Path {
+		case "/v4/anime/42":
+			fmt.Fprint(w, `{"data": {"title": "Mirror Show", "title_english": "Mirror Show EN", "status": "Finished Airing"}}`)
+		case "/v4/anime/42/episodes":
+			if r.URL.Query().Get("page") == "1" {
+				fmt.Fprint(w, `{"data": [{"mal_id": 1, "title": "First"}], "pagination": {"last_visible_page": 2, "has_next_page": true}}`)
+			} else {
+				fmt.Fprint(w, `{"data": [{"mal_id": 2, "title": "Second"}], "pagination": {"last_visible_page": 2, "has_next_page": false}}`)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewMALProvider(nil)
+	p.Configure(&types.APIConfig{
+		RateLimit: 1000,
+		Providers: map[string]types.ProviderSettings{
+			"mal": {
+				BaseURL:   srv.URL + "/v4/",
+				UserAgent: "autotitle-ci",
+				Headers:   map[string]string{"X-Token": "secret"},
+			},
+		},
+	})
+
+	var progress []types.FetchEvent
+	ctx := types.ContextWithEvents(context.Background(), func(e types.Event) {
+		if data, ok := e.Data.(types.FetchEvent); ok {
+			progress = append(progress, data)
+		}
+	})
+
+	media, err := p.FetchMedia(ctx, "42")
+	if err != nil {
+		t.Fatalf("FetchMedia failed: %v", err)
+	}
+	if media.Title != "Mirror Show" || media.TitleEN != "Mirror Show EN" {
+		t.Errorf("unexpected titles: %q / %q", media.Title, media.TitleEN)
+	}
+	if len(media.Episodes) != 2 || media.Episodes[1].Title != "Second" {
+		t.Errorf("expected 2 paginated episodes, got %+v", media.Episodes)
+	}
+	if len(progress) != 2 || progress[1].Page != 2 || progress[1].Pages != 2 || progress[1].Episodes != 2 {
+		t.Errorf("unexpected fetch progress: %+v", progress)
+	}
+	if userAgent != "autotitle-ci" || token != "secret" {
+		t.Errorf("configured headers not sent: User-Agent=%q X-Token=%q", userAgent, token)
+	}
+}
+
+func TestMALProvider_InvalidProxy(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"data": []}`)
+	}))
+	defer srv.Close()
+
+	p := NewMALProvider(nil)
+	p.Configure(&types.APIConfig{
+		RateLimit: 1000,
+		Providers: map[string]types.ProviderSettings{
+			"mal": {BaseURL: srv.URL, Proxy: "127.0.0.1:1080"},
+		},
+	})
+
+	// The request must not bypass the misconfigured proxy
+	if _, err := p.Search(context.Background(), "show"); err == nil || !strings.Contains(err.Error(), "invalid proxy URL") {
+		t.Errorf("Search with an invalid proxy: err = %v, want the proxy error", err)
+	}
+	if requests != 0 {
+		t.Errorf("%d requests went out directly", requests)
+	}
+}
@@ -2,28 +2,106 @@
 package provider
 
 import (
+	"fmt"
+	"slices"
+	"sync"
+
 	"github.com/mydehq/autotitle/internal/types"
 )
 
-// providers is the global registry of available providers
-var providers []types.Provider
+// Registry holds the available providers and filler sources. It is safe for
+// concurrent use.
+type Registry struct {
+	mu            sync.RWMutex
+	parent        *Registry
+	providers     []types.Provider
+	fillerSources []types.FillerSource
+}
 
-// fillerSources is the global registry of available filler sources
-var fillerSources []types.FillerSource
+// registry is the global registry built-in sources, plugins and library
+// users register with
+var registry = &Registry{}
 
-// RegisterProvider adds a provider to the registry
-func RegisterProvider(p types.Provider) {
-	providers = append(providers, p)
+// Global returns the registry the package-level functions use
+func Global() *Registry {
+	return registry
 }
 
-// RegisterFillerSource adds a filler source to the registry
-func RegisterFillerSource(s types.FillerSource) {
-	fillerSources = append(fillerSources, s)
+// NewRegistry creates a registry layered over parent: its own entries come
+// first and hide parent entries of the same name, and entries registered
+// with parent later are still found. parent may be nil.
+func NewRegistry(parent *Registry) *Registry {
+	return &Registry{parent: parent}
 }
 
-// GetProviderForURL finds the provider that can handle the given URL
-func GetProviderForURL(url string) (types.Provider, error) {
-	for _, p := range providers {
+// AddProvider adds a provider. It fails if the name is already registered.
+func (r *Registry) AddProvider(p types.Provider) error {
+	name := p.Name() // Plugins answer this by running; keep it out of the lock
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.providers {
+		if existing.Name() == name {
+			return fmt.Errorf("provider %q is already registered", name)
+		}
+	}
+	r.providers = append(r.providers, p)
+	return nil
+}
+
+// AddFillerSource adds a filler source. It fails if the name is already
+// registered.
+func (r *Registry) AddFillerSource(s types.FillerSource) error {
+	name := s.Name()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.fillerSources {
+		if existing.Name() == name {
+			return fmt.Errorf("filler source %q is already registered", name)
+		}
+	}
+	r.fillerSources = append(r.fillerSources, s)
+	return nil
+}
+
+// Providers returns the registered providers in registration order,
+// followed by those of the parent registry not hidden by them
+func (r *Registry) Providers() []types.Provider {
+	r.mu.RLock()
+	providers := append([]types.Provider(nil), r.providers...)
+	r.mu.RUnlock()
+	if r.parent == nil {
+		return providers
+	}
+	own := len(providers)
+	for _, p := range r.parent.Providers() {
+		if !slices.ContainsFunc(providers[:own], func(q types.Provider) bool { return q.Name() == p.Name() }) {
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
+// FillerSources returns the registered filler sources in registration
+// order, followed by those of the parent registry not hidden by them
+func (r *Registry) FillerSources() []types.FillerSource {
+	r.mu.RLock()
+	sources := append([]types.FillerSource(nil), r.fillerSources...)
+	r.mu.RUnlock()
+	if r.parent == nil {
+		return sources
+	}
+	own := len(sources)
+	for _, s := range r.parent.FillerSources() {
+		if !slices.ContainsFunc(sources[:own], func(q types.FillerSource) bool { return q.Name() == s.Name() }) {
+			sources = append(sources, s)
+		}
+	}
+	return sources
+}
+
+// ProviderForURL finds the provider that can handle the given URL
+func (r *Registry) ProviderForURL(url string) (types.Provider, error) {
+	for _, p := range r.Providers() {
 		if p.MatchesURL(url) {
 			return p, nil
 		}
@@ -31,9 +109,9 @@ func GetProviderForURL(url string) (types.Provider, error) {
 	return nil, types.ErrProviderNotFound{URL: url}
 }
 
-// GetProvider finds a provider by its name
-func GetProvider(name string) (types.Provider, error) {
-	for _, p := range providers {
+// Provider finds a provider by its name
+func (r *Registry) Provider(name string) (types.Provider, error) {
+	for _, p := range r.Providers() {
 		if p.Name() == name {
 			return p, nil
 		}
@@ -41,9 +119,19 @@ func GetProvider(name string) (types.Provider, error) {
 	return nil, types.ErrProviderNotFound{URL: name}
 }
 
-// GetFillerSourceForURL finds the filler source that can handle the given URL
-func GetFillerSourceForURL(url string) (types.FillerSource, error) {
-	for _, s := range fillerSources {
+// FillerSource finds a filler source by its name
+func (r *Registry) FillerSource(name string) (types.FillerSource, error) {
+	for _, s := range r.FillerSources() {
+		if s.Name() == name {
+			return s, nil
+		}
+	}
+	return nil, types.ErrFillerSourceNotFound{URL: name}
+}
+
+// FillerSourceForURL finds the filler source that can handle the given URL
+func (r *Registry) FillerSourceForURL(url string) (types.FillerSource, error) {
+	for _, s := range r.FillerSources() {
 		if s.MatchesURL(url) {
 			return s, nil
 		}
@@ -51,6 +139,58 @@ func GetFillerSourceForURL(url string) (types.FillerSource, error) {
 	return nil, types.ErrFillerSourceNotFound{URL: url}
 }
 
+// ProviderNames returns all registered provider names
+func (r *Registry) ProviderNames() []string {
+	providers := r.Providers()
+	names := make([]string, len(providers))
+	for i, p := range providers {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// FillerSourceNames returns all registered filler source names
+func (r *Registry) FillerSourceNames() []string {
+	sources := r.FillerSources()
+	names := make([]string, len(sources))
+	for i, s := range sources {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// RegisterProvider adds a provider to the global registry. It fails if the
+// name is already registered.
+func RegisterProvider(p types.Provider) error {
+	return registry.AddProvider(p)
+}
+
+// RegisterFillerSource adds a filler source to the global registry. It
+// fails if the name is already registered.
+func RegisterFillerSource(s types.FillerSource) error {
+	return registry.AddFillerSource(s)
+}
+
+// GetProviderForURL finds the provider that can handle the given URL
+func GetProviderForURL(url string) (types.Provider, error) {
+	return registry.ProviderForURL(url)
+}
+
+// GetProvider finds a provider by its name
+func GetProvider(name string) (types.Provider, error) {
+	return registry.Provider(name)
+}
+
+// GetFillerSource finds a filler source by its name
+func GetFillerSource(name string) (types.FillerSource, error) {
+	return registry.FillerSource(name)
+}
+
+// GetFillerSourceForURL finds the filler source that can handle the given URL
+func GetFillerSourceForURL(url string) (types.FillerSource, error) {
+	return registry.FillerSourceForURL(url)
+}
+
 // ExtractProviderAndID extracts the provider name and ID from a URL
 func ExtractProviderAndID(url string) (provider string, id string, err error) {
 	p, err := GetProviderForURL(url)
@@ -66,18 +206,10 @@ func ExtractProviderAndID(url string) (provider string, id string, err error) {
 
 // ListProviders returns all registered provider names
 func ListProviders() []string {
-	names := make([]string, len(providers))
-	for i, p := range providers {
-		names[i] = p.Name()
-	}
-	return names
+	return registry.ProviderNames()
 }
 
 // ListFillerSources returns all registered filler source names
 func ListFillerSources() []string {
-	names := make([]string, len(fillerSources))
-	for i, s := range fillerSources {
-		names[i] = s.Name()
-	}
-	return names
+	return registry.FillerSourceNames()
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/mydehq/autotitle/internal/matcher"
 	"github.com/mydehq/autotitle/internal/tagger"
 	"github.com/mydehq/autotitle/internal/types"
+	"github.com/mydehq/autotitle/internal/util"
 )
 
 // Renamer handles file renaming operations
@@ -27,6 +28,24 @@ type Renamer struct {
 	BackupConfig  types.BackupConfig
 	Formats       []string
 	Offset        *int
+	Segments      []Segment
+}
+
+// Segment maps local episode numbers onto the episodes of another media entry.
+// The n-th entry of Episodes resolves to media episode Start+n-1.
+type Segment struct {
+	Episodes []int // Local episode numbers, sorted
+	Start    int   // Media episode number of the first local episode
+	Media    *types.Media
+}
+
+// Resolve returns the media episode number for a local episode number
+func (s Segment) Resolve(num int) (int, bool) {
+	idx, found := slices.BinarySearch(s.Episodes, num)
+	if !found {
+		return 0, false
+	}
+	return s.Start + idx, true
 }
 
 // New creates a new Renamer
@@ -34,7 +53,7 @@ func New(db types.DatabaseRepository, backupConfig types.BackupConfig, formats [
 	dbPath := db.Path()
 	cacheRoot := filepath.Dir(dbPath)
 
-	bm := backup.New(cacheRoot, backupConfig.DirName)
+	bm := backup.FromConfig(cacheRoot, backupConfig)
 
 	if len(formats) == 0 {
 		formats = config.GetDefaults().Formats
@@ -55,6 +74,15 @@ func (r *Renamer) WithEvents(h types.EventHandler) *Renamer {
 	return r
 }
 
+// WithBackupManager replaces the default backup manager
+func (r *Renamer) WithBackupManager(bm types.BackupManager) *Renamer {
+	r.BackupManager = bm
+	if r.Events != nil {
+		bm.WithEvents(r.Events)
+	}
+	return r
+}
+
 // WithDryRun enables dry-run mode
 func (r *Renamer) WithDryRun() *Renamer {
 	r.DryRun = true
@@ -79,8 +107,24 @@ func (r *Renamer) WithOffset(offset int) *Renamer {
 	return r
 }
 
+// WithSegments maps local episode ranges onto other media entries.
+// Episodes outside every segment resolve against the target's media.
+func (r *Renamer) WithSegments(segments []Segment) *Renamer {
+	r.Segments = segments
+	return r
+}
+
 // Execute performs the rename operation for a target
 func (r *Renamer) Execute(ctx context.Context, dir string, target *types.Target, media *types.Media) ([]types.RenameOperation, error) {
+	operations, err := r.Plan(dir, target, media)
+	if err != nil {
+		return nil, err
+	}
+	return r.Apply(ctx, dir, operations)
+}
+
+// Plan computes the rename operations for a target without touching any file
+func (r *Renamer) Plan(dir string, target *types.Target, media *types.Media) ([]types.RenameOperation, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read directory: %w", err)
@@ -94,10 +138,9 @@ func (r *Renamer) Execute(ctx context.Context, dir string, target *types.Target,
 		}
 	}
 
-	smartPadding := r.calculatePadding(media)
+	smartPadding := r.smartPadding(media)
 
 	var operations []types.RenameOperation
-	renameMappings := make(map[string]string)
 
 	usedTargets := make(map[string]bool)
 
@@ -112,108 +155,178 @@ func (r *Renamer) Execute(ctx context.Context, dir string, target *types.Target,
 			continue
 		}
 
-		var matchResult *matcher.MatchResult
-		var matchPattern *types.Pattern
-
-		patIdx := 0
-		found := false
-		for i := range target.Patterns {
-			for range target.Patterns[i].Input {
-				if patIdx < len(patterns) {
-					p := patterns[patIdx]
-					if result, ok := p.MatchTyped(filename); ok {
-						matchResult = result
-						matchPattern = &target.Patterns[i]
-						found = true
-						break
-					}
-				}
-				patIdx++
-			}
-			if found {
-				break
-			}
+		op, skip := r.planFile(dir, filename, target, patterns, media, smartPadding, 0)
+		if skip != nil {
+			r.emit(*skip)
+			continue
 		}
+		newFilename := filepath.Base(op.TargetPath)
 
-		if matchResult == nil {
-			r.emit(types.Event{Type: types.EventWarning, Message: fmt.Sprintf("No pattern matched: %s", filename)})
+		// Check for target collision
+		if usedTargets[op.TargetPath] {
+			r.emit(types.Event{
+				Type:    types.EventError,
+				Message: fmt.Sprintf("Collision detected: %s and another file both want to rename to %s", filename, newFilename),
+				Data:    types.CollisionEvent{File: filename, Target: newFilename},
+			})
 			continue
 		}
+		usedTargets[op.TargetPath] = true
 
-		outputCfg := matchPattern.Output
-
-		padding := outputCfg.Padding
-		if padding == 0 {
-			padding = smartPadding
+		if op.SourcePath == op.TargetPath {
+			op.Status = types.StatusSkipped
+			r.emit(types.Event{Type: types.EventInfo, Message: fmt.Sprintf("Skipped (unchanged): %s", filename)})
+		} else {
+			planned := types.Event{
+				Type:    types.EventProgress,
+				Message: fmt.Sprintf("Planned: %s → %s", filename, newFilename),
+				Data:    types.RenameEvent{Stage: types.RenamePlanned, Operation: op},
+			}
+			if r.DryRun {
+				planned.Type = types.EventInfo
+				planned.Message = fmt.Sprintf("[DRY-RUN] %s → %s", filename, newFilename)
+			}
+			r.emit(planned)
 		}
 
-		// Calculate Offset
-		offset := MatchResultOffset(r.Offset, matchPattern)
+		operations = append(operations, op)
+	}
 
-		// Get Episode
-		episodeNum := matchResult.EpisodeNum + offset
-		ep := media.GetEpisode(episodeNum)
-		if ep == nil {
-			msg := fmt.Sprintf("Episode %d not found in database", matchResult.EpisodeNum)
-			if offset != 0 {
-				msg = fmt.Sprintf("Episode %d (mapped to %d) not found in database", matchResult.EpisodeNum, episodeNum)
-			}
-			r.emit(types.Event{Type: types.EventWarning, Message: msg})
-			continue
-		}
+	return operations, nil
+}
+
+// PlanFile computes the rename operation of a single file in dir. A positive
+// episode replaces the number parsed from the filename; offset and segments
+// still apply to it.
+func (r *Renamer) PlanFile(dir, filename string, target *types.Target, media *types.Media, episode int) (types.RenameOperation, error) {
+	patterns, err := r.compilePatterns(target)
+	if len(patterns) == 0 {
+		return types.RenameOperation{}, fmt.Errorf("no valid patterns found: %w", err)
+	}
 
-		// Build Variables
-		vars := matcher.TemplateVars{
-			Series:   media.GetTitle("SERIES"),
-			SeriesEn: media.GetTitle("SERIES_EN"),
-			SeriesJp: media.GetTitle("SERIES_JP"),
-			EpNum:    fmt.Sprintf("%d", ep.Number),
-			EpName:   ep.Title,
-			Res:      matchResult.Resolution,
-			Ext:      matchResult.Extension,
+	op, skip := r.planFile(dir, filename, target, patterns, media, r.smartPadding(media), episode)
+	if skip != nil {
+		return types.RenameOperation{}, fmt.Errorf("%s", skip.Message)
+	}
+	if op.SourcePath == op.TargetPath {
+		op.Status = types.StatusSkipped
+	}
+	return op, nil
+}
+
+// planFile computes the pending rename of filename. When the file can't be
+// renamed, the returned event says why.
+func (r *Renamer) planFile(dir, filename string, target *types.Target, patterns []*matcher.Pattern, media *types.Media, smartPadding, episode int) (types.RenameOperation, *types.Event) {
+	var matchResult *matcher.MatchResult
+	var matchPattern *types.Pattern
+
+	patIdx := 0
+	found := false
+	for i := range target.Patterns {
+		for range target.Patterns[i].Input {
+			if patIdx < len(patterns) {
+				p := patterns[patIdx]
+				if result, ok := p.MatchTyped(filename); ok {
+					matchResult = result
+					matchPattern = &target.Patterns[i]
+					found = true
+					break
+				}
+			}
+			patIdx++
 		}
-		if ep.IsFiller {
-			vars.Filler = "[F]"
+		if found {
+			break
 		}
+	}
 
-		// Generate Filename
-		separator := outputCfg.Separator
+	if matchResult == nil {
+		return types.RenameOperation{}, &types.Event{Type: types.EventWarning, Message: fmt.Sprintf("No pattern matched: %s", filename)}
+	}
+	if episode > 0 {
+		matchResult.EpisodeNum = episode
+	}
 
-		newFilename, err := matcher.GenerateFilenameFromFields(outputCfg.Fields, separator, vars, padding)
-		if err != nil {
-			r.emit(types.Event{Type: types.EventError, Message: fmt.Sprintf("Failed to generate filename: %v", err)})
-			continue
-		}
+	outputCfg := matchPattern.Output
 
-		sourcePath := filepath.Join(dir, filename)
-		targetPath := filepath.Join(dir, newFilename)
+	padding := outputCfg.Padding
+	if padding == 0 {
+		padding = smartPadding
+	}
 
-		// Check for target collision
-		if usedTargets[targetPath] {
-			r.emit(types.Event{Type: types.EventError, Message: fmt.Sprintf("Collision detected: %s and another file both want to rename to %s", filename, newFilename)})
-			continue
-		}
-		usedTargets[targetPath] = true
-
-		op := types.RenameOperation{
-			SourcePath: sourcePath,
-			TargetPath: targetPath,
-			Episode:    ep,
-			Series:     media.Title,
-			Status:     types.StatusPending,
-		}
+	// Calculate Offset
+	offset := MatchResultOffset(r.Offset, matchPattern)
 
-		if sourcePath == targetPath {
-			op.Status = types.StatusSkipped
-			r.emit(types.Event{Type: types.EventInfo, Message: fmt.Sprintf("Skipped (unchanged): %s", filename)})
-		} else {
-			renameMappings[filename] = newFilename
-			if r.DryRun {
-				r.emit(types.Event{Type: types.EventInfo, Message: fmt.Sprintf("[DRY-RUN] %s → %s", filename, newFilename)})
-			}
+	// Get Episode
+	local := matchResult.EpisodeNum + offset
+	episodeNum := local
+	epMedia := media
+	if seg, num, ok := r.resolveSegment(episodeNum); ok {
+		epMedia = seg.Media
+		episodeNum = num
+	}
+	ep := epMedia.GetEpisode(episodeNum)
+	if ep == nil {
+		msg := fmt.Sprintf("Episode %d not found in database", matchResult.EpisodeNum)
+		if episodeNum != matchResult.EpisodeNum {
+			msg = fmt.Sprintf("Episode %d (mapped to %d) not found in database", matchResult.EpisodeNum, episodeNum)
 		}
+		return types.RenameOperation{}, &types.Event{Type: types.EventWarning, Message: msg, Data: types.EpisodeNotFoundEvent{
+			File:   filename,
+			Number: matchResult.EpisodeNum,
+			Mapped: episodeNum,
+		}}
+	}
+	if ep.Skip {
+		return types.RenameOperation{}, &types.Event{Type: types.EventInfo, Message: fmt.Sprintf("Skipped (override): %s", filename)}
+	}
 
-		operations = append(operations, op)
+	// Build Variables
+	vars := matcher.TemplateVars{
+		Series:   epMedia.GetTitle("SERIES"),
+		SeriesEn: epMedia.GetTitle("SERIES_EN"),
+		SeriesJp: epMedia.GetTitle("SERIES_JP"),
+		EpNum:    fmt.Sprintf("%d", ep.Number),
+		EpName:   ep.Title,
+		Res:      matchResult.Resolution,
+		Ext:      matchResult.Extension,
+	}
+	markers := outputCfg.Markers.Resolve()
+	switch ep.Classification() {
+	case types.FillerTypeFiller:
+		vars.Filler = markers.Filler
+	case types.FillerTypeMixed:
+		vars.Mixed = markers.Mixed
+	case types.FillerTypeAnimeCanon:
+		vars.AnimeCanon = markers.AnimeCanon
+	}
+
+	// Generate Filename
+	separator := outputCfg.Separator
+
+	newFilename, err := matcher.GenerateFilenameFromFields(outputCfg.Fields, separator, vars, padding)
+	if err != nil {
+		return types.RenameOperation{}, &types.Event{Type: types.EventError, Message: fmt.Sprintf("Failed to generate filename: %v", err)}
+	}
+
+	return types.RenameOperation{
+		SourcePath: filepath.Join(dir, filename),
+		TargetPath: filepath.Join(dir, newFilename),
+		Episode:    ep,
+		Local:      local,
+		Series:     epMedia.Title,
+		Status:     types.StatusPending,
+	}, nil
+}
+
+// Apply backs up and performs the pending operations of a plan. Operations
+// with any other status are left alone.
+func (r *Renamer) Apply(ctx context.Context, dir string, operations []types.RenameOperation) ([]types.RenameOperation, error) {
+	renameMappings := make(map[string]string)
+	for _, op := range operations {
+		if op.Status == types.StatusPending {
+			renameMappings[filepath.Base(op.SourcePath)] = filepath.Base(op.TargetPath)
+		}
 	}
 
 	// Perform Backup
@@ -224,9 +337,41 @@ func (r *Renamer) Execute(ctx context.Context, dir string, target *types.Target,
 	// Perform Rename
 	r.performRenames(operations)
 
+	// Tagging changed renamed files in place; let the backup recognise them
+	if r.Tag && r.shouldBackup() && len(renameMappings) > 0 {
+		r.updateFingerprints(ctx, dir, operations)
+	}
+
 	return operations, nil
 }
 
+// updateFingerprints refreshes backup fingerprints of successfully renamed files
+func (r *Renamer) updateFingerprints(ctx context.Context, dir string, ops []types.RenameOperation) {
+	updater, ok := r.BackupManager.(types.FingerprintUpdater)
+	if !ok {
+		return
+	}
+	renamed := make(map[string]string)
+	for _, op := range ops {
+		if op.Status == types.StatusSuccess {
+			renamed[filepath.Base(op.SourcePath)] = filepath.Base(op.TargetPath)
+		}
+	}
+	if err := updater.UpdateFingerprints(ctx, dir, renamed); err != nil {
+		r.emit(types.Event{Type: types.EventWarning, Message: fmt.Sprintf("Failed to update backup: %v", err)})
+	}
+}
+
+// resolveSegment finds the segment containing a local episode number
+func (r *Renamer) resolveSegment(num int) (*Segment, int, bool) {
+	for i := range r.Segments {
+		if mapped, ok := r.Segments[i].Resolve(num); ok {
+			return &r.Segments[i], mapped, true
+		}
+	}
+	return nil, 0, false
+}
+
 func (r *Renamer) compilePatterns(target *types.Target) ([]*matcher.Pattern, error) {
 	var patterns []*matcher.Pattern
 	var errs []string
@@ -248,6 +393,16 @@ func (r *Renamer) compilePatterns(target *types.Target) ([]*matcher.Pattern, err
 	return patterns, nil
 }
 
+// smartPadding returns the episode number width fitting the target's media
+// and every segment's media
+func (r *Renamer) smartPadding(media *types.Media) int {
+	padding := r.calculatePadding(media)
+	for _, seg := range r.Segments {
+		padding = max(padding, r.calculatePadding(seg.Media))
+	}
+	return padding
+}
+
 func (r *Renamer) calculatePadding(media *types.Media) int {
 	smartPadding := 2
 	maxEp := media.EpisodeCount
@@ -277,9 +432,12 @@ func MatchResultOffset(globalOffset *int, pattern *types.Pattern) int {
 	return 0
 }
 
+func (r *Renamer) shouldBackup() bool {
+	return !r.DryRun && !r.NoBackup && r.BackupConfig.Enabled
+}
+
 func (r *Renamer) performBackup(ctx context.Context, dir string, mappings map[string]string) error {
-	shouldBackup := !r.DryRun && !r.NoBackup && r.BackupConfig.Enabled
-	if shouldBackup && len(mappings) > 0 {
+	if r.shouldBackup() && len(mappings) > 0 {
 		r.emit(types.Event{Type: types.EventInfo, Message: "Creating backup..."})
 		if err := r.BackupManager.Backup(ctx, dir, mappings); err != nil {
 			return fmt.Errorf("backup failed: %w", err)
@@ -290,29 +448,39 @@ func (r *Renamer) performBackup(ctx context.Context, dir string, mappings map[st
 
 func (r *Renamer) performRenames(ops []types.RenameOperation) {
 	for i, op := range ops {
-		if op.Status == types.StatusSkipped {
+		if op.Status != types.StatusPending {
 			continue
 		}
 		if r.DryRun {
 			continue
 		}
 
-		if err := os.Rename(op.SourcePath, op.TargetPath); err != nil {
+		if err := util.RenameNoReplace(op.SourcePath, op.TargetPath); err != nil {
 			ops[i].Status = types.StatusFailed
 			ops[i].Error = err.Error()
-			r.emit(types.Event{Type: types.EventError, Message: fmt.Sprintf("Failed: %s: %v", filepath.Base(op.SourcePath), err)})
+			r.emit(types.Event{
+				Type:    types.EventError,
+				Message: fmt.Sprintf("Failed: %s: %v", filepath.Base(op.SourcePath), err),
+				Data:    types.RenameEvent{Stage: types.RenameFailed, Operation: ops[i]},
+			})
 		} else {
 			ops[i].Status = types.StatusSuccess
-			r.emit(types.Event{Type: types.EventSuccess, Message: fmt.Sprintf("Renamed: %s → %s", filepath.Base(op.SourcePath), filepath.Base(op.TargetPath))})
+			r.emit(types.Event{
+				Type:    types.EventSuccess,
+				Message: fmt.Sprintf("Renamed: %s → %s", filepath.Base(op.SourcePath), filepath.Base(op.TargetPath)),
+				Data:    types.RenameEvent{Stage: types.RenameApplied, Operation: ops[i]},
+			})
 
 			if r.Tag && op.Episode != nil {
-				r.tagFile(op.TargetPath, op.Episode, ops[i].Series)
+				r.tagFile(op.TargetPath, op.Local, op.Episode, ops[i].Series)
 			}
 		}
 	}
 }
 
-func (r *Renamer) tagFile(path string, ep *types.Episode, show string) {
+// tagFile embeds the metadata of ep into path and reports the file under its
+// local episode number
+func (r *Renamer) tagFile(path string, local int, ep *types.Episode, show string) {
 	info := tagger.TagInfo{
 		Title:       ep.Title,
 		Show:        show,
@@ -320,10 +488,19 @@ func (r *Renamer) tagFile(path string, ep *types.Episode, show string) {
 		EpisodeSort: ep.Number,
 		AirDate:     ep.AirDate,
 	}
+	name := filepath.Base(path)
 	if err := tagger.TagFile(context.Background(), path, info); err != nil {
-		r.emit(types.Event{Type: types.EventWarning, Message: fmt.Sprintf("Tagging failed for %s: %v", filepath.Base(path), err)})
+		r.emit(types.Event{
+			Type:    types.EventWarning,
+			Message: fmt.Sprintf("Tagging failed for %s: %v", name, err),
+			Data:    types.TagEvent{File: name, Episode: local, Status: types.StatusFailed, Error: err.Error()},
+		})
 	} else {
-		r.emit(types.Event{Type: types.EventInfo, Message: fmt.Sprintf("Tagged: %s", filepath.Base(path))})
+		r.emit(types.Event{
+			Type:    types.EventInfo,
+			Message: fmt.Sprintf("Tagged: %s", name),
+			Data:    types.TagEvent{File: name, Episode: local, Status: types.StatusSuccess},
+		})
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/mydehq/autotitle/internal/config"
@@ -134,3 +135,116 @@ func TestRenamer_OffsetZeroOverride(t *testing.T) {
 		t.Errorf("Expected matched episode number 1, got %d", op.Episode.Number)
 	}
 }
+
+func TestRenamer_FillerMarkers(t *testing.T) {
+	media := &types.Media{
+		Title: "Test Series",
+		Episodes: []types.Episode{
+			{Number: 1, Title: "Canon", FillerType: types.FillerTypeCanon},
+			{Number: 2, Title: "Mixed", IsMixed: true},
+			{Number: 3, Title: "Filler", FillerType: types.FillerTypeFiller, IsFiller: true},
+		},
+	}
+
+	target := &config.Target{
+		Patterns: []config.Pattern{
+			{
+				Input: []string{"{{SERIES}} - {{EP_NUM}}"},
+				Output: config.OutputConfig{
+					Fields:    []string{"EP_NUM", "FILLER", "MIXED", "EP_NAME"},
+					Separator: " ",
+					Markers:   &types.MarkerConfig{Filler: "(filler)"},
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	for _, name := range []string{"Test Series - 01.mkv", "Test Series - 02.mkv", "Test Series - 03.mkv"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := New(&MockDB{}, types.BackupConfig{Enabled: false}, []string{"mkv"})
+	r.WithDryRun()
+
+	ops, err := r.Execute(context.Background(), tmpDir, target, media)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	want := map[int]string{
+		1: "01 Canon.mkv",
+		2: "02 [M] Mixed.mkv",
+		3: "03 (filler) Filler.mkv",
+	}
+	for _, op := range ops {
+		if got := filepath.Base(op.TargetPath); got != want[op.Episode.Number] {
+			t.Errorf("episode %d: got %q, want %q", op.Episode.Number, got, want[op.Episode.Number])
+		}
+	}
+}
+
+func TestRenamer_EventPayloads(t *testing.T) {
+	media := &types.Media{
+		Title: "Test Series",
+		Episodes: []types.Episode{
+			{Number: 1, Title: "Pilot"},
+		},
+	}
+
+	target := &config.Target{
+		Patterns: []config.Pattern{
+			{
+				Input: []string{"{{SERIES}} - {{EP_NUM}}"},
+				Output: config.OutputConfig{
+					Fields:    []string{"SERIES", "EP_NAME"},
+					Separator: " - ",
+				},
+			},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	for _, name := range []string{"Test Series - 01.mkv", "Test Series - 001.mkv", "Test Series - 07.mkv"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var events []types.Event
+	r := New(&MockDB{}, types.BackupConfig{Enabled: false}, []string{"mkv"})
+	r.WithEvents(func(e types.Event) { events = append(events, e) })
+
+	if _, err := r.Execute(context.Background(), tmpDir, target, media); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	var stages []types.RenameStage
+	var collision *types.CollisionEvent
+	var missing *types.EpisodeNotFoundEvent
+	for _, e := range events {
+		switch data := e.Data.(type) {
+		case types.RenameEvent:
+			stages = append(stages, data.Stage)
+			if data.Operation.Episode == nil || data.Operation.Episode.Number != 1 {
+				t.Errorf("rename event without episode 1: %+v", data.Operation)
+			}
+		case types.CollisionEvent:
+			collision = &data
+		case types.EpisodeNotFoundEvent:
+			missing = &data
+		}
+	}
+
+	if want := []types.RenameStage{types.RenamePlanned, types.RenameApplied}; !slices.Equal(stages, want) {
+		t.Errorf("rename stages = %v, want %v", stages, want)
+	}
+	if collision == nil || collision.Target != "Test Series - Pilot.mkv" {
+		t.Errorf("collision event = %+v", collision)
+	}
+	if missing == nil || missing.File != "Test Series - 07.mkv" || missing.Number != 7 {
+		t.Errorf("episode-not-found event = %+v", missing)
+	}
+}
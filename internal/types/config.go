@@ -3,6 +3,8 @@ package types
 import (
 	"fmt"
 	"path/filepath"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Config represents the autotitle configuration file
@@ -13,10 +15,95 @@ type Config struct {
 
 // Target represents a rename target in the configuration
 type Target struct {
-	Path      string    `yaml:"path"`
-	URL       string    `yaml:"url"`                  // Provider URL (MAL, TMDB, etc.)
-	FillerURL string    `yaml:"filler_url,omitempty"` // Optional filler source URL
-	Patterns  []Pattern `yaml:"patterns"`
+	Path         string       `yaml:"path"`
+	URL          string       `yaml:"url"`                     // Provider URL (MAL, TMDB, etc.)
+	FallbackURLs []string     `yaml:"fallback_urls,omitempty"` // Extra provider URLs used to fill missing metadata
+	Merge        *MergeConfig `yaml:"merge,omitempty"`         // How metadata from URL and FallbackURLs is combined
+	FillerURLs   StringList   `yaml:"filler_url,omitempty"`    // Optional filler source URL(s)
+	FillerPolicy string       `yaml:"filler_policy,omitempty"` // How several filler sources are combined
+	Patterns     []Pattern    `yaml:"patterns"`
+
+	// Mapping splits local episode numbers across several provider entries,
+	// e.g. absolute numbering over per-cour MAL entries
+	Mapping []MappingSegment `yaml:"mapping,omitempty"`
+
+	// Overrides patch episode data after it is loaded, keyed by episode
+	// range in ParseRanges syntax (e.g. "12", "120-135", "1-3, 7"). Keys
+	// are local episode numbers, also for episodes routed through Mapping.
+	Overrides map[string]EpisodeOverride `yaml:"overrides,omitempty"`
+}
+
+// EpisodeOverride replaces provider data for the episodes it applies to
+type EpisodeOverride struct {
+	Title      string     `yaml:"title,omitempty" json:"title,omitempty"`
+	Filler     *bool      `yaml:"filler,omitempty" json:"filler,omitempty"`           // true = filler, false = canon
+	FillerType FillerType `yaml:"filler_type,omitempty" json:"filler_type,omitempty"` // canon, mixed, filler, anime_canon (wins over filler)
+	AirDate    string     `yaml:"air_date,omitempty" json:"air_date,omitempty"`
+	Skip       bool       `yaml:"skip,omitempty" json:"skip,omitempty"` // Leave matching files untouched
+}
+
+// MappingSegment maps a range of local episode numbers onto a provider entry.
+// The n-th local episode in Episodes becomes provider episode Start+n-1.
+type MappingSegment struct {
+	Episodes   string     `yaml:"episodes"`             // Local episodes, e.g. "25-48"
+	URL        string     `yaml:"url"`                  // Provider URL for this segment
+	Start      int        `yaml:"start,omitempty"`      // Provider episode for the first local episode (default 1)
+	FillerURLs StringList `yaml:"filler_url,omitempty"` // Optional filler source URL(s) for this segment
+}
+
+// Filler policies for combining several filler sources
+const (
+	FillerPolicyFirst       = "first"        // First source that knows an episode wins (default)
+	FillerPolicyUnion       = "union"        // Most filler-like classification wins
+	FillerPolicyPreferCanon = "prefer-canon" // Least filler-like classification wins
+)
+
+// StringList is a list of strings that also accepts a single scalar in YAML
+type StringList []string
+
+// UnmarshalYAML accepts either "value" or ["value", ...]
+func (l *StringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		if value.Value == "" {
+			*l = nil
+			return nil
+		}
+		*l = StringList{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// MarshalYAML writes a single entry as a scalar to keep map files readable
+func (l StringList) MarshalYAML() (any, error) {
+	if len(l) == 1 {
+		return l[0], nil
+	}
+	return []string(l), nil
+}
+
+// Merge policies for combining metadata from several providers
+const (
+	MergeFillGaps   = "fill_gaps"  // Primary wins, fallbacks only fill empty fields
+	MergePrecedence = "precedence" // Per-field provider order decides
+)
+
+// MergeFields lists the field names accepted in MergeConfig.Precedence
+var MergeFields = []string{"title", "title_en", "title_jp", "status", "aliases", "episode_title", "air_date"}
+
+// MergeConfig controls how metadata from several providers is combined
+type MergeConfig struct {
+	Policy string `yaml:"policy,omitempty" json:"policy,omitempty"` // fill_gaps (default) or precedence
+
+	// Precedence maps a field (title, title_en, title_jp, status, aliases,
+	// episode_title, air_date) to an ordered list of provider names.
+	// Providers not listed keep their URL order after the listed ones.
+	Precedence map[string][]string `yaml:"precedence,omitempty" json:"precedence,omitempty"`
 }
 
 // Pattern represents input/output pattern configuration
@@ -27,20 +114,53 @@ type Pattern struct {
 
 // OutputConfig represents output format configuration
 type OutputConfig struct {
-	Fields    []string `yaml:"fields,flow"`
-	Separator string   `yaml:"separator,omitempty"`
-	Offset    int      `yaml:"offset,omitempty"`  // Episode number offset
-	Padding   int      `yaml:"padding,omitempty"` // Episode number padding (e.g. 2 -> 01, 3 -> 001)
+	Fields    []string      `yaml:"fields,flow"`
+	Separator string        `yaml:"separator,omitempty"`
+	Offset    int           `yaml:"offset,omitempty"`  // Episode number offset
+	Padding   int           `yaml:"padding,omitempty"` // Episode number padding (e.g. 2 -> 01, 3 -> 001)
+	Markers   *MarkerConfig `yaml:"markers,omitempty"` // Text used by FILLER, MIXED and ANIME_CANON fields
+}
+
+// Default markers used when a pattern doesn't configure its own
+const (
+	DefaultFillerMarker = "[F]"
+	DefaultMixedMarker  = "[M]"
+)
+
+// MarkerConfig holds the text inserted for each filler classification
+type MarkerConfig struct {
+	Filler     string `yaml:"filler,omitempty"`      // Default: [F]
+	Mixed      string `yaml:"mixed,omitempty"`       // Default: [M]
+	AnimeCanon string `yaml:"anime_canon,omitempty"` // Default: empty
+}
+
+// Resolve returns the markers with defaults applied to unset entries
+func (m *MarkerConfig) Resolve() MarkerConfig {
+	res := MarkerConfig{Filler: DefaultFillerMarker, Mixed: DefaultMixedMarker}
+	if m == nil {
+		return res
+	}
+	if m.Filler != "" {
+		res.Filler = m.Filler
+	}
+	if m.Mixed != "" {
+		res.Mixed = m.Mixed
+	}
+	res.AnimeCanon = m.AnimeCanon
+	return res
 }
 
 // GlobalConfig represents the global configuration file (~/.config/autotitle/config.yml)
 type GlobalConfig struct {
-	MapFile  string        `yaml:"map_file"`
-	Patterns []Pattern     `yaml:"patterns"`
-	Formats  []string      `yaml:"formats"`
-	API      APIConfig     `yaml:"api"`
-	Backup   BackupConfig  `yaml:"backup"`
-	Tagging  TaggingConfig `yaml:"tagging"`
+	MapFile  string         `yaml:"map_file"`
+	Patterns []Pattern      `yaml:"patterns"`
+	Formats  []string       `yaml:"formats"`
+	API      APIConfig      `yaml:"api"`
+	Backup   BackupConfig   `yaml:"backup"`
+	Tagging  TaggingConfig  `yaml:"tagging"`
+	Database DatabaseConfig `yaml:"database,omitempty"`
+	Refresh  RefreshConfig  `yaml:"refresh,omitempty"`
+	Plugins  []string       `yaml:"plugins,omitempty"` // Extra provider plugin executables
 }
 
 // Clone returns a deep copy of the configuration
@@ -64,6 +184,37 @@ func (t *Target) Clone() *Target {
 		return nil
 	}
 	res := *t
+	if len(t.FillerURLs) > 0 {
+		res.FillerURLs = make(StringList, len(t.FillerURLs))
+		copy(res.FillerURLs, t.FillerURLs)
+	}
+	if len(t.FallbackURLs) > 0 {
+		res.FallbackURLs = make([]string, len(t.FallbackURLs))
+		copy(res.FallbackURLs, t.FallbackURLs)
+	}
+	if t.Merge != nil {
+		merge := *t.Merge
+		if len(t.Merge.Precedence) > 0 {
+			merge.Precedence = make(map[string][]string, len(t.Merge.Precedence))
+			for field, order := range t.Merge.Precedence {
+				merge.Precedence[field] = append([]string(nil), order...)
+			}
+		}
+		res.Merge = &merge
+	}
+	if len(t.Mapping) > 0 {
+		res.Mapping = make([]MappingSegment, len(t.Mapping))
+		for i, m := range t.Mapping {
+			m.FillerURLs = append(StringList(nil), m.FillerURLs...)
+			res.Mapping[i] = m
+		}
+	}
+	if len(t.Overrides) > 0 {
+		res.Overrides = make(map[string]EpisodeOverride, len(t.Overrides))
+		for k, v := range t.Overrides {
+			res.Overrides[k] = v
+		}
+	}
 	if len(t.Patterns) > 0 {
 		res.Patterns = make([]Pattern, len(t.Patterns))
 		for i, p := range t.Patterns {
@@ -87,6 +238,10 @@ func (p *Pattern) Clone() *Pattern {
 		res.Output.Fields = make([]string, len(p.Output.Fields))
 		copy(res.Output.Fields, p.Output.Fields)
 	}
+	if p.Output.Markers != nil {
+		markers := *p.Output.Markers
+		res.Output.Markers = &markers
+	}
 	return &res
 }
 
@@ -103,6 +258,29 @@ func (g *GlobalConfig) Clone() GlobalConfig {
 		res.Formats = make([]string, len(g.Formats))
 		copy(res.Formats, g.Formats)
 	}
+	if len(g.API.Providers) > 0 {
+		res.API.Providers = make(map[string]ProviderSettings, len(g.API.Providers))
+		for name, ps := range g.API.Providers {
+			if len(ps.Headers) > 0 {
+				headers := make(map[string]string, len(ps.Headers))
+				for k, v := range ps.Headers {
+					headers[k] = v
+				}
+				ps.Headers = headers
+			}
+			res.API.Providers[name] = ps
+		}
+	}
+	if len(g.Plugins) > 0 {
+		res.Plugins = make([]string, len(g.Plugins))
+		copy(res.Plugins, g.Plugins)
+	}
+	if len(g.Refresh.MaxAge) > 0 {
+		res.Refresh.MaxAge = make(map[string]string, len(g.Refresh.MaxAge))
+		for status, age := range g.Refresh.MaxAge {
+			res.Refresh.MaxAge[status] = age
+		}
+	}
 	return res
 }
 
@@ -35,12 +35,29 @@ func (e ErrDatabaseNotFound) Error() string {
 type ErrConfigInvalid struct {
 	Path   string
 	Reason string
+	Err    error // Underlying error, if any
 }
 
 func (e ErrConfigInvalid) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("invalid config %s: %s: %v", e.Path, e.Reason, e.Err)
+	}
 	return fmt.Sprintf("invalid config %s: %s", e.Path, e.Reason)
 }
 
+func (e ErrConfigInvalid) Unwrap() error {
+	return e.Err
+}
+
+// ErrNoMediaFiles indicates a directory has no media files to work on
+type ErrNoMediaFiles struct {
+	Directory string
+}
+
+func (e ErrNoMediaFiles) Error() string {
+	return fmt.Sprintf("no media files found in %s", e.Directory)
+}
+
 // ErrProviderNotFound indicates no provider matches the given URL
 type ErrProviderNotFound struct {
 	URL string
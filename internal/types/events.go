@@ -0,0 +1,91 @@
+package types
+
+import "context"
+
+// Event payloads. Operations set Event.Data to one of these so consumers can
+// switch on the concrete type instead of parsing Message.
+
+// RenameStage is the point of a rename an event reports
+type RenameStage string
+
+const (
+	RenamePlanned RenameStage = "planned" // Computed, not yet applied (always the case in dry-run)
+	RenameApplied RenameStage = "applied"
+	RenameFailed  RenameStage = "failed"
+)
+
+// RenameEvent reports a single rename operation
+type RenameEvent struct {
+	Stage     RenameStage     `json:"stage"`
+	Operation RenameOperation `json:"operation"`
+}
+
+// EpisodeNotFoundEvent reports a file whose episode is missing from the database
+type EpisodeNotFoundEvent struct {
+	File   string `json:"file"`
+	Number int    `json:"number"` // Episode number parsed from the filename
+	Mapped int    `json:"mapped"` // Number looked up after offset and segment mapping
+}
+
+// CollisionEvent reports a file left alone because another file already
+// renames to the same target
+type CollisionEvent struct {
+	File   string `json:"file"`
+	Target string `json:"target"`
+}
+
+// Backup event actions
+const (
+	BackupActionStored   = "stored"   // A file was added to a new generation
+	BackupActionRestored = "restored" // A file was put back under its original name
+	BackupActionSkipped  = "skipped"  // A conflicting file was left in the backup
+	BackupActionFailed   = "failed"   // A file could not be restored
+	BackupActionPruned   = "pruned"   // A whole generation was deleted
+)
+
+// BackupEvent reports backup and restore progress
+type BackupEvent struct {
+	Action     string `json:"action"` // One of the BackupAction constants
+	File       string `json:"file,omitempty"`
+	Generation string `json:"generation,omitempty"`
+	Done       int    `json:"done,omitempty"`  // Files handled so far
+	Total      int    `json:"total,omitempty"` // Files in the operation
+}
+
+// TagEvent reports the result of embedding metadata into a file. Episode is
+// the local episode number, as in the target's overrides and mappings; the
+// provider number of a mapped episode is only written into the tags.
+type TagEvent struct {
+	File    string          `json:"file"`
+	Episode int             `json:"episode,omitempty"`
+	Status  OperationStatus `json:"status"`           // StatusSuccess, StatusSkipped or StatusFailed
+	Reason  string          `json:"reason,omitempty"` // Why the file was skipped
+	Error   string          `json:"error,omitempty"`
+}
+
+// FetchEvent reports paged provider fetch progress
+type FetchEvent struct {
+	Provider string `json:"provider"`
+	ID       string `json:"id"`
+	Page     int    `json:"page"`
+	Pages    int    `json:"pages,omitempty"` // Total pages, when the API reports it
+	Episodes int    `json:"episodes"`        // Episodes fetched so far
+}
+
+type eventsKey struct{}
+
+// ContextWithEvents returns a context carrying h. Components without an
+// event handler of their own, such as providers, report progress through it.
+func ContextWithEvents(ctx context.Context, h EventHandler) context.Context {
+	if h == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, eventsKey{}, h)
+}
+
+// EmitContext sends e to the handler carried by ctx, if any
+func EmitContext(ctx context.Context, e Event) {
+	if h, ok := ctx.Value(eventsKey{}).(EventHandler); ok {
+		h(e)
+	}
+}
@@ -29,11 +29,13 @@ type Provider interface {
 
 // SearchResult represents a normalized search response
 type SearchResult struct {
-	Provider string
-	ID       string
-	Title    string
-	Year     int
-	URL      string
+	Provider string   `json:"provider"`
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Year     int      `json:"year,omitempty"`
+	URL      string   `json:"url"`
+	Aliases  []string `json:"aliases,omitempty"`  // Alternative titles (English, Japanese, synonyms)
+	Episodes int      `json:"episodes,omitempty"` // Episode count, 0 if unknown
 }
 
 // FillerSource is a source for filler episode data (decoupled from providers)
@@ -47,8 +49,33 @@ type FillerSource interface {
 	// ExtractSlug extracts the series slug from a filler source URL
 	ExtractSlug(url string) (string, error)
 
-	// FetchFillers returns a list of filler episode numbers
-	FetchFillers(ctx context.Context, slug string) ([]int, error)
+	// FetchFillers returns the classification of each episode the source knows about
+	FetchFillers(ctx context.Context, slug string) (map[int]FillerType, error)
+}
+
+// FillerSuggester is implemented by filler sources that can find the list URL
+// for a series title. SuggestURL returns "" when the source has no list.
+type FillerSuggester interface {
+	SuggestURL(ctx context.Context, title string) (string, error)
+}
+
+// Configurable is implemented by components that accept API settings.
+// Providers always implement it; filler sources may opt in.
+type Configurable interface {
+	Configure(cfg *APIConfig)
+}
+
+// ProviderCloner is implemented by providers that can be copied, so that
+// each client configures an instance of its own. Providers without it are
+// shared and configured again before every use.
+type ProviderCloner interface {
+	Clone() Provider
+}
+
+// FillerSourceCloner is the counterpart of ProviderCloner for filler
+// sources
+type FillerSourceCloner interface {
+	Clone() FillerSource
 }
 
 // DatabaseRepository handles media database persistence
@@ -76,6 +103,9 @@ type DatabaseRepository interface {
 
 	// Path returns the database directory path
 	Path() string
+
+	// Close releases the resources held by the repository
+	Close() error
 }
 
 // MediaSummary is a lightweight summary for database listings
@@ -92,8 +122,12 @@ type BackupManager interface {
 	// mappings is oldName -> newName
 	Backup(ctx context.Context, dir string, mappings map[string]string) error
 
-	// Restore restores files from the backup
-	Restore(ctx context.Context, dir string) error
+	// Restore restores files from the backup, undoing the latest generation
+	// or every generation back to opts.Generation
+	Restore(ctx context.Context, dir string, opts RestoreOptions) (*RestoreReport, error)
+
+	// List returns the backup generations of a directory, oldest first
+	List(ctx context.Context, dir string) ([]BackupRecord, error)
 
 	// Clean removes the backup for a specific directory
 	Clean(ctx context.Context, dir string) error
@@ -108,6 +142,28 @@ type BackupManager interface {
 	CleanAll(ctx context.Context) error
 }
 
+// FingerprintUpdater is implemented by backup managers that fingerprint
+// renamed files. The renamer calls it after changing files it renamed (e.g.
+// metadata tagging) so undo still recognises them. renamed maps original
+// names to the names the files have now.
+type FingerprintUpdater interface {
+	UpdateFingerprints(ctx context.Context, dir string, renamed map[string]string) error
+}
+
+// BackupInspector is implemented by backup managers that can report on and
+// maintain the backups in their registry
+type BackupInspector interface {
+	// Usage returns a generation's disk usage
+	Usage(ctx context.Context, gen BackupRecord) BackupUsage
+
+	// Verify checks that a generation is complete and can be restored
+	Verify(ctx context.Context, gen BackupRecord) []BackupIssue
+
+	// Prune drops registry records whose backup is gone and deletes the
+	// generations opts selects
+	Prune(ctx context.Context, opts PruneOptions) (*PruneReport, error)
+}
+
 // ConfigRepository handles configuration loading and saving
 type ConfigRepository interface {
 	// Load loads configuration from a file
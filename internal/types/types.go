@@ -12,17 +12,39 @@ const (
 	MediaTypeTVShow MediaType = "tvshow"
 )
 
+// FillerType classifies an episode's relation to the source material
+type FillerType string
+
+const (
+	FillerTypeCanon      FillerType = "canon"       // Adapted from the source material
+	FillerTypeMixed      FillerType = "mixed"       // Mix of canon and filler content
+	FillerTypeFiller     FillerType = "filler"      // Anime-original content
+	FillerTypeAnimeCanon FillerType = "anime_canon" // Anime-original but considered canon
+)
+
 // Episode represents a single episode in a series
 type Episode struct {
-	Number   int    `json:"number"`
-	Title    string `json:"title"`
-	IsFiller bool   `json:"is_filler,omitempty"`
-	IsMixed  bool   `json:"is_mixed,omitempty"`
-	AirDate  string `json:"air_date,omitempty"`
+	Number     int        `json:"number"`
+	Title      string     `json:"title"`
+	IsFiller   bool       `json:"is_filler,omitempty"`
+	IsMixed    bool       `json:"is_mixed,omitempty"`
+	FillerType FillerType `json:"filler_type,omitempty"`
+	AirDate    string     `json:"air_date,omitempty"`
+	Skip       bool       `json:"-"` // Set by map file overrides; never persisted
+
+	// Sources records which provider each field came from (set when merged)
+	Sources map[string]string `json:"sources,omitempty"`
 }
 
+// MediaSchemaVersion is the current version of persisted Media documents
+const MediaSchemaVersion = 1
+
 // Media is the unified type for all content (anime, movies, TV shows)
 type Media struct {
+	// SchemaVersion is the document version as stored. Repositories upgrade
+	// older documents on load and always save MediaSchemaVersion.
+	SchemaVersion int `json:"schema_version"`
+
 	ID                 string    `json:"id"`
 	Provider           string    `json:"provider"`
 	Title              string    `json:"title"`
@@ -37,18 +59,91 @@ type Media struct {
 	FillerSource       string    `json:"filler_source,omitempty"`
 	LastUpdate         time.Time `json:"last_update"`
 	Episodes           []Episode `json:"episodes,omitempty"`
+
+	// Sources records which provider each field came from (set when merged)
+	Sources map[string]string `json:"sources,omitempty"`
+
+	// Origin records how the entry was generated so it can be refreshed
+	// without the map file
+	Origin *MediaOrigin `json:"origin,omitempty"`
+}
+
+// MediaOrigin holds the generation inputs besides the provider URL
+type MediaOrigin struct {
+	FillerURLs   []string     `json:"filler_urls,omitempty"`
+	FillerPolicy string       `json:"filler_policy,omitempty"`
+	FallbackURLs []string     `json:"fallback_urls,omitempty"`
+	Merge        *MergeConfig `json:"merge,omitempty"`
 }
 
 // APIConfig holds API-related settings
 type APIConfig struct {
 	RateLimit float64 `yaml:"rate_limit"` // Requests per second
 	Timeout   int     `yaml:"timeout"`    // Seconds
+
+	// Providers holds per-provider settings keyed by provider or filler source name
+	Providers map[string]ProviderSettings `yaml:"providers,omitempty"`
+}
+
+// ProviderSettings holds HTTP settings for a single provider or filler source
+type ProviderSettings struct {
+	BaseURL   string            `yaml:"base_url,omitempty"`   // API root (e.g. a self-hosted Jikan mirror)
+	UserAgent string            `yaml:"user_agent,omitempty"` // User-Agent header
+	Proxy     string            `yaml:"proxy,omitempty"`      // Proxy URL (http, https or socks5)
+	Headers   map[string]string `yaml:"headers,omitempty"`    // Extra request headers
+}
+
+// Settings returns the settings for the named provider (zero value if unset)
+func (c *APIConfig) Settings(name string) ProviderSettings {
+	if c == nil {
+		return ProviderSettings{}
+	}
+	return c.Providers[name]
 }
 
 // BackupConfig holds backup-related settings
 type BackupConfig struct {
-	Enabled bool   `yaml:"enabled"`
-	DirName string `yaml:"dir_name"`
+	Enabled  bool   `yaml:"enabled"`
+	DirName  string `yaml:"dir_name"`
+	Mode     string `yaml:"mode,omitempty"`     // "copy" (default) or "journal"
+	Location string `yaml:"location,omitempty"` // "directory" (default) or "central"
+	Keep     int    `yaml:"keep,omitempty"`     // Latest generations kept per directory besides the first; 0 keeps all
+	MaxAge   string `yaml:"max_age,omitempty"`  // Drop generations older than this (e.g. "90d")
+}
+
+// Backup modes
+const (
+	BackupModeCopy    = "copy"    // Hardlink (or copy) the original files
+	BackupModeJournal = "journal" // Record names and fingerprints only
+)
+
+// Backup locations
+const (
+	BackupLocationDirectory = "directory" // In a hidden folder inside each media directory
+	BackupLocationCentral   = "central"   // In the backup store under the cache root
+)
+
+// DatabaseConfig holds database storage settings
+type DatabaseConfig struct {
+	Backend string `yaml:"backend,omitempty"` // "json" (default) or "sqlite"
+}
+
+// Refresh modes
+const (
+	RefreshAuto   = "auto"   // Apply max_age and on_missing_titles (default)
+	RefreshAlways = "always" // Refetch on every run
+	RefreshNever  = "never"  // Only fetch entries that are not cached
+)
+
+// RefreshConfig decides when cached media is fetched again.
+// MaxAge is keyed by provider status (e.g. "Finished Airing") or "default";
+// ages use Go durations plus d/w suffixes ("12h", "7d", "2w").
+// Statuses without a max age keep the built-in rule: finished shows are never
+// refreshed and airing shows wait for the next episode's air date.
+type RefreshConfig struct {
+	Mode            string            `yaml:"mode,omitempty"`
+	MaxAge          map[string]string `yaml:"max_age,omitempty"`
+	OnMissingTitles bool              `yaml:"on_missing_titles,omitempty"` // Refresh when episodes lack titles
 }
 
 // TaggingConfig holds metadata tagging settings
@@ -57,6 +152,27 @@ type TaggingConfig struct {
 	Enabled *bool `yaml:"enabled,omitempty"`
 }
 
+// SetFillerType sets the classification and keeps IsFiller/IsMixed in sync
+func (e *Episode) SetFillerType(t FillerType) {
+	e.FillerType = t
+	e.IsFiller = t == FillerTypeFiller
+	e.IsMixed = t == FillerTypeMixed
+}
+
+// Classification returns the filler type, deriving it from IsFiller/IsMixed
+// for entries cached before FillerType existed
+func (e *Episode) Classification() FillerType {
+	switch {
+	case e.FillerType != "":
+		return e.FillerType
+	case e.IsMixed:
+		return FillerTypeMixed
+	case e.IsFiller:
+		return FillerTypeFiller
+	}
+	return ""
+}
+
 // GetTitle returns the requested title variant with fallback to default
 func (m *Media) GetTitle(variant string) string {
 	switch variant {
@@ -97,16 +213,110 @@ type RenameOperation struct {
 	SourcePath string          `json:"source_path"`
 	TargetPath string          `json:"target_path"`
 	Episode    *Episode        `json:"episode,omitempty"`
+	Local      int             `json:"local,omitempty"`  // Local episode number, before segment mapping
 	Series     string          `json:"series,omitempty"` // Series title (populated after match)
 	Status     OperationStatus `json:"status"`
 	Error      string          `json:"error,omitempty"`
 }
 
-// BackupRecord tracks a backup in the global registry
+// BackupRecord tracks a backup generation in the global registry
 type BackupRecord struct {
-	Path      string    `json:"path"`       // Full path to backup dir
-	SourceDir string    `json:"source_dir"` // Original directory
-	Timestamp time.Time `json:"timestamp"`
+	Path       string    `json:"path"`                 // Full path to the generation's backup dir
+	SourceDir  string    `json:"source_dir"`           // Original directory
+	Generation string    `json:"generation,omitempty"` // Generation ID, sortable oldest to newest
+	Mode       string    `json:"mode,omitempty"`       // Backup mode; empty means copy
+	Timestamp  time.Time `json:"timestamp"`
+	Files      int       `json:"files,omitempty"` // Number of renamed files
+}
+
+// RestoreOptions selects what a restore undoes
+type RestoreOptions struct {
+	// Generation is the oldest generation to undo. Every newer generation is
+	// undone first, so the directory returns to its state before that run.
+	// Empty undoes only the latest generation.
+	Generation string
+
+	// Only limits the restore to files whose original or renamed name
+	// matches a glob, or whose episode number is in a range like "1-3,7".
+	// Empty restores every file.
+	Only string
+
+	// Conflict decides what happens to files changed since the backup:
+	// ConflictSkip (default), ConflictForce or ConflictRenameAside
+	Conflict string
+}
+
+// Restore conflict policies
+const (
+	ConflictSkip        = "skip"         // Leave conflicting files alone and keep them in the backup
+	ConflictForce       = "force"        // Restore anyway, replacing whatever is in the way
+	ConflictRenameAside = "rename-aside" // Restore, moving conflicting files aside first
+)
+
+// Reasons a file conflicts with its backup
+const (
+	ConflictMissing  = "missing"  // The renamed file is gone or was renamed again
+	ConflictModified = "modified" // The renamed file changed since the backup
+	ConflictOccupied = "occupied" // Another file now has the original name
+)
+
+// RestoreConflict describes a file that did not match its backup
+type RestoreConflict struct {
+	Original string `json:"original"`        // Name before the rename
+	Renamed  string `json:"renamed"`         // Name the rename produced
+	Reason   string `json:"reason"`          // ConflictMissing, ConflictModified or ConflictOccupied
+	Action   string `json:"action"`          // "skipped", "forced", "moved-aside" or "failed"
+	Aside    string `json:"aside,omitempty"` // Name the conflicting file was moved to
+	Error    string `json:"error,omitempty"` // Set when the file could not be restored
+}
+
+// RestoreReport summarizes a restore
+type RestoreReport struct {
+	Restored  []string          `json:"restored"` // Original names put back
+	Conflicts []RestoreConflict `json:"conflicts,omitempty"`
+}
+
+// BackupUsage is a backup generation with its disk usage
+type BackupUsage struct {
+	BackupRecord
+	Size    int64 `json:"size"`    // Bytes held only by the backup
+	Linked  int   `json:"linked"`  // Backup files hardlinked to the media they back up
+	Copied  int   `json:"copied"`  // Backup files stored as separate copies
+	Missing bool  `json:"missing"` // The generation's directory no longer exists
+}
+
+// BackupIssue is a problem found while verifying a backup generation
+type BackupIssue struct {
+	SourceDir  string `json:"source_dir"`
+	Generation string `json:"generation"`
+	File       string `json:"file,omitempty"` // Original file name; empty for the generation as a whole
+	Problem    string `json:"problem"`
+	Restorable bool   `json:"restorable"` // Undo can still restore the file, possibly with a conflict policy
+}
+
+// VerifyReport summarizes a backup verification
+type VerifyReport struct {
+	Checked int           `json:"checked"` // Generations verified
+	Issues  []BackupIssue `json:"issues,omitempty"`
+}
+
+// PruneOptions selects what a backup prune deletes besides stale registry
+// records
+type PruneOptions struct {
+	// MaxAge deletes every generation older than this. Zero keeps them.
+	MaxAge time.Duration
+
+	// Orphaned deletes central generations whose media directory is missing.
+	// Off by default: the directory may only be on an unmounted drive.
+	Orphaned bool
+}
+
+// PruneReport lists what a backup prune removed
+type PruneReport struct {
+	Stale    []BackupRecord `json:"stale,omitempty"`    // Registry records whose backup was gone
+	Orphaned []BackupRecord `json:"orphaned,omitempty"` // Central generations of missing directories, deleted only with PruneOptions.Orphaned
+	Removed  []BackupRecord `json:"removed,omitempty"`  // Generations deleted for their age
+	Migrated []string       `json:"migrated,omitempty"` // Directories whose legacy backup became a generation
 }
 
 // EventType represents the type of progress event
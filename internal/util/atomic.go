@@ -0,0 +1,95 @@
+package util
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteFileAtomic writes data to a temporary file in the target directory,
+// syncs it and renames it over path, so readers never see a partial file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }() // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// RenameNoReplace renames oldpath to newpath like os.Rename, but fails with
+// an error wrapping fs.ErrExist instead of replacing an existing file. Where
+// the filesystem supports hardlinks the check is atomic (link, then unlink).
+func RenameNoReplace(oldpath, newpath string) error {
+	err := os.Link(oldpath, newpath)
+	if err == nil {
+		if err := os.Remove(oldpath); err != nil {
+			_ = os.Remove(newpath)
+			return err
+		}
+		return nil
+	}
+
+	if !os.IsExist(err) {
+		// No hardlinks here: check, then rename
+		if _, statErr := os.Lstat(newpath); statErr == nil {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrExist}
+		} else if !os.IsNotExist(statErr) {
+			return statErr
+		}
+		return os.Rename(oldpath, newpath)
+	}
+
+	// A case-only rename on a case-insensitive filesystem finds itself
+	if strings.EqualFold(oldpath, newpath) && sameFile(oldpath, newpath) {
+		return os.Rename(oldpath, newpath)
+	}
+	return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrExist}
+}
+
+func sameFile(a, b string) bool {
+	ai, err := os.Lstat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Lstat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(ai, bi)
+}
+
+// syncDir flushes a directory entry after a rename. Best effort: not every
+// platform supports syncing directories.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Lock timing. Where flock is unavailable, a lock file whose holder stopped
+// refreshing it for LockStale is assumed to belong to a crashed process and
+// is taken over.
+var (
+	LockWait  = 10 * time.Second
+	LockStale = 2 * time.Minute
+)
+
+// lockPoll is the delay between attempts while waiting for a lock
+const lockPoll = 50 * time.Millisecond
+
+// ErrLocked is returned when a lock is still held after LockWait
+var ErrLocked = errors.New("locked by another autotitle process")
+
+// errBusy reports that a single attempt found the lock held
+var errBusy = errors.New("lock busy")
+
+// FileLock is an advisory lock on a file: a flock on unix, elsewhere an
+// exclusively created file holding a token that identifies its holder
+type FileLock struct {
+	path  string
+	state lockState // Platform specific
+}
+
+// Lock acquires the lock file at path, waiting up to LockWait for other
+// holders to release it
+func Lock(path string) (*FileLock, error) {
+	deadline := time.Now().Add(LockWait)
+	for {
+		l, err := tryLock(path)
+		if !errors.Is(err, errBusy) {
+			return l, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s: %w", path, ErrLocked)
+		}
+		time.Sleep(lockPoll)
+	}
+}
@@ -0,0 +1,110 @@
+//go:build !unix
+
+package util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockState identifies the holder and stops its heartbeat
+type lockState struct {
+	token []byte
+	stop  chan struct{}
+}
+
+// tryLock creates path exclusively with a fresh token. A lock file whose
+// heartbeat stopped for LockStale is taken over.
+func tryLock(path string) (*FileLock, error) {
+	token := newLockToken()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > LockStale {
+			takeOverLock(path)
+		}
+		return nil, errBusy
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock file: %w", err)
+	}
+
+	_, err = f.Write(token)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("failed to write lock file: %w", err)
+	}
+
+	l := &FileLock{path: path, state: lockState{token: token, stop: make(chan struct{})}}
+	go l.heartbeat()
+	return l, nil
+}
+
+// takeOverLock moves a stale lock file out of the way. The file is renamed
+// before its token is compared, so if another process replaced the stale
+// lock in the meantime, its live lock is put back instead of removed.
+func takeOverLock(path string) {
+	stale, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	aside := path + "." + string(newLockToken()) + ".stale"
+	if err := os.Rename(path, aside); err != nil {
+		return
+	}
+	if moved, err := os.ReadFile(aside); err == nil && !bytes.Equal(moved, stale) {
+		_ = os.Rename(aside, path)
+		return
+	}
+	_ = os.Remove(aside)
+}
+
+// heartbeat refreshes the lock file's modification time so long runs are
+// not mistaken for crashed ones
+func (l *FileLock) heartbeat() {
+	ticker := time.NewTicker(LockStale / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.state.stop:
+			return
+		case <-ticker.C:
+			if l.owned() {
+				now := time.Now()
+				_ = os.Chtimes(l.path, now, now)
+			}
+		}
+	}
+}
+
+// owned reports whether the lock file still holds this lock's token
+func (l *FileLock) owned() bool {
+	data, err := os.ReadFile(l.path)
+	return err == nil && bytes.Equal(data, l.state.token)
+}
+
+// Unlock releases the lock. A lock file another process has taken over in
+// the meantime is left alone.
+func (l *FileLock) Unlock() error {
+	close(l.state.stop)
+	if !l.owned() {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// newLockToken returns a token unique to this process and call
+func newLockToken() []byte {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return []byte(fmt.Sprintf("%d-%s", os.Getpid(), hex.EncodeToString(b)))
+}
@@ -0,0 +1,141 @@
+package util
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	oldWait, oldStale := LockWait, LockStale
+	t.Cleanup(func() { LockWait, LockStale = oldWait, oldStale })
+	LockWait = 100 * time.Millisecond
+	LockStale = time.Hour
+
+	path := filepath.Join(t.TempDir(), ".lock")
+	l, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	if _, err := Lock(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("second Lock error = %v, want ErrLocked", err)
+	}
+
+	if err := l.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	l, err = Lock(path)
+	if err != nil {
+		t.Fatalf("Lock after Unlock failed: %v", err)
+	}
+	_ = l.Unlock()
+}
+
+func TestLock_Stale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lock")
+	if err := os.WriteFile(path, []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-LockStale - time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Lock(path)
+	if err != nil {
+		t.Fatalf("stale lock was not taken over: %v", err)
+	}
+	_ = l.Unlock()
+}
+
+func TestLock_Exclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lock")
+
+	var holders atomic.Int32
+	var overlap atomic.Bool
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				l, err := Lock(path)
+				if err != nil {
+					t.Errorf("Lock failed: %v", err)
+					return
+				}
+				if holders.Add(1) > 1 {
+					overlap.Store(true)
+				}
+				time.Sleep(time.Millisecond)
+				holders.Add(-1)
+				if err := l.Unlock(); err != nil {
+					t.Errorf("Unlock failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if overlap.Load() {
+		t.Error("lock was held by two holders at once")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFileAtomic failed: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != content {
+			t.Errorf("read %q, %v; want %q", data, err, content)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %d entries", len(entries))
+	}
+}
+
+func TestRenameNoReplace(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.mkv")
+	b := filepath.Join(dir, "b.mkv")
+	c := filepath.Join(dir, "c.mkv")
+	for path, content := range map[string]string{a: "a", b: "b"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := RenameNoReplace(a, b); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("rename onto existing file: error = %v, want fs.ErrExist", err)
+	}
+	if data, _ := os.ReadFile(b); string(data) != "b" {
+		t.Errorf("existing file was replaced: %q", data)
+	}
+
+	if err := RenameNoReplace(a, c); err != nil {
+		t.Fatalf("RenameNoReplace failed: %v", err)
+	}
+	if _, err := os.Stat(a); !os.IsNotExist(err) {
+		t.Errorf("source still exists: %v", err)
+	}
+	if data, _ := os.ReadFile(c); string(data) != "a" {
+		t.Errorf("renamed file holds %q, want %q", data, "a")
+	}
+}
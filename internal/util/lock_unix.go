@@ -0,0 +1,59 @@
+//go:build unix
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockState is the flocked lock file, open for as long as the lock is held
+type lockState struct {
+	file *os.File
+}
+
+// tryLock takes an exclusive flock on path. The kernel drops it when the
+// holder exits, so there are no stale locks to take over.
+func tryLock(path string) (*FileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errBusy
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// Unlock removes the file; a lock won on a removed file guards nothing
+	held, err := f.Stat()
+	if err == nil {
+		var current os.FileInfo
+		if current, err = os.Stat(path); err == nil && !os.SameFile(held, current) {
+			err = errBusy
+		}
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, errBusy
+	}
+
+	_ = f.Truncate(0)
+	_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+	return &FileLock{path: path, state: lockState{file: f}}, nil
+}
+
+// Unlock releases the lock. The file is removed while still locked, so
+// waiters that opened it notice and start over.
+func (l *FileLock) Unlock() error {
+	err := os.Remove(l.path)
+	_ = l.state.file.Close() // Drops the flock
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
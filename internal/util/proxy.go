@@ -0,0 +1,26 @@
+package util
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+// proxySchemes are the proxy URL schemes net/http supports
+var proxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// ParseProxy parses a proxy URL such as "socks5://127.0.0.1:1080",
+// rejecting schemes net/http cannot use and URLs without a host
+func ParseProxy(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", proxy, err)
+	}
+	if !slices.Contains(proxySchemes, u.Scheme) {
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https or socks5", proxy)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", proxy)
+	}
+	return u, nil
+}
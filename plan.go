@@ -0,0 +1,152 @@
+package autotitle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mydehq/autotitle/internal/renamer"
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// RenamePlan holds the renames of a directory as Rename would perform them,
+// to be reviewed and edited before Apply. Operations other than
+// StatusPending are left alone.
+type RenamePlan struct {
+	Operations []RenameOperation
+
+	dir     string
+	target  *types.Target
+	media   *types.Media
+	renamer *renamer.Renamer
+}
+
+// PlanRename computes the renames of the specified directory without
+// touching any file
+func (c *Client) PlanRename(ctx context.Context, path string, opts ...Option) (*RenamePlan, error) {
+	r, target, media, err := c.renamer(ctx, path, c.options(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	ops, err := r.Plan(path, target, media)
+	if err != nil {
+		return nil, err
+	}
+	return &RenamePlan{
+		Operations: ops,
+		dir:        path,
+		target:     target,
+		media:      media,
+		renamer:    r,
+	}, nil
+}
+
+// SetEpisode plans operation i again as if episode had been parsed from its
+// filename. The offset and mapping of the map file still apply.
+func (p *RenamePlan) SetEpisode(i, episode int) error {
+	if err := p.check(i); err != nil {
+		return err
+	}
+	if episode <= 0 {
+		return fmt.Errorf("invalid episode number: %d", episode)
+	}
+
+	source := filepath.Base(p.Operations[i].SourcePath)
+	op, err := p.renamer.PlanFile(p.dir, source, p.target, p.media, episode)
+	if err != nil {
+		return err
+	}
+	if err := p.checkTarget(i, op.TargetPath); err != nil {
+		return err
+	}
+	p.Operations[i] = op
+	return nil
+}
+
+// SetTarget renames operation i to name instead, in the same directory
+func (p *RenamePlan) SetTarget(i int, name string) error {
+	if err := p.check(i); err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		return fmt.Errorf("invalid filename: %q", name)
+	}
+
+	targetPath := filepath.Join(p.dir, name)
+	if err := p.checkTarget(i, targetPath); err != nil {
+		return err
+	}
+	op := &p.Operations[i]
+	op.TargetPath = targetPath
+	op.Status = types.StatusPending
+	if op.SourcePath == op.TargetPath {
+		op.Status = types.StatusSkipped
+	}
+	return nil
+}
+
+// Skip leaves operation i out of Apply
+func (p *RenamePlan) Skip(i int) {
+	if p.check(i) == nil {
+		p.Operations[i].Status = types.StatusSkipped
+	}
+}
+
+// Pending returns the number of operations Apply would perform
+func (p *RenamePlan) Pending() int {
+	n := 0
+	for _, op := range p.Operations {
+		if op.Status == types.StatusPending {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply backs up and renames the pending operations, as Rename does
+func (p *RenamePlan) Apply(ctx context.Context) ([]RenameOperation, error) {
+	return p.renamer.Apply(ctx, p.dir, p.Operations)
+}
+
+func (p *RenamePlan) check(i int) error {
+	if i < 0 || i >= len(p.Operations) {
+		return fmt.Errorf("operation %d out of range", i)
+	}
+	return nil
+}
+
+// checkTarget fails if operation i renaming to targetPath would replace a
+// file: another pending operation's target, or a file on disk that no
+// earlier pending operation renames away
+func (p *RenamePlan) checkTarget(i int, targetPath string) error {
+	source := p.Operations[i].SourcePath
+	if targetPath == source {
+		return nil
+	}
+	for j, op := range p.Operations {
+		if j != i && op.Status == types.StatusPending && op.TargetPath == targetPath {
+			return fmt.Errorf("%s already renames to %s", filepath.Base(op.SourcePath), filepath.Base(targetPath))
+		}
+	}
+
+	info, err := os.Lstat(targetPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if srcInfo, err := os.Lstat(source); err == nil && os.SameFile(srcInfo, info) {
+		return nil // Case-only rename on a case-insensitive filesystem
+	}
+	for _, op := range p.Operations[:i] {
+		if op.Status == types.StatusPending && op.SourcePath == targetPath {
+			return nil // Renamed away before operation i runs
+		}
+	}
+	return fmt.Errorf("%s already exists", filepath.Base(targetPath))
+}
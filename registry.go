@@ -0,0 +1,84 @@
+package autotitle
+
+import (
+	"fmt"
+
+	"github.com/mydehq/autotitle/internal/provider"
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// Extension interfaces. Implement these to plug private data sources,
+// storage or backup strategies into autotitle.
+type (
+	Provider           = types.Provider
+	FillerSource       = types.FillerSource
+	DatabaseRepository = types.DatabaseRepository
+	BackupManager      = types.BackupManager
+	BackupInspector    = types.BackupInspector
+	FingerprintUpdater = types.FingerprintUpdater
+	FillerSuggester    = types.FillerSuggester
+	Configurable       = types.Configurable
+	ProviderCloner     = types.ProviderCloner
+	FillerSourceCloner = types.FillerSourceCloner
+	BackupRecord       = types.BackupRecord
+	RestoreOptions     = types.RestoreOptions
+	PruneOptions       = types.PruneOptions
+	APIConfig          = types.APIConfig
+	FillerType         = types.FillerType
+)
+
+// Filler classifications returned by FillerSource.FetchFillers
+const (
+	FillerTypeCanon      = types.FillerTypeCanon
+	FillerTypeMixed      = types.FillerTypeMixed
+	FillerTypeFiller     = types.FillerTypeFiller
+	FillerTypeAnimeCanon = types.FillerTypeAnimeCanon
+)
+
+// Errors returned by providers and repositories
+type (
+	ErrProviderNotFound     = types.ErrProviderNotFound
+	ErrFillerSourceNotFound = types.ErrFillerSourceNotFound
+	ErrDatabaseNotFound     = types.ErrDatabaseNotFound
+	ErrAPIError             = types.ErrAPIError
+)
+
+// RegisterProvider makes a custom provider available to all operations.
+// Providers are consulted in registration order when resolving a URL, after
+// the built-in ones. It returns an error if the name is already registered.
+func RegisterProvider(p Provider) error {
+	if p == nil {
+		return fmt.Errorf("provider is nil")
+	}
+	if p.Name() == "" {
+		return fmt.Errorf("provider name is empty")
+	}
+	return provider.RegisterProvider(p)
+}
+
+// RegisterFillerSource makes a custom filler source available to all operations.
+// It returns an error if the name is already registered.
+func RegisterFillerSource(s FillerSource) error {
+	if s == nil {
+		return fmt.Errorf("filler source is nil")
+	}
+	if s.Name() == "" {
+		return fmt.Errorf("filler source name is empty")
+	}
+	return provider.RegisterFillerSource(s)
+}
+
+// Provider registry functions
+var (
+	GetProviderForURL     = provider.GetProviderForURL
+	GetFillerSourceForURL = provider.GetFillerSourceForURL
+	GetProvider           = provider.GetProvider
+	GetFillerSource       = provider.GetFillerSource
+	ListProviders         = provider.ListProviders
+	ListFillerSources     = provider.ListFillerSources
+)
+
+// HTTPClientFor returns the HTTP client set via ClientConfig.HTTPClient for
+// the operation ctx belongs to, or fallback. Custom providers send their
+// requests through it.
+var HTTPClientFor = provider.HTTPClient
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mydehq/autotitle"
+	"github.com/mydehq/autotitle/internal/config"
+	"github.com/mydehq/autotitle/internal/database"
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+func TestClient_ExplicitConfigAndCache(t *testing.T) {
+	// 1. Setup Environment: a media dir with a custom map file name and a
+	// private cache dir holding the series
+	mediaDir := t.TempDir()
+	cacheDir := t.TempDir()
+	if _, err := os.Create(filepath.Join(mediaDir, "Show - 01.mkv")); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := database.NewRepository(filepath.Join(cacheDir, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	media := &types.Media{
+		ID:       "101",
+		Provider: "mal",
+		Title:    "Show",
+		Status:   "Finished Airing",
+		Episodes: []types.Episode{{Number: 1, Title: "Pilot"}},
+	}
+	if err := repo.Save(context.Background(), media); err != nil {
+		t.Fatal(err)
+	}
+
+	mapFile := &types.Config{Targets: []types.Target{{
+		Path: ".",
+		URL:  "https://myanimelist.net/anime/101/Show",
+		Patterns: []types.Pattern{{
+			Input:  []string{"Show - {{EP_NUM}}"},
+			Output: types.OutputConfig{Fields: []string{"SERIES", "EP_NUM", "EP_NAME"}, Separator: " - "},
+		}},
+	}}}
+	if err := config.Save(filepath.Join(mediaDir, "series.yml"), mapFile); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2. Configure the client without touching the global config or cache
+	cfg := config.GetDefaults()
+	cfg.MapFile = "series.yml"
+	cfg.Refresh.Mode = types.RefreshNever
+	noTag := false
+	cfg.Tagging.Enabled = &noTag
+
+	var events []autotitle.Event
+	client := autotitle.NewClient(autotitle.ClientConfig{
+		Config:   &cfg,
+		CacheDir: cacheDir,
+		Events:   func(e autotitle.Event) { events = append(events, e) },
+	})
+
+	// 3. Execute
+	ops, err := client.Rename(context.Background(), mediaDir, autotitle.WithDryRun())
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	// 4. Verify
+	if len(ops) != 1 || filepath.Base(ops[0].TargetPath) != "Show - 01 - Pilot.mkv" {
+		t.Fatalf("unexpected operations: %+v", ops)
+	}
+	planned := false
+	for _, e := range events {
+		if data, ok := e.Data.(autotitle.RenameEvent); ok && data.Stage == autotitle.RenamePlanned {
+			planned = true
+		}
+	}
+	if !planned {
+		t.Errorf("client event handler did not receive the planned rename: %+v", events)
+	}
+
+	path, err := client.DBPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(cacheDir, "db") {
+		t.Errorf("DBPath = %s, want %s", path, filepath.Join(cacheDir, "db"))
+	}
+}
+
+func TestClient_OwnProviderSettings(t *testing.T) {
+	// 1. Setup Environment: two Jikan mirrors answering with different titles
+	mirror := func(title string) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"data":[{"mal_id":1,"title":%q,"url":"https://myanimelist.net/anime/1"}]}`, title)
+		}))
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+	newClient := func(baseURL string) *autotitle.Client {
+		cfg := config.GetDefaults()
+		cfg.API.RateLimit = 1000
+		cfg.API.Providers = map[string]types.ProviderSettings{"mal": {BaseURL: baseURL}}
+		client := autotitle.NewClient(autotitle.ClientConfig{Config: &cfg, CacheDir: t.TempDir()})
+		t.Cleanup(func() { _ = client.Close() })
+		return client
+	}
+	clients := map[string]*autotitle.Client{
+		"First":  newClient(mirror("First")),
+		"Second": newClient(mirror("Second")),
+	}
+
+	// 2. Execute: both clients search concurrently
+	var wg sync.WaitGroup
+	for want, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 5 {
+				results, err := client.Search(context.Background(), "show", autotitle.WithProvider("mal"))
+				if err != nil {
+					t.Errorf("Search failed: %v", err)
+					return
+				}
+				// 3. Verify: each client keeps talking to its own mirror
+				if len(results) != 1 || results[0].Title != want {
+					t.Errorf("client for %s got %+v", want, results)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestClient_Close(t *testing.T) {
+	cfg := config.GetDefaults()
+	cfg.Database.Backend = database.BackendSQLite
+	client := autotitle.NewClient(autotitle.ClientConfig{Config: &cfg, CacheDir: t.TempDir()})
+
+	if _, err := client.DBList(context.Background(), ""); err != nil {
+		t.Fatalf("DBList failed: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := client.DBList(context.Background(), ""); !errors.Is(err, autotitle.ErrClientClosed) {
+		t.Errorf("DBList after Close: err = %v, want %v", err, autotitle.ErrClientClosed)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("second Close failed: %v", err)
+	}
+}
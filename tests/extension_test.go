@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mydehq/autotitle"
+)
+
+// The extension interfaces must be implementable with the autotitle
+// package alone, as code outside the module has no access to internal/types
+
+type stubProvider struct{ name string }
+
+func (p stubProvider) Name() string              { return p.name }
+func (p stubProvider) Type() autotitle.MediaType { return "anime" }
+func (p stubProvider) MatchesURL(url string) bool {
+	return strings.HasPrefix(url, "stub://"+p.name+"/")
+}
+func (p stubProvider) ExtractID(string) (string, error) { return "1", nil }
+func (p stubProvider) Configure(*autotitle.APIConfig)   {}
+func (p stubProvider) FetchMedia(context.Context, string) (*autotitle.Media, error) {
+	return &autotitle.Media{}, nil
+}
+func (p stubProvider) Search(context.Context, string) ([]autotitle.SearchResult, error) {
+	return nil, nil
+}
+
+type stubFillerSource struct{}
+
+func (stubFillerSource) Name() string                       { return "stub-fillers" }
+func (stubFillerSource) MatchesURL(string) bool             { return false }
+func (stubFillerSource) ExtractSlug(string) (string, error) { return "", nil }
+func (stubFillerSource) FetchFillers(context.Context, string) (map[int]autotitle.FillerType, error) {
+	return map[int]autotitle.FillerType{1: autotitle.FillerTypeFiller}, nil
+}
+
+type stubBackupManager struct{}
+
+func (m *stubBackupManager) Backup(context.Context, string, map[string]string) error { return nil }
+func (m *stubBackupManager) Restore(context.Context, string, autotitle.RestoreOptions) (*autotitle.RestoreReport, error) {
+	return &autotitle.RestoreReport{}, nil
+}
+func (m *stubBackupManager) List(context.Context, string) ([]autotitle.BackupRecord, error) {
+	return nil, nil
+}
+func (m *stubBackupManager) Clean(context.Context, string) error { return nil }
+func (m *stubBackupManager) WithEvents(autotitle.EventHandler) autotitle.BackupManager {
+	return m
+}
+func (m *stubBackupManager) ListAll(context.Context) ([]autotitle.BackupRecord, error) {
+	return nil, nil
+}
+func (m *stubBackupManager) CleanAll(context.Context) error { return nil }
+
+var (
+	_ autotitle.Provider      = stubProvider{}
+	_ autotitle.FillerSource  = stubFillerSource{}
+	_ autotitle.BackupManager = (*stubBackupManager)(nil)
+)
+
+func TestRegisterProvider_Concurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := range 8 {
+		name := fmt.Sprintf("stub-concurrent-%d", i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := autotitle.RegisterProvider(stubProvider{name: name}); err != nil {
+				t.Errorf("RegisterProvider(%s) failed: %v", name, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = autotitle.GetProviderForURL("stub://" + name + "/1")
+			_ = autotitle.ListProviders()
+		}()
+	}
+	wg.Wait()
+
+	for i := range 8 {
+		url := fmt.Sprintf("stub://stub-concurrent-%d/1", i)
+		if _, err := autotitle.GetProviderForURL(url); err != nil {
+			t.Errorf("provider for %s not registered: %v", url, err)
+		}
+	}
+	if err := autotitle.RegisterProvider(stubProvider{name: "stub-concurrent-0"}); err == nil {
+		t.Error("registering a duplicate name should fail")
+	}
+}
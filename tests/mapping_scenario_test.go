@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mydehq/autotitle"
+	"github.com/mydehq/autotitle/internal/config"
+	"github.com/mydehq/autotitle/internal/database"
+	"github.com/mydehq/autotitle/internal/renamer"
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+func TestScenario_AbsoluteNumberingAcrossSeasons(t *testing.T) {
+	// 1. Setup Environment: a release group numbers both cours absolutely
+	tmpDir := t.TempDir()
+	files := []string{
+		"[Group] Show - 01.mkv",
+		"[Group] Show - 24.mkv",
+		"[Group] Show - 25.mkv",
+		"[Group] Show - 48.mkv",
+	}
+	for _, f := range files {
+		if _, err := os.Create(filepath.Join(tmpDir, f)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2. Two provider entries, one per cour, each numbered from 1
+	season := func(title string) *types.Media {
+		m := &types.Media{Title: title, EpisodeCount: 24}
+		for i := 1; i <= 24; i++ {
+			m.Episodes = append(m.Episodes, types.Episode{Number: i, Title: fmt.Sprintf("%s Ep %d", title, i)})
+		}
+		return m
+	}
+	first, second := season("Show"), season("Show Part 2")
+
+	// 3. Configure Target
+	target := &types.Target{
+		Path: tmpDir,
+		Patterns: []types.Pattern{
+			{
+				Input: []string{"[Group] Show - {{EP_NUM}}.{{EXT}}"},
+				Output: types.OutputConfig{
+					Fields: []string{"SERIES", " - ", "EP_NUM", " - ", "EP_NAME"},
+				},
+			},
+		},
+	}
+
+	local := make([]int, 0, 24)
+	for i := 25; i <= 48; i++ {
+		local = append(local, i)
+	}
+
+	// 4. Execute
+	mockDB := &MockDB{path: filepath.Join(tmpDir, "db")}
+	r := renamer.New(mockDB, types.BackupConfig{Enabled: false}, []string{"mkv"})
+	r.WithSegments([]renamer.Segment{{Episodes: local, Start: 1, Media: second}})
+
+	ops, err := r.Execute(context.Background(), tmpDir, target, first)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	// 5. Verify
+	expected := map[string]string{
+		"[Group] Show - 01.mkv": "Show - 01 - Show Ep 1.mkv",
+		"[Group] Show - 24.mkv": "Show - 24 - Show Ep 24.mkv",
+		"[Group] Show - 25.mkv": "Show Part 2 - 01 - Show Part 2 Ep 1.mkv",
+		"[Group] Show - 48.mkv": "Show Part 2 - 24 - Show Part 2 Ep 24.mkv",
+	}
+	if len(ops) != len(expected) {
+		t.Fatalf("expected %d operations, got %d", len(expected), len(ops))
+	}
+	for _, op := range ops {
+		src := filepath.Base(op.SourcePath)
+		if want := expected[src]; filepath.Base(op.TargetPath) != want {
+			t.Errorf("%s → %s, want %s", src, filepath.Base(op.TargetPath), want)
+		}
+		if src == "[Group] Show - 48.mkv" && (op.Local != 48 || op.Episode.Number != 24) {
+			t.Errorf("%s: local %d, episode %d; want 48, 24", src, op.Local, op.Episode.Number)
+		}
+	}
+}
+
+func TestScenario_OverridesOnMappedEpisodes(t *testing.T) {
+	// 1. Setup Environment: episodes 3-4 belong to a second entry
+	mediaDir := t.TempDir()
+	cacheDir := t.TempDir()
+	for _, name := range []string{"Show - 01.mkv", "Show - 03.mkv", "Show - 04.mkv"} {
+		if _, err := os.Create(filepath.Join(mediaDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, err := database.NewRepository(filepath.Join(cacheDir, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []*types.Media{
+		{ID: "101", Provider: "mal", Title: "Show", Status: "Finished Airing",
+			Episodes: []types.Episode{{Number: 1, Title: "Pilot"}, {Number: 2, Title: "Second"}}},
+		{ID: "102", Provider: "mal", Title: "Show Part 2", Status: "Finished Airing",
+			Episodes: []types.Episode{{Number: 1, Title: "Return"}, {Number: 2, Title: "Finale"}}},
+	} {
+		if err := repo.Save(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2. Overrides are keyed by the local numbers, mapped or not
+	mapFile := &types.Config{Targets: []types.Target{{
+		Path:    ".",
+		URL:     "https://myanimelist.net/anime/101/Show",
+		Mapping: []types.MappingSegment{{Episodes: "3-4", URL: "https://myanimelist.net/anime/102/Show_Part_2"}},
+		Overrides: map[string]types.EpisodeOverride{
+			"1": {Title: "First"},
+			"3": {Title: "Comeback"},
+			"4": {Skip: true},
+		},
+		Patterns: []types.Pattern{{
+			Input:  []string{"Show - {{EP_NUM}}"},
+			Output: types.OutputConfig{Fields: []string{"SERIES", "EP_NUM", "EP_NAME"}, Separator: " - "},
+		}},
+	}}}
+	if err := config.Save(filepath.Join(mediaDir, "_autotitle.yml"), mapFile); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.GetDefaults()
+	cfg.Refresh.Mode = types.RefreshNever
+	noTag := false
+	cfg.Tagging.Enabled = &noTag
+	client := autotitle.NewClient(autotitle.ClientConfig{Config: &cfg, CacheDir: cacheDir})
+
+	// 3. Execute
+	plan, err := client.PlanRename(context.Background(), mediaDir, autotitle.WithNoBackup())
+	if err != nil {
+		t.Fatalf("PlanRename failed: %v", err)
+	}
+
+	// 4. Verify
+	expected := map[string]string{
+		"Show - 01.mkv": "Show - 01 - First.mkv",
+		"Show - 03.mkv": "Show Part 2 - 01 - Comeback.mkv",
+	}
+	for _, op := range plan.Operations {
+		src := filepath.Base(op.SourcePath)
+		want, ok := expected[src]
+		if !ok {
+			if op.Status != types.StatusSkipped {
+				t.Errorf("%s: status = %s, want %s", src, op.Status, types.StatusSkipped)
+			}
+			continue
+		}
+		if got := filepath.Base(op.TargetPath); got != want {
+			t.Errorf("%s → %s, want %s", src, got, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mydehq/autotitle"
+	"github.com/mydehq/autotitle/internal/config"
+	"github.com/mydehq/autotitle/internal/database"
+	"github.com/mydehq/autotitle/internal/types"
+)
+
+// setupPlanDir creates a directory of three episodes with a map file and a
+// client whose cache knows the series
+func setupPlanDir(t *testing.T) (*autotitle.Client, string) {
+	t.Helper()
+	mediaDir := t.TempDir()
+	cacheDir := t.TempDir()
+	for _, name := range []string{"Show - 01.mkv", "Show - 02.mkv", "Show - 03.mkv"} {
+		if _, err := os.Create(filepath.Join(mediaDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo, err := database.NewRepository(filepath.Join(cacheDir, "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	media := &types.Media{
+		ID:       "101",
+		Provider: "mal",
+		Title:    "Show",
+		Status:   "Finished Airing",
+		Episodes: []types.Episode{
+			{Number: 1, Title: "Pilot"},
+			{Number: 2, Title: "Second"},
+			{Number: 3, Title: "Third"},
+		},
+	}
+	if err := repo.Save(context.Background(), media); err != nil {
+		t.Fatal(err)
+	}
+
+	mapFile := &types.Config{Targets: []types.Target{{
+		Path: ".",
+		URL:  "https://myanimelist.net/anime/101/Show",
+		Patterns: []types.Pattern{{
+			Input:  []string{"Show - {{EP_NUM}}"},
+			Output: types.OutputConfig{Fields: []string{"SERIES", "EP_NUM", "EP_NAME"}, Separator: " - "},
+		}},
+	}}}
+	if err := config.Save(filepath.Join(mediaDir, "_autotitle.yml"), mapFile); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.GetDefaults()
+	cfg.Refresh.Mode = types.RefreshNever
+	noTag := false
+	cfg.Tagging.Enabled = &noTag
+	return autotitle.NewClient(autotitle.ClientConfig{Config: &cfg, CacheDir: cacheDir}), mediaDir
+}
+
+func TestRenamePlan_EditAndApply(t *testing.T) {
+	// 1. Setup Environment
+	client, mediaDir := setupPlanDir(t)
+
+	// 2. Plan: nothing is touched yet
+	plan, err := client.PlanRename(context.Background(), mediaDir, autotitle.WithNoBackup())
+	if err != nil {
+		t.Fatalf("PlanRename failed: %v", err)
+	}
+	if len(plan.Operations) != 3 || plan.Pending() != 3 {
+		t.Fatalf("unexpected plan: %+v", plan.Operations)
+	}
+	if _, err := os.Stat(filepath.Join(mediaDir, "Show - 01.mkv")); err != nil {
+		t.Fatalf("planning touched files: %v", err)
+	}
+
+	// 3. Edit: episode 02 is really episode 3, which collides until the
+	// original episode 3 is skipped
+	if err := plan.SetEpisode(1, 3); err == nil {
+		t.Error("SetEpisode should reject a target another rename already uses")
+	}
+	plan.Skip(2)
+	if err := plan.SetEpisode(1, 3); err != nil {
+		t.Fatalf("SetEpisode failed: %v", err)
+	}
+	if err := plan.SetEpisode(0, 99); err == nil {
+		t.Error("SetEpisode should fail for an episode missing from the database")
+	}
+	if err := plan.SetTarget(0, "../escape.mkv"); err == nil {
+		t.Error("SetTarget should reject names outside the directory")
+	}
+	if err := plan.SetTarget(0, "Custom.mkv"); err != nil {
+		t.Fatalf("SetTarget failed: %v", err)
+	}
+
+	// 4. Apply
+	ops, err := plan.Apply(context.Background())
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	// 5. Verify
+	wantStatus := []types.OperationStatus{types.StatusSuccess, types.StatusSuccess, types.StatusSkipped}
+	for i, op := range ops {
+		if op.Status != wantStatus[i] {
+			t.Errorf("operation %d: status = %s, want %s", i, op.Status, wantStatus[i])
+		}
+	}
+	for _, name := range []string{"Custom.mkv", "Show - 03 - Third.mkv", "Show - 03.mkv"} {
+		if _, err := os.Stat(filepath.Join(mediaDir, name)); err != nil {
+			t.Errorf("expected %s after apply: %v", name, err)
+		}
+	}
+}
+
+func TestRenamePlan_RefusesOverwrite(t *testing.T) {
+	client, mediaDir := setupPlanDir(t)
+	episode3 := filepath.Join(mediaDir, "Show - 03.mkv")
+	if err := os.WriteFile(episode3, []byte("episode 3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := client.PlanRename(context.Background(), mediaDir, autotitle.WithNoBackup())
+	if err != nil {
+		t.Fatalf("PlanRename failed: %v", err)
+	}
+
+	// Episode 3 stays where it is, so its name is taken
+	plan.Skip(2)
+	if err := plan.SetTarget(0, "Show - 03.mkv"); err == nil {
+		t.Error("SetTarget should reject a file that stays on disk")
+	}
+	// Episode 1 is renamed away before episode 2, freeing its name
+	if err := plan.SetTarget(1, "Show - 01.mkv"); err != nil {
+		t.Errorf("SetTarget onto a file renamed earlier failed: %v", err)
+	}
+
+	// A file appearing after planning is not replaced either
+	intruder := filepath.Join(mediaDir, "Show - 01 - Pilot.mkv")
+	if err := os.WriteFile(intruder, []byte("unrelated"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ops, err := plan.Apply(context.Background())
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if ops[0].Status != types.StatusFailed {
+		t.Errorf("rename onto an existing file: status = %s, want %s", ops[0].Status, types.StatusFailed)
+	}
+	if data, _ := os.ReadFile(intruder); string(data) != "unrelated" {
+		t.Errorf("existing file was overwritten: %q", data)
+	}
+	if data, _ := os.ReadFile(episode3); string(data) != "episode 3" {
+		t.Errorf("untouched file was overwritten: %q", data)
+	}
+}
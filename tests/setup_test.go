@@ -12,6 +12,7 @@ type MockDB struct {
 }
 
 func (m *MockDB) Path() string                                       { return m.path }
+func (m *MockDB) Close() error                                       { return nil }
 func (m *MockDB) Save(ctx context.Context, media *types.Media) error { return nil }
 func (m *MockDB) Load(ctx context.Context, provider, id string) (*types.Media, error) {
 	return nil, nil
@@ -40,7 +40,7 @@ if [ -d ".autotitle_backup" ]; then
 else
     fail "Local backup folder missing"
 fi
-if [ -f ".autotitle_backup/mappings.json" ]; then
+if ls .autotitle_backup/*/mappings.json >/dev/null 2>&1; then
     log "✔ Local mappings.json created"
 else
     fail "Local mappings.json missing"